@@ -1,28 +1,266 @@
 package cmd
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/apikey"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/audit"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/auth"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/captcha"
 	"github.com/Fortress-Digital/go-rest-skeleton/internal/config"
 	"github.com/Fortress-Digital/go-rest-skeleton/internal/handler"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/hook"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/invite"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/job"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/lockout"
 	"github.com/Fortress-Digital/go-rest-skeleton/internal/log"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/mail"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/model"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/ratelimit"
 	"github.com/Fortress-Digital/go-rest-skeleton/internal/route"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/scheduler"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/secret"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/session"
 	"github.com/Fortress-Digital/go-rest-skeleton/internal/supabase"
 	"github.com/Fortress-Digital/go-rest-skeleton/internal/validation"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"log/slog"
+	"time"
 )
 
-func Execute(log log.LoggerInterface) error {
-	cfg, err := config.NewConfig()
+func serveCommand() *command {
+	return &command{
+		name:    "serve",
+		summary: "Start the HTTP server, job worker and scheduler.",
+		run: func(c *cli, args []string) error {
+			if err := exactArgs(args, 0); err != nil {
+				return err
+			}
+
+			cfg, err := c.config()
+			if err != nil {
+				return err
+			}
+
+			log, level, err := c.logger(cfg)
+			if err != nil {
+				return err
+			}
+
+			return serve(cfg, c.loadOptions(), log, level)
+		},
+	}
+}
+
+// serve builds every component before starting the background ones last,
+// so a failing step leaves nothing running. Until the server takes them
+// over, the components built so far are released on every error.
+func serve(cfg *config.Config, options config.LoadOptions, log log.LoggerInterface, level *slog.LevelVar) (err error) {
+	var release []ShutdownFunc
+	defer func() {
+		if err != nil && len(release) > 0 {
+			_ = shutdownAll(release)
+		}
+	}()
+
+	live := config.NewLive(cfg)
+	live.Subscribe(reloadLogLevel(level))
+
+	interval := time.Duration(0)
+	if cfg.Reload.Watch {
+		interval = time.Duration(cfg.Reload.Interval) * time.Second
+	}
+
+	watcher := config.NewWatcher(live, options, log, interval)
+
+	var db *gorm.DB
+	if cfg.Database.Enabled {
+		db, err = model.NewDB(cfg, log)
+		if err != nil {
+			log.Error("Database error", err)
+			return err
+		}
+	}
+
+	authClient := supabase.NewAuthClient(cfg.Supabase.Url, cfg.Supabase.Key)
+	storage := supabase.NewStorageClient(cfg.Supabase.Url, cfg.Supabase.Key)
+	passwords, err := newPasswordPolicy(cfg.Passwords)
 	if err != nil {
-		log.Error("Config error", err)
+		log.Error("Password policy error", err)
 		return err
 	}
 
-	auth := supabase.NewAuthClient(cfg.Supabase.Url, cfg.Supabase.Key)
-	validator := validation.NewValidator()
-	handler := handler.NewHandler(cfg, auth, validator)
+	validator := validation.NewValidator(validation.WithPasswordPolicy(passwords))
+
+	mailer, err := mail.NewMailer(cfg.Mail, log)
+	if err != nil {
+		log.Error("Mailer error", err)
+		return err
+	}
+
+	release = append(release, closeMailer(mailer))
+
+	renderer, err := mail.NewRenderer(cfg.Application.Name, cfg.Mail.DefaultLocale)
+	if err != nil {
+		log.Error("Mail templates error", err)
+		return err
+	}
+
+	templateMailer := mail.NewTemplateMailer(mailer, renderer, cfg.Mail.From)
+
+	store, err := newJobStore(cfg.Queue, db)
+	if err != nil {
+		log.Error("Queue error", err)
+		return err
+	}
+
+	queue := job.NewQueue(store, cfg.Queue.MaxAttempts)
+	registry := job.NewRegistry()
+	mail.RegisterJobs(registry, templateMailer)
+
+	worker := job.NewWorker(store, registry, log, job.WorkerOptions{
+		Concurrency:    cfg.Queue.Concurrency,
+		PollInterval:   time.Duration(cfg.Queue.PollInterval) * time.Second,
+		RetryBackoff:   time.Duration(cfg.Queue.RetryBackoff) * time.Second,
+		MaxBackoff:     time.Duration(cfg.Queue.MaxRetryBackoff) * time.Second,
+		ReserveTimeout: time.Duration(cfg.Queue.ReserveTimeout) * time.Second,
+	})
+
+	limits, closeLimits, err := newRateLimitStore(cfg.RateLimitStore, db)
+	if err != nil {
+		log.Error("Rate limit store error", err)
+		return err
+	}
+
+	release = append(release, closeLimits)
+
+	var tasks *scheduler.Scheduler
+	if cfg.Scheduler.Enabled {
+		retention := time.Duration(cfg.Queue.Retention) * 24 * time.Hour
+		revokedRetention := time.Duration(cfg.Sessions.RevokedRetention) * time.Second
+
+		tasks, err = newScheduler(cfg.Scheduler, db, store, retention, revokedRetention, log)
+		if err != nil {
+			log.Error("Scheduler error", err)
+			return err
+		}
+	}
+
+	limiter := ratelimit.NewLimiter(limits)
+
+	var tracker *lockout.Tracker
+	if cfg.Lockout.Enabled {
+		tracker = lockout.NewTracker(limits, map[string]lockout.Policy{
+			lockout.KindEmail: lockout.NewPolicy(cfg.Lockout.Email),
+			lockout.KindIP:    lockout.NewPolicy(cfg.Lockout.IP),
+		})
+	}
+
+	// Revocations are only checked when sessions are tracked. A nil manager
+	// must not be wrapped in the interface.
+	var sessions *session.Manager
+	var revocations auth.Revocations
+	if cfg.Sessions.Enabled {
+		sessionStore, err := newSessionStore(cfg.Sessions, db)
+		if err != nil {
+			log.Error("Session store error", err)
+			return err
+		}
+
+		cipher, err := secret.NewCipher(cfg.Sessions.EncryptionKey)
+		if err != nil {
+			log.Error("Session encryption key error", err)
+			return err
+		}
+
+		lifetime := time.Duration(cfg.Sessions.Lifetime) * time.Second
+		refreshBefore := time.Duration(cfg.Sessions.RefreshBefore) * time.Second
+		sessions = session.NewManager(sessionStore, authClient, cfg.Supabase.JwtSecret, cipher, lifetime, refreshBefore)
+		revocations = sessions
+	}
+
+	var invitations invite.Store
+	if cfg.Registration.Mode == "invite" {
+		if db == nil {
+			return errors.New("registration: invitations require the database to be enabled")
+		}
+
+		invitations = invite.NewDatabaseStore(db)
+	}
+
+	deps := route.Dependencies{
+		Handler:        handler.NewHandler(cfg, authClient, validator, mail.NewQueuedMailer(queue), log, tracker, limiter, sessions, invitations),
+		HookHandler:    handler.NewHookHandler(newHooks(cfg, db), log),
+		StorageHandler: handler.NewStorageHandler(cfg, storage, validator),
+		RateLimiter:    limiter,
+		Authenticators: []auth.Authenticator{
+			auth.NewJWTAuthenticator(cfg.Supabase.JwtSecret, revocations),
+		},
+		Log: log,
+	}
+
+	if sessions != nil {
+		deps.SessionHandler = handler.NewSessionHandler(cfg, authClient, sessions, log)
+		deps.Authenticators = append(deps.Authenticators, session.NewAuthenticator(sessions, cfg.Sessions.CookieName))
+	}
+
+	if invitations != nil {
+		deps.InvitationHandler = handler.NewInvitationHandler(cfg, invitations, validator, audit.NewLogRecorder(log))
+	}
+
+	if tracker != nil {
+		deps.LockoutHandler = handler.NewLockoutHandler(tracker, audit.NewLogRecorder(log))
+	}
+
+	if cfg.APIKeys.Enabled {
+		if db == nil {
+			return errors.New("api_keys: API keys require the database to be enabled")
+		}
+
+		keys := apikey.NewDatabaseStore(db)
+		touchInterval := time.Duration(cfg.APIKeys.TouchInterval) * time.Second
+
+		deps.APIKeyHandler = handler.NewAPIKeyHandler(cfg, keys, validator)
+		deps.Authenticators = append(deps.Authenticators, apikey.NewAuthenticator(keys, touchInterval))
+	}
+
+	if cfg.Captcha.Enabled && !cfg.Captcha.Forward {
+		deps.Captcha, err = captcha.NewVerifier(cfg.Captcha.Provider, cfg.Captcha.Secret, time.Duration(cfg.Captcha.Timeout)*time.Second)
+		if err != nil {
+			log.Error("Captcha error", err)
+			return err
+		}
+	}
+
+	if cfg.Supabase.Hooks.Secret != "" {
+		tolerance := time.Duration(cfg.Supabase.Hooks.Tolerance) * time.Second
+		deps.WebhookVerifier, err = supabase.NewWebhookVerifier(cfg.Supabase.Hooks.Secret, tolerance)
+		if err != nil {
+			log.Error("Webhook verifier error", err)
+			return err
+		}
+	}
+
+	router := route.NewRouter(live, deps)
 
-	router := route.NewRouter(cfg, handler)
+	watcher.Start()
+	worker.Start()
 
-	err = NewServer(cfg, router, log)
+	shutdown := []ShutdownFunc{watcher.Shutdown, worker.Shutdown}
+	if tasks != nil {
+		tasks.Start()
+
+		// Stop scheduling before the worker drains, as tasks may enqueue jobs.
+		shutdown = append([]ShutdownFunc{tasks.Shutdown}, shutdown...)
+	}
+
+	// The server shuts everything down from here on, on failure too.
+	shutdown = append(shutdown, release...)
+	release = nil
+
+	err = NewServer(cfg, router, log, shutdown...)
 	if err != nil {
 		log.Error("NewServer error", err)
 		return err
@@ -30,3 +268,134 @@ func Execute(log log.LoggerInterface) error {
 
 	return nil
 }
+
+// newHooks selects the Supabase Auth hook implementations served by the
+// application. Hooks left nil respond with a 404 if Supabase calls them.
+func newHooks(cfg *config.Config, db *gorm.DB) supabase.Hooks {
+	hooks := supabase.Hooks{
+		BeforeUserCreated: hook.NewRegistrationHook(cfg.Registration, cfg.Supabase.JwtSecret),
+	}
+
+	if db != nil {
+		hooks.CustomAccessToken = hook.NewClaimsHook(hook.NewDatabaseClaimsStore(db))
+	}
+
+	return hooks
+}
+
+// newJobStore selects the queue driver. The database driver is the default
+// whenever a database connection is available.
+func newJobStore(cfg config.Queue, db *gorm.DB) (job.Store, error) {
+	switch cfg.Driver {
+	case job.DriverDatabase:
+		if db == nil {
+			return nil, errors.New("queue: the database driver requires the database to be enabled")
+		}
+
+		return job.NewDatabaseStore(db), nil
+	case job.DriverMemory:
+		return job.NewMemoryStore(), nil
+	case "":
+		if db != nil {
+			return job.NewDatabaseStore(db), nil
+		}
+
+		return job.NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("queue: unknown driver %q", cfg.Driver)
+	}
+}
+
+// newPasswordPolicy returns the policy of new passwords, checking them
+// against the breached passwords dataset when enabled.
+func newPasswordPolicy(cfg config.Passwords) (validation.PasswordPolicy, error) {
+	policy := validation.PasswordPolicy{
+		MinLength:     cfg.MinLength,
+		MaxLength:     cfg.MaxLength,
+		MinClasses:    cfg.MinClasses,
+		MinEntropy:    cfg.MinEntropy,
+		DisallowEmail: cfg.DisallowEmail,
+	}
+
+	if cfg.Breached.Enabled {
+		breached, err := validation.NewPwnedPasswords(cfg.Breached.Path)
+		if err != nil {
+			return policy, err
+		}
+
+		policy.Breached = breached
+	}
+
+	return policy, nil
+}
+
+// newSessionStore selects where sessions are kept. The database driver is the
+// default whenever a database connection is available.
+func newSessionStore(cfg config.Sessions, db *gorm.DB) (session.Store, error) {
+	switch cfg.Driver {
+	case session.DriverDatabase:
+		if db == nil {
+			return nil, errors.New("sessions: the database driver requires the database to be enabled")
+		}
+
+		return session.NewDatabaseStore(db), nil
+	case session.DriverMemory:
+		return session.NewMemoryStore(), nil
+	case "":
+		if db != nil {
+			return session.NewDatabaseStore(db), nil
+		}
+
+		return session.NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("sessions: unknown driver %q", cfg.Driver)
+	}
+}
+
+// newRateLimitStore selects where rate limit counters are kept. The returned
+// function releases the store connections on shutdown.
+func newRateLimitStore(cfg config.RateLimitStore, db *gorm.DB) (ratelimit.Store, ShutdownFunc, error) {
+	noop := func(context.Context) error { return nil }
+
+	switch cfg.Driver {
+	case ratelimit.DriverMemory, "":
+		return ratelimit.NewMemoryStore(), noop, nil
+	case ratelimit.DriverDatabase:
+		if db == nil {
+			return nil, nil, errors.New("rate_limit_store: the database driver requires the database to be enabled")
+		}
+
+		return ratelimit.NewDatabaseStore(db), noop, nil
+	case ratelimit.DriverRedis:
+		if cfg.Redis.Addr == "" {
+			return nil, nil, errors.New("rate_limit_store: redis.addr is required by the redis driver")
+		}
+
+		timeout := time.Duration(cfg.Redis.Timeout) * time.Second
+		client := redis.NewClient(&redis.Options{
+			Addr:         cfg.Redis.Addr,
+			Password:     cfg.Redis.Password,
+			DB:           cfg.Redis.DB,
+			DialTimeout:  timeout,
+			ReadTimeout:  timeout,
+			WriteTimeout: timeout,
+		})
+
+		return ratelimit.NewRedisStore(client, "ratelimit:"), func(context.Context) error { return client.Close() }, nil
+	default:
+		return nil, nil, fmt.Errorf("rate_limit_store: unknown driver %q", cfg.Driver)
+	}
+}
+
+// closeMailer waits for emails queued in memory to be delivered before
+// exiting.
+func closeMailer(mailer mail.Mailer) ShutdownFunc {
+	return func(ctx context.Context) error {
+		async, ok := mailer.(*mail.AsyncMailer)
+		if !ok {
+			return nil
+		}
+
+		return async.Close(ctx)
+	}
+}
@@ -0,0 +1,346 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/config"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/log"
+	"io"
+	"log/slog"
+	"maps"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	ExitOK    = 0
+	ExitError = 1
+	ExitUsage = 2
+)
+
+const binaryName = "app"
+
+// usageError reports invalid arguments. It exits with ExitUsage and prints
+// the usage of the command it was raised from.
+type usageError struct {
+	message string
+}
+
+func (e *usageError) Error() string {
+	return e.message
+}
+
+func newUsageError(format string, args ...any) error {
+	return &usageError{message: fmt.Sprintf(format, args...)}
+}
+
+// command is a node of the CLI tree. Leaf commands have a run function,
+// group commands only dispatch to their subcommands.
+type command struct {
+	name        string
+	args        string
+	summary     string
+	setup       func(fs *flag.FlagSet)
+	run         func(c *cli, args []string) error
+	subcommands []*command
+}
+
+func (cmd *command) find(name string) *command {
+	for _, sub := range cmd.subcommands {
+		if sub.name == name {
+			return sub
+		}
+	}
+
+	return nil
+}
+
+// pathList is a repeatable flag. The first value given replaces the default.
+type pathList struct {
+	values []string
+	set    bool
+}
+
+func (l *pathList) String() string {
+	return strings.Join(l.values, ",")
+}
+
+func (l *pathList) Set(value string) error {
+	if !l.set {
+		l.values, l.set = nil, true
+	}
+
+	l.values = append(l.values, value)
+
+	return nil
+}
+
+// overrides collects repeatable key.path=value flags.
+type overrides map[string]string
+
+func (o overrides) String() string {
+	pairs := make([]string, 0, len(o))
+	for key, value := range o {
+		pairs = append(pairs, key+"="+value)
+	}
+
+	return strings.Join(pairs, ",")
+}
+
+func (o overrides) Set(value string) error {
+	key, val, ok := strings.Cut(value, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("expected key.path=value, got %q", value)
+	}
+
+	o[key] = val
+
+	return nil
+}
+
+// cli holds the global flags and standard streams shared by every command.
+type cli struct {
+	configPaths *pathList
+	env         string
+	overrides   overrides
+	logLevel    string
+	stdin       io.Reader
+	stdout      io.Writer
+	stderr      io.Writer
+}
+
+func (c *cli) registerGlobalFlags(fs *flag.FlagSet) {
+	fs.Var(c.configPaths, "config", "path to a config file, repeat to layer several files")
+	fs.StringVar(&c.env, "env", c.env, "environment overlay to load, e.g. production for config.production.yml")
+	fs.Var(c.overrides, "set", "override a config value as key.path=value, may be repeated")
+	fs.StringVar(&c.logLevel, "log-level", c.logLevel, "minimum log level: debug, info, warn or error, overrides log.level")
+}
+
+// loadOptions describes the configuration layers selected by the global
+// flags, so reloads read the same sources as startup.
+func (c *cli) loadOptions() config.LoadOptions {
+	overrides := maps.Clone(c.overrides)
+	if c.logLevel != "" {
+		overrides["log.level"] = strings.ToLower(c.logLevel)
+	}
+
+	return config.LoadOptions{
+		Paths:     c.configPaths.values,
+		Env:       c.env,
+		Overrides: overrides,
+	}
+}
+
+// config loads and validates the configuration selected by the global flags.
+func (c *cli) config() (*config.Config, error) {
+	cfg, err := config.NewConfig(c.loadOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	if err = cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// logger logs at the configured level. The level can be changed later through
+// the returned LevelVar.
+func (c *cli) logger(cfg *config.Config) (log.LoggerInterface, *slog.LevelVar, error) {
+	level, err := log.ParseLevel(cfg.Log.Level)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	levelVar := &slog.LevelVar{}
+	levelVar.Set(level)
+
+	return log.NewLogger(levelVar), levelVar, nil
+}
+
+// reloadLogLevel applies log.level changes to a running logger.
+func reloadLogLevel(level *slog.LevelVar) func(previous *config.Config, current *config.Config) {
+	return func(_ *config.Config, current *config.Config) {
+		if parsed, err := log.ParseLevel(current.Log.Level); err == nil {
+			level.Set(parsed)
+		}
+	}
+}
+
+func rootCommand() *command {
+	return &command{
+		name:    binaryName,
+		summary: "Go REST skeleton API server and operational tasks.",
+		subcommands: []*command{
+			serveCommand(),
+			migrateCommand(),
+			configCommand(),
+			routesCommand(),
+			userCommand(),
+			secretCommand(),
+			versionCommand(),
+		},
+	}
+}
+
+// Execute runs the command selected by args (without the program name) and
+// returns the process exit code.
+func Execute(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	c := &cli{
+		configPaths: &pathList{values: []string{config.DefaultPath}},
+		overrides:   overrides{},
+		stdin:       stdin,
+		stdout:      stdout,
+		stderr:      stderr,
+	}
+
+	root := rootCommand()
+	cmd, path, rest, err := c.resolve(root, args)
+
+	if err == nil {
+		err = c.invoke(cmd, path, rest)
+	}
+
+	var usage *usageError
+
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, flag.ErrHelp):
+		c.printUsage(stdout, cmd, path)
+		return ExitOK
+	case errors.As(err, &usage):
+		fmt.Fprintf(stderr, "Error: %s\n\n", usage.message)
+		c.printUsage(stderr, cmd, path)
+		return ExitUsage
+	default:
+		fmt.Fprintf(stderr, "Error: %s\n", err)
+		return ExitError
+	}
+}
+
+// resolve walks down the command tree, parsing the global flags that may
+// appear before each command name.
+func (c *cli) resolve(root *command, args []string) (*command, []string, []string, error) {
+	cmd := root
+	path := []string{root.name}
+
+	for len(cmd.subcommands) > 0 {
+		fs := c.flagSet(path)
+		if err := parseFlags(fs, args); err != nil {
+			return cmd, path, nil, err
+		}
+
+		args = fs.Args()
+		if len(args) == 0 {
+			return cmd, path, nil, newUsageError("missing command")
+		}
+
+		if args[0] == "help" {
+			return c.resolveHelp(cmd, path, args[1:])
+		}
+
+		sub := cmd.find(args[0])
+		if sub == nil {
+			return cmd, path, nil, newUsageError("unknown command %q", args[0])
+		}
+
+		cmd = sub
+		path = append(path, sub.name)
+		args = args[1:]
+	}
+
+	return cmd, path, args, nil
+}
+
+// resolveHelp handles "help [command...]" by resolving the named command and
+// asking for its usage.
+func (c *cli) resolveHelp(cmd *command, path []string, names []string) (*command, []string, []string, error) {
+	for _, name := range names {
+		sub := cmd.find(name)
+		if sub == nil {
+			return cmd, path, nil, newUsageError("unknown command %q", name)
+		}
+
+		cmd = sub
+		path = append(path, sub.name)
+	}
+
+	return cmd, path, nil, flag.ErrHelp
+}
+
+func (c *cli) invoke(cmd *command, path []string, args []string) error {
+	fs := c.flagSet(path)
+	if cmd.setup != nil {
+		cmd.setup(fs)
+	}
+
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if c.logLevel != "" {
+		if _, err := log.ParseLevel(c.logLevel); err != nil {
+			return &usageError{message: err.Error()}
+		}
+	}
+
+	return cmd.run(c, fs.Args())
+}
+
+func (c *cli) flagSet(path []string) *flag.FlagSet {
+	fs := flag.NewFlagSet(strings.Join(path, " "), flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	c.registerGlobalFlags(fs)
+
+	return fs
+}
+
+// parseFlags reports invalid flags as usage errors.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	err := fs.Parse(args)
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		return &usageError{message: err.Error()}
+	}
+
+	return err
+}
+
+func (c *cli) printUsage(w io.Writer, cmd *command, path []string) {
+	fmt.Fprintf(w, "%s\n\nUsage:\n", cmd.summary)
+
+	if len(cmd.subcommands) > 0 {
+		fmt.Fprintf(w, "  %s [flags] <command>\n\nCommands:\n", strings.Join(path, " "))
+
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		for _, sub := range cmd.subcommands {
+			fmt.Fprintf(tw, "  %s\t%s\n", sub.name, sub.summary)
+		}
+		_ = tw.Flush()
+	} else {
+		fmt.Fprintf(w, "  %s\n", strings.TrimSpace(strings.Join(path, " ")+" [flags] "+cmd.args))
+	}
+
+	fs := c.flagSet(path)
+	if cmd.setup != nil && len(cmd.subcommands) == 0 {
+		cmd.setup(fs)
+	}
+
+	fmt.Fprintf(w, "\nFlags:\n")
+	fs.SetOutput(w)
+	fs.PrintDefaults()
+
+	if len(cmd.subcommands) > 0 {
+		fmt.Fprintf(w, "\nRun '%s help <command>' for more information on a command.\n", strings.Join(path, " "))
+	}
+}
+
+func exactArgs(args []string, n int) error {
+	if len(args) != n {
+		return newUsageError("expected %d argument(s), got %d", n, len(args))
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"bytes"
+	"github.com/go-playground/assert/v2"
+	"strings"
+	"testing"
+)
+
+func execute(args ...string) (int, string, string) {
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	code := Execute(args, strings.NewReader(""), stdout, stderr)
+
+	return code, stdout.String(), stderr.String()
+}
+
+func TestExecuteVersion(t *testing.T) {
+	code, stdout, _ := execute("version")
+
+	assert.Equal(t, code, ExitOK)
+	assert.Equal(t, strings.HasPrefix(stdout, "app dev "), true)
+}
+
+func TestExecuteUsageErrors(t *testing.T) {
+	tests := [][]string{
+		{},
+		{"unknown"},
+		{"config"},
+		{"version", "extra"},
+		{"--log-level", "loud", "version"},
+		{"serve", "--no-such-flag"},
+	}
+
+	for _, args := range tests {
+		code, _, stderr := execute(args...)
+
+		assert.Equal(t, code, ExitUsage)
+		assert.Equal(t, strings.HasPrefix(stderr, "Error: "), true)
+		assert.Equal(t, strings.Contains(stderr, "Usage:"), true)
+	}
+}
+
+func TestExecuteHelp(t *testing.T) {
+	for _, args := range [][]string{{"help"}, {"--help"}, {"help", "user", "set-role"}, {"user", "set-role", "-h"}} {
+		code, stdout, _ := execute(args...)
+
+		assert.Equal(t, code, ExitOK)
+		assert.Equal(t, strings.Contains(stdout, "Usage:"), true)
+	}
+
+	_, stdout, _ := execute("help", "user", "set-role")
+	assert.Equal(t, strings.Contains(stdout, "app user set-role [flags] <user-id> <role>"), true)
+	assert.Equal(t, strings.Contains(stdout, "-tenant"), true)
+}
+
+func TestExecuteGlobalFlagsAfterCommand(t *testing.T) {
+	code, _, stderr := execute("config", "validate", "--config", "does-not-exist.yml")
+
+	assert.Equal(t, code, ExitError)
+	assert.Equal(t, strings.Contains(stderr, "does-not-exist.yml"), true)
+}
+
+func TestHandlerName(t *testing.T) {
+	name := handlerName("github.com/Fortress-Digital/go-rest-skeleton/internal/handler.(*Handler).HomeHandler-fm")
+
+	assert.Equal(t, name, "handler.(*Handler).HomeHandler")
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+)
+
+func configCommand() *command {
+	return &command{
+		name:    "config",
+		summary: "Inspect the configuration.",
+		subcommands: []*command{
+			{
+				name:    "show",
+				summary: "Print the merged configuration with secrets redacted.",
+				run: func(c *cli, args []string) error {
+					if err := exactArgs(args, 0); err != nil {
+						return err
+					}
+
+					cfg, err := c.config()
+					if err != nil {
+						return err
+					}
+
+					fmt.Fprint(c.stdout, cfg)
+
+					return nil
+				},
+			},
+			{
+				name:    "validate",
+				summary: "Load the configuration and report every invalid value, for use in CI.",
+				run: func(c *cli, args []string) error {
+					if err := exactArgs(args, 0); err != nil {
+						return err
+					}
+
+					if _, err := c.config(); err != nil {
+						return err
+					}
+
+					fmt.Fprintf(c.stdout, "Configuration %s is valid.\n", c.configPaths)
+
+					return nil
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/config"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/model"
+	"gorm.io/gorm"
+)
+
+var errDatabaseDisabled = errors.New("the database is disabled, set database.enabled to true")
+
+func migrateCommand() *command {
+	return &command{
+		name:    "migrate",
+		summary: "Create or update the database tables.",
+		run: func(c *cli, args []string) error {
+			if err := exactArgs(args, 0); err != nil {
+				return err
+			}
+
+			cfg, err := c.config()
+			if err != nil {
+				return err
+			}
+
+			db, err := c.database(cfg)
+			if err != nil {
+				return err
+			}
+
+			if err = model.Migrate(db); err != nil {
+				return fmt.Errorf("migration failed: %w", err)
+			}
+
+			fmt.Fprintln(c.stdout, "Database migrated.")
+
+			return nil
+		},
+	}
+}
+
+// database connects to the configured database for commands that cannot run
+// without one.
+func (c *cli) database(cfg *config.Config) (*gorm.DB, error) {
+	if !cfg.Database.Enabled {
+		return nil, errDatabaseDisabled
+	}
+
+	logger, _, err := c.logger(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return model.NewDB(cfg, logger)
+}
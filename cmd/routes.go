@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/config"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/handler"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/route"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/supabase"
+	"github.com/labstack/echo/v4"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+func routesCommand() *command {
+	return &command{
+		name:    "routes",
+		summary: "List the HTTP routes served with the current configuration.",
+		run: func(c *cli, args []string) error {
+			if err := exactArgs(args, 0); err != nil {
+				return err
+			}
+
+			cfg, err := c.config()
+			if err != nil {
+				return err
+			}
+
+			// Handlers are only referenced, never called, so the routes can be
+			// listed without connecting to any service.
+			deps := route.Dependencies{}
+			if cfg.APIKeys.Enabled {
+				deps.APIKeyHandler = &handler.APIKeyHandler{}
+			}
+
+			if cfg.Sessions.Enabled {
+				deps.SessionHandler = &handler.SessionHandler{}
+			}
+
+			if cfg.Lockout.Enabled {
+				deps.LockoutHandler = &handler.LockoutHandler{}
+			}
+
+			if cfg.Registration.Mode == "invite" {
+				deps.InvitationHandler = &handler.InvitationHandler{}
+			}
+
+			if cfg.Supabase.Hooks.Secret != "" {
+				tolerance := time.Duration(cfg.Supabase.Hooks.Tolerance) * time.Second
+				if deps.WebhookVerifier, err = supabase.NewWebhookVerifier(cfg.Supabase.Hooks.Secret, tolerance); err != nil {
+					return err
+				}
+			}
+
+			routes := route.NewRouter(config.NewLive(cfg), deps).Routes()
+			sort.Slice(routes, func(i, j int) bool {
+				if routes[i].Path == routes[j].Path {
+					return routes[i].Method < routes[j].Method
+				}
+
+				return routes[i].Path < routes[j].Path
+			})
+
+			tw := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "METHOD\tPATH\tHANDLER")
+			for _, r := range routes {
+				if r.Method == echo.RouteNotFound {
+					continue
+				}
+
+				fmt.Fprintf(tw, "%s\t%s\t%s\n", r.Method, r.Path, handlerName(r.Name))
+			}
+
+			return tw.Flush()
+		},
+	}
+}
+
+// handlerName shortens "github.com/org/repo/internal/handler.(*Handler).HomeHandler-fm"
+// to "handler.(*Handler).HomeHandler".
+func handlerName(name string) string {
+	name = strings.TrimSuffix(name, "-fm")
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	return name
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/config"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/job"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/log"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/ratelimit"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/scheduler"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/session"
+	"gorm.io/gorm"
+	"time"
+)
+
+// newScheduler registers the tasks enabled in the configuration. A task is
+// enabled by giving it a cron expression under scheduler.tasks. Revoked
+// sessions are kept for revokedRetention.
+func newScheduler(cfg config.Scheduler, db *gorm.DB, jobs job.Store, retention time.Duration, revokedRetention time.Duration, log log.LoggerInterface) (*scheduler.Scheduler, error) {
+	location := time.UTC
+	if cfg.Timezone != "" {
+		var err error
+		if location, err = time.LoadLocation(cfg.Timezone); err != nil {
+			return nil, fmt.Errorf("scheduler: invalid timezone: %w", err)
+		}
+	}
+
+	var store scheduler.Store = scheduler.NewMemoryStore()
+	if db != nil {
+		store = scheduler.NewDatabaseStore(db)
+	}
+
+	tasks := map[string]scheduler.TaskFunc{
+		"purge_jobs": func(ctx context.Context) error {
+			purged, err := jobs.Purge(ctx, time.Now().Add(-retention))
+			log.Info("Purged completed jobs", "count", purged)
+			return err
+		},
+		"purge_task_locks": func(ctx context.Context) error {
+			purged, err := store.PurgeLocks(ctx, time.Now().Add(-24*time.Hour))
+			log.Info("Purged scheduled task locks", "count", purged)
+			return err
+		},
+		"purge_rate_limits": func(ctx context.Context) error {
+			if db == nil {
+				return nil
+			}
+
+			purged, err := ratelimit.NewDatabaseStore(db).Purge(ctx, time.Now())
+			log.Info("Purged expired rate limit counters", "count", purged)
+			return err
+		},
+		"purge_sessions": func(ctx context.Context) error {
+			if db == nil {
+				return nil
+			}
+
+			now := time.Now()
+			purged, err := session.NewDatabaseStore(db).Purge(ctx, now, now.Add(-revokedRetention))
+			log.Info("Purged expired sessions", "count", purged)
+			return err
+		},
+	}
+
+	s := scheduler.NewScheduler(store, log, location)
+
+	for name, expr := range cfg.Tasks {
+		if expr == "" {
+			continue
+		}
+
+		fn, ok := tasks[name]
+		if !ok {
+			return nil, fmt.Errorf("scheduler: unknown task %q", name)
+		}
+
+		if err := s.Register(name, expr, fn); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/config"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/secret"
+	"io"
+	"strings"
+)
+
+const secretsKeyEnv = "SECRETS_KEY"
+
+func secretCommand() *command {
+	return &command{
+		name:    "secret",
+		summary: "Manage the local encrypted secret store read by secret://store/<name> values.",
+		subcommands: []*command{
+			{
+				name:    "keygen",
+				summary: "Generate a new store key. Keep it in " + secretsKeyEnv + " or " + secretsKeyEnv + "_FILE.",
+				run: func(c *cli, args []string) error {
+					if err := exactArgs(args, 0); err != nil {
+						return err
+					}
+
+					key, err := secret.GenerateKey()
+					if err != nil {
+						return err
+					}
+
+					fmt.Fprintln(c.stdout, key)
+
+					return nil
+				},
+			},
+			secretStoreCommand("set", "<name>", "Store a secret read from stdin.", func(c *cli, store *secret.Store, args []string) error {
+				if err := exactArgs(args, 1); err != nil {
+					return err
+				}
+
+				value, err := io.ReadAll(c.stdin)
+				if err != nil {
+					return err
+				}
+
+				store.Set(args[0], strings.TrimRight(string(value), "\r\n"))
+
+				return store.Save()
+			}),
+			secretStoreCommand("delete", "<name>", "Remove a secret.", func(c *cli, store *secret.Store, args []string) error {
+				if err := exactArgs(args, 1); err != nil {
+					return err
+				}
+
+				store.Delete(args[0])
+
+				return store.Save()
+			}),
+			secretStoreCommand("list", "", "List the stored secret names.", func(c *cli, store *secret.Store, args []string) error {
+				if err := exactArgs(args, 0); err != nil {
+					return err
+				}
+
+				for _, name := range store.Names() {
+					fmt.Fprintln(c.stdout, name)
+				}
+
+				return nil
+			}),
+		},
+	}
+}
+
+// secretStoreCommand opens the store before running fn. The configuration is
+// not loaded, as it may itself refer to secrets that are not stored yet.
+func secretStoreCommand(name string, args string, summary string, fn func(c *cli, store *secret.Store, args []string) error) *command {
+	var path string
+
+	return &command{
+		name:    name,
+		args:    args,
+		summary: summary,
+		setup: func(fs *flag.FlagSet) {
+			fs.StringVar(&path, "store", config.Default().Secrets.Store, "path to the secret store")
+		},
+		run: func(c *cli, args []string) error {
+			key, ok, err := secret.LookupEnv(secretsKeyEnv)
+			if err != nil {
+				return err
+			}
+
+			if !ok || key == "" {
+				return errors.New(secretsKeyEnv + " or " + secretsKeyEnv + "_FILE must be set, see 'secret keygen'")
+			}
+
+			store, err := secret.OpenStore(path, key)
+			if err != nil {
+				return err
+			}
+
+			return fn(c, store, args)
+		},
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"github.com/Fortress-Digital/go-rest-skeleton/internal/config"
 	"github.com/Fortress-Digital/go-rest-skeleton/internal/log"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 	"log/slog"
 	"net/http"
 	"os"
@@ -14,14 +16,28 @@ import (
 	"time"
 )
 
-func NewServer(cfg *config.Config, router http.Handler, log log.LoggerInterface) error {
-	srv := &http.Server{
-		Addr:         fmt.Sprintf(":%d", cfg.Server.Port),
-		Handler:      router,
-		IdleTimeout:  time.Duration(cfg.Server.Timeout) * time.Second,
-		ReadTimeout:  time.Duration(cfg.Server.ReadTimeout) * time.Second,
-		WriteTimeout: time.Duration(cfg.Server.WriteTimeout) * time.Second,
-		ErrorLog:     slog.NewLogLogger(log.Handler(), slog.LevelError),
+// ShutdownFunc releases a background component once the server has stopped
+// accepting requests, e.g. draining a job worker.
+type ShutdownFunc func(ctx context.Context) error
+
+// NewServer serves the router until SIGINT or SIGTERM, then shuts down the
+// background components of onShutdown. They are shut down as well when the
+// server fails to start.
+func NewServer(cfg *config.Config, router http.Handler, log log.LoggerInterface, onShutdown ...ShutdownFunc) error {
+	srv, err := newHTTPServer(cfg, router, log)
+	if err != nil {
+		return errors.Join(err, shutdownAll(onShutdown))
+	}
+
+	var redirect *http.Server
+	if cfg.Server.TLS.Enabled && cfg.Server.TLS.RedirectPort > 0 {
+		redirect = &http.Server{
+			Addr:         fmt.Sprintf(":%d", cfg.Server.TLS.RedirectPort),
+			Handler:      redirectToHTTPS(cfg.Server.Port),
+			ReadTimeout:  srv.ReadTimeout,
+			WriteTimeout: srv.WriteTimeout,
+			ErrorLog:     srv.ErrorLog,
+		}
 	}
 
 	// Create a channel to receive the error from the ListenAndServe() method
@@ -44,16 +60,46 @@ func NewServer(cfg *config.Config, router http.Handler, log log.LoggerInterface)
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
-		shutdownError <- srv.Shutdown(ctx)
+		err := srv.Shutdown(ctx)
+		if redirect != nil {
+			err = errors.Join(err, redirect.Shutdown(ctx))
+		}
+
+		// Background components run after the server so in-flight requests
+		// can still enqueue work while they finish.
+		for _, shutdown := range onShutdown {
+			err = errors.Join(err, shutdown(ctx))
+		}
+
+		shutdownError <- err
 	}()
 
-	log.Info("starting server", "addr", srv.Addr, "env", cfg.Application.Env)
+	if redirect != nil {
+		go func() {
+			log.Info("starting HTTPS redirect", "addr", redirect.Addr)
+
+			if err := redirect.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				log.Error("HTTPS redirect stopped", "error", err)
+			}
+		}()
+	}
+
+	log.Info("starting server", "addr", srv.Addr, "env", cfg.Application.Env, "tls", srv.TLSConfig != nil)
 
 	// Call the ListenAndServe() method on our http.NewServer struct
 	// Only returning an error if it's not http.ErrServerClosed
-	err := srv.ListenAndServe()
+	if srv.TLSConfig != nil {
+		err = srv.ListenAndServeTLS("", "")
+	} else {
+		err = srv.ListenAndServe()
+	}
+
 	if !errors.Is(err, http.ErrServerClosed) {
-		return err
+		if redirect != nil {
+			err = errors.Join(err, redirect.Close())
+		}
+
+		return errors.Join(err, shutdownAll(onShutdown))
 	}
 
 	// Otherwise, block until the shutdownError channel receives a value
@@ -66,3 +112,52 @@ func NewServer(cfg *config.Config, router http.Handler, log log.LoggerInterface)
 
 	return nil
 }
+
+// shutdownAll shuts the components down in order, giving them 30 seconds
+// like a graceful shutdown.
+func shutdownAll(onShutdown []ShutdownFunc) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var err error
+	for _, shutdown := range onShutdown {
+		err = errors.Join(err, shutdown(ctx))
+	}
+
+	return err
+}
+
+// newHTTPServer configures the listener for the server settings: HTTPS with
+// HTTP/2 when TLS is enabled, otherwise plain HTTP with optional h2c.
+func newHTTPServer(cfg *config.Config, router http.Handler, log log.LoggerInterface) (*http.Server, error) {
+	srv := &http.Server{
+		Addr:         fmt.Sprintf(":%d", cfg.Server.Port),
+		Handler:      router,
+		IdleTimeout:  time.Duration(cfg.Server.Timeout) * time.Second,
+		ReadTimeout:  time.Duration(cfg.Server.ReadTimeout) * time.Second,
+		WriteTimeout: time.Duration(cfg.Server.WriteTimeout) * time.Second,
+		ErrorLog:     slog.NewLogLogger(log.Handler(), slog.LevelError),
+	}
+
+	if !cfg.Server.TLS.Enabled {
+		if cfg.Server.H2C {
+			srv.Handler = h2c.NewHandler(router, &http2.Server{IdleTimeout: srv.IdleTimeout})
+		}
+
+		return srv, nil
+	}
+
+	tlsConfig, err := newTLSConfig(cfg.Server.TLS, log)
+	if err != nil {
+		return nil, err
+	}
+
+	srv.TLSConfig = tlsConfig
+
+	// ConfigureServer also rejects cipher suites HTTP/2 cannot use.
+	if err = http2.ConfigureServer(srv, &http2.Server{IdleTimeout: srv.IdleTimeout}); err != nil {
+		return nil, fmt.Errorf("tls: %w", err)
+	}
+
+	return srv, nil
+}
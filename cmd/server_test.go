@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"context"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/config"
+	"github.com/go-playground/assert/v2"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"testing"
+)
+
+func TestNewServerShutsDownWhenListenFails(t *testing.T) {
+	listener, err := net.Listen("tcp", ":0")
+	assert.Equal(t, err, nil)
+	defer listener.Close()
+
+	cfg := config.Default()
+	cfg.Server.Port = listener.Addr().(*net.TCPAddr).Port
+
+	var stopped []string
+	stop := func(name string) ShutdownFunc {
+		return func(context.Context) error {
+			stopped = append(stopped, name)
+			return nil
+		}
+	}
+
+	err = NewServer(cfg, http.NotFoundHandler(), slog.New(slog.NewTextHandler(io.Discard, nil)), stop("worker"), stop("mailer"))
+
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, stopped, []string{"worker", "mailer"})
+}
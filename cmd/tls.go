@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/config"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+func newTLSConfig(cfg config.TLS, log log.LoggerInterface) (*tls.Config, error) {
+	certificates, err := newCertificateReloader(cfg.CertFile, cfg.KeyFile, log)
+	if err != nil {
+		return nil, err
+	}
+
+	ciphers, err := cipherSuites(cfg.CipherSuites)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:     tlsVersions[cfg.MinVersion],
+		CipherSuites:   ciphers,
+		GetCertificate: certificates.GetCertificate,
+	}
+
+	// Route groups decide whether a certificate is required, so the
+	// handshake only verifies the ones given.
+	if cfg.Client.Enabled {
+		if tlsConfig.ClientCAs, err = loadCertPool(cfg.Client.CAFile); err != nil {
+			return nil, err
+		}
+
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	return tlsConfig, nil
+}
+
+func loadCertPool(path string) (*x509.CertPool, error) {
+	bundle, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("tls: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(bundle) {
+		return nil, fmt.Errorf("tls: %s contains no PEM certificates", path)
+	}
+
+	return pool, nil
+}
+
+// cipherSuites maps names such as TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256 to
+// their ID. Only suites Go considers secure are accepted. They apply to TLS
+// 1.2, as TLS 1.3 suites are not configurable.
+func cipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	known := map[string]uint16{}
+	for _, suite := range tls.CipherSuites() {
+		known[suite.Name] = suite.ID
+	}
+
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := known[name]
+		if !ok {
+			return nil, fmt.Errorf("tls: unknown or insecure cipher suite %q", name)
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// certificateReloader serves the key pair from disk, loading it again when
+// either file changes. A pair that fails to load is logged and the previous
+// one is kept, so a half written renewal does not break the handshakes.
+type certificateReloader struct {
+	certFile string
+	keyFile  string
+	log      log.LoggerInterface
+
+	mu          sync.Mutex
+	certificate *tls.Certificate
+	stamps      [2]fileStamp
+}
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func newCertificateReloader(certFile string, keyFile string, log log.LoggerInterface) (*certificateReloader, error) {
+	r := &certificateReloader{certFile: certFile, keyFile: keyFile, log: log}
+
+	if err := r.load(r.stat()); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *certificateReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if stamps := r.stat(); stamps != r.stamps {
+		if err := r.load(stamps); err != nil {
+			r.log.Error("TLS certificate reload failed", "error", err)
+		} else {
+			r.log.Info("TLS certificate reloaded", "cert_file", r.certFile)
+		}
+	}
+
+	return r.certificate, nil
+}
+
+func (r *certificateReloader) load(stamps [2]fileStamp) error {
+	certificate, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("tls: %w", err)
+	}
+
+	r.certificate = &certificate
+	r.stamps = stamps
+
+	return nil
+}
+
+func (r *certificateReloader) stat() [2]fileStamp {
+	var stamps [2]fileStamp
+
+	for i, file := range []string{r.certFile, r.keyFile} {
+		if info, err := os.Stat(file); err == nil {
+			stamps[i] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+		}
+	}
+
+	return stamps
+}
+
+// redirectToHTTPS sends plain HTTP requests to the same URL over HTTPS on
+// port. 308 keeps the method and body of non GET requests.
+func redirectToHTTPS(port int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if name, _, err := net.SplitHostPort(host); err == nil {
+			host = name
+		}
+
+		host = strings.Trim(host, "[]")
+		if port == 443 {
+			if strings.Contains(host, ":") {
+				host = "[" + host + "]"
+			}
+		} else {
+			host = net.JoinHostPort(host, strconv.Itoa(port))
+		}
+
+		target := url.URL{Scheme: "https", Host: host, Path: r.URL.Path, RawPath: r.URL.RawPath, RawQuery: r.URL.RawQuery}
+
+		http.Redirect(w, r, target.String(), http.StatusPermanentRedirect)
+	})
+}
@@ -0,0 +1,280 @@
+package cmd
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/config"
+	"github.com/go-playground/assert/v2"
+	"golang.org/x/net/http2"
+	"io"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var discardLogger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError + 1}))
+
+// writeCertificate writes a self-signed certificate for localhost and returns
+// the cert and key paths, along with the certificate for client trust.
+func writeCertificate(t *testing.T, dir string, commonName string) (string, string, *x509.Certificate) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Equal(t, err, nil)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Equal(t, err, nil)
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.Equal(t, err, nil)
+
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+
+	assert.Equal(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600), nil)
+	assert.Equal(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600), nil)
+
+	// Move the modification time forward so a rewrite within the filesystem
+	// timestamp granularity is still seen as a change.
+	later := time.Now().Add(time.Duration(len(commonName)) * time.Second)
+	_ = os.Chtimes(certFile, later, later)
+	_ = os.Chtimes(keyFile, later, later)
+
+	certificate, err := x509.ParseCertificate(der)
+	assert.Equal(t, err, nil)
+
+	return certFile, keyFile, certificate
+}
+
+func servedCommonName(t *testing.T, r *certificateReloader) string {
+	t.Helper()
+
+	certificate, err := r.GetCertificate(&tls.ClientHelloInfo{})
+	assert.Equal(t, err, nil)
+
+	leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+	assert.Equal(t, err, nil)
+
+	return leaf.Subject.CommonName
+}
+
+func TestCertificateReloaderPicksUpRenewal(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, _ := writeCertificate(t, dir, "first")
+
+	reloader, err := newCertificateReloader(certFile, keyFile, discardLogger)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, servedCommonName(t, reloader), "first")
+
+	writeCertificate(t, dir, "renewed")
+	assert.Equal(t, servedCommonName(t, reloader), "renewed")
+
+	assert.Equal(t, os.WriteFile(certFile, []byte("half written"), 0o600), nil)
+	assert.Equal(t, servedCommonName(t, reloader), "renewed")
+}
+
+func TestNewCertificateReloaderRequiresValidPair(t *testing.T) {
+	_, err := newCertificateReloader("missing.pem", "missing.key", discardLogger)
+
+	assert.NotEqual(t, err, nil)
+}
+
+func TestCipherSuites(t *testing.T) {
+	ids, err := cipherSuites([]string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, ids, []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256})
+
+	_, err = cipherSuites([]string{"TLS_RSA_WITH_RC4_128_SHA"})
+	assert.NotEqual(t, err, nil)
+}
+
+func tlsServerConfig(t *testing.T) (*config.Config, *x509.Certificate) {
+	certFile, keyFile, certificate := writeCertificate(t, t.TempDir(), "localhost")
+
+	cfg := config.Default()
+	cfg.Server.TLS = config.TLS{Enabled: true, CertFile: certFile, KeyFile: keyFile, MinVersion: "1.3"}
+
+	return cfg, certificate
+}
+
+// startServer serves srv on a random local port until the test ends.
+func startServer(t *testing.T, srv *http.Server) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Equal(t, err, nil)
+
+	go func() {
+		if srv.TLSConfig != nil {
+			_ = srv.ServeTLS(listener, "", "")
+		} else {
+			_ = srv.Serve(listener)
+		}
+	}()
+
+	t.Cleanup(func() { _ = srv.Shutdown(context.Background()) })
+
+	return listener.Addr().String()
+}
+
+func protoHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Proto))
+	})
+}
+
+func TestNewHTTPServerServesHTTP2OverTLS(t *testing.T) {
+	cfg, certificate := tlsServerConfig(t)
+
+	srv, err := newHTTPServer(cfg, protoHandler(), discardLogger)
+	assert.Equal(t, err, nil)
+
+	addr := startServer(t, srv)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(certificate)
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{RootCAs: roots},
+		ForceAttemptHTTP2: true,
+	}}
+
+	resp, err := client.Get("https://" + addr)
+	assert.Equal(t, err, nil)
+	defer resp.Body.Close()
+
+	assert.Equal(t, resp.ProtoMajor, 2)
+	assert.Equal(t, resp.TLS.Version, uint16(tls.VersionTLS13))
+
+	legacy := &http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{RootCAs: roots, MaxVersion: tls.VersionTLS12},
+	}}
+
+	_, err = legacy.Get("https://" + addr)
+	assert.NotEqual(t, err, nil)
+}
+
+func TestNewHTTPServerServesH2C(t *testing.T) {
+	cfg := config.Default()
+	cfg.Server.H2C = true
+
+	srv, err := newHTTPServer(cfg, protoHandler(), discardLogger)
+	assert.Equal(t, err, nil)
+
+	addr := startServer(t, srv)
+
+	client := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network string, addr string, _ *tls.Config) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, addr)
+		},
+	}}
+
+	resp, err := client.Get("http://" + addr)
+	assert.Equal(t, err, nil)
+	defer resp.Body.Close()
+
+	assert.Equal(t, resp.ProtoMajor, 2)
+}
+
+func TestRedirectToHTTPS(t *testing.T) {
+	tests := []struct {
+		port     int
+		host     string
+		expected string
+	}{
+		{443, "example.com", "https://example.com/files?page=2"},
+		{443, "example.com:80", "https://example.com/files?page=2"},
+		{8443, "example.com:8080", "https://example.com:8443/files?page=2"},
+		{8443, "[::1]:8080", "https://[::1]:8443/files?page=2"},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/files?page=2", nil)
+		req.Host = test.host
+		rec := httptest.NewRecorder()
+
+		redirectToHTTPS(test.port).ServeHTTP(rec, req)
+
+		assert.Equal(t, rec.Code, http.StatusPermanentRedirect)
+		assert.Equal(t, rec.Header().Get("Location"), test.expected)
+	}
+}
+
+func TestNewHTTPServerVerifiesClientCertificates(t *testing.T) {
+	cfg, certificate := tlsServerConfig(t)
+
+	clientCert, clientKey, _ := writeCertificate(t, t.TempDir(), "payments")
+	cfg.Server.TLS.Client = config.ClientTLS{Enabled: true, CAFile: clientCert}
+
+	srv, err := newHTTPServer(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.VerifiedChains) > 0 {
+			_, _ = w.Write([]byte(r.TLS.VerifiedChains[0][0].Subject.CommonName))
+		}
+	}), discardLogger)
+	assert.Equal(t, err, nil)
+
+	addr := startServer(t, srv)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(certificate)
+
+	get := func(certFile string, keyFile string) (string, error) {
+		tlsConfig := &tls.Config{RootCAs: roots}
+		if certFile != "" {
+			pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+			assert.Equal(t, err, nil)
+
+			// Always present the certificate, even when the server does not
+			// list its issuer as acceptable.
+			tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) { return &pair, nil }
+		}
+
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+
+		resp, err := client.Get("https://" + addr)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+
+		return string(body), err
+	}
+
+	identity, err := get(clientCert, clientKey)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, identity, "payments")
+
+	identity, err = get("", "")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, identity, "")
+
+	untrustedCert, untrustedKey, _ := writeCertificate(t, t.TempDir(), "intruder")
+	_, err = get(untrustedCert, untrustedKey)
+	assert.NotEqual(t, err, nil)
+}
@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/hook"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/model"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/supabase"
+	"golang.org/x/term"
+	"gorm.io/gorm/clause"
+	"io"
+	"os"
+	"strings"
+)
+
+func userCommand() *command {
+	return &command{
+		name:    "user",
+		summary: "Manage users and their application roles.",
+		subcommands: []*command{
+			userCreateCommand(),
+			userSetRoleCommand(),
+			userShowCommand(),
+		},
+	}
+}
+
+func userCreateCommand() *command {
+	return &command{
+		name:    "create",
+		args:    "<email>",
+		summary: "Register a user with Supabase. The password is prompted for, or read from stdin when piped.",
+		run: func(c *cli, args []string) error {
+			if err := exactArgs(args, 1); err != nil {
+				return err
+			}
+
+			cfg, err := c.config()
+			if err != nil {
+				return err
+			}
+
+			password, err := c.readPassword()
+			if err != nil {
+				return err
+			}
+
+			authClient := supabase.NewAuthClient(cfg.Supabase.Url, cfg.Supabase.Key)
+
+			user, errRes, err := authClient.SignUp(supabase.UserCredentials{Email: args[0], Password: password})
+			if err != nil {
+				return err
+			}
+
+			if errRes != nil {
+				return fmt.Errorf("supabase rejected the user: %s", errRes.Message)
+			}
+
+			fmt.Fprintf(c.stdout, "Created user %s (%s).\n", user.ID, user.Email)
+
+			return nil
+		},
+	}
+}
+
+// readPassword prompts for a password twice on a terminal, or reads the first
+// line of stdin. Passwords are never taken as arguments, which other users
+// can see in the process list and which end up in the shell history.
+func (c *cli) readPassword() (string, error) {
+	if f, ok := c.stdin.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		fmt.Fprint(c.stderr, "Password: ")
+		password, err := term.ReadPassword(int(f.Fd()))
+		fmt.Fprintln(c.stderr)
+		if err != nil {
+			return "", err
+		}
+
+		fmt.Fprint(c.stderr, "Confirm password: ")
+		confirmation, err := term.ReadPassword(int(f.Fd()))
+		fmt.Fprintln(c.stderr)
+		if err != nil {
+			return "", err
+		}
+
+		if string(password) != string(confirmation) {
+			return "", errors.New("the passwords do not match")
+		}
+
+		return string(password), nil
+	}
+
+	line, err := bufio.NewReader(c.stdin).ReadString('\n')
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		if err != nil && !errors.Is(err, io.EOF) {
+			return "", err
+		}
+
+		return "", errors.New("no password given on stdin")
+	}
+
+	return password, nil
+}
+
+func userSetRoleCommand() *command {
+	var tenant string
+
+	return &command{
+		name:    "set-role",
+		args:    "<user-id> <role>",
+		summary: "Set the application role added to a user's access tokens.",
+		setup: func(fs *flag.FlagSet) {
+			fs.StringVar(&tenant, "tenant", "", "tenant the user belongs to")
+		},
+		run: func(c *cli, args []string) error {
+			if err := exactArgs(args, 2); err != nil {
+				return err
+			}
+
+			cfg, err := c.config()
+			if err != nil {
+				return err
+			}
+
+			db, err := c.database(cfg)
+			if err != nil {
+				return err
+			}
+
+			claim := &model.UserClaim{UserID: args[0], TenantID: tenant, Role: args[1]}
+
+			err = db.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "user_id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"tenant_id", "role", "updated_at"}),
+			}).Create(claim).Error
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(c.stdout, "User %s now has role %q.\n", claim.UserID, claim.Role)
+
+			return nil
+		},
+	}
+}
+
+func userShowCommand() *command {
+	return &command{
+		name:    "show",
+		args:    "<user-id>",
+		summary: "Show the role and tenant stored for a user.",
+		run: func(c *cli, args []string) error {
+			if err := exactArgs(args, 1); err != nil {
+				return err
+			}
+
+			cfg, err := c.config()
+			if err != nil {
+				return err
+			}
+
+			db, err := c.database(cfg)
+			if err != nil {
+				return err
+			}
+
+			claim, err := hook.NewDatabaseClaimsStore(db).FindByUserID(context.Background(), args[0])
+			if err != nil {
+				return err
+			}
+
+			if claim == nil {
+				return fmt.Errorf("no role stored for user %s", args[0])
+			}
+
+			fmt.Fprintf(c.stdout, "user:   %s\ntenant: %s\nrole:   %s\n", claim.UserID, claim.TenantID, claim.Role)
+
+			return nil
+		},
+	}
+}
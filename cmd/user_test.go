@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"github.com/go-playground/assert/v2"
+	"strings"
+	"testing"
+)
+
+func TestReadPasswordFromStdin(t *testing.T) {
+	tests := []struct {
+		name        string
+		stdin       string
+		expected    string
+		expectedErr bool
+	}{
+		{"Line", "correct-horse\n", "correct-horse", false},
+		{"Windows line ending", "correct-horse\r\nignored\n", "correct-horse", false},
+		{"Without newline", "correct-horse", "correct-horse", false},
+		{"Empty line", "\n", "", true},
+		{"Nothing", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &cli{stdin: strings.NewReader(tt.stdin)}
+
+			password, err := c.readPassword()
+
+			assert.Equal(t, password, tt.expected)
+			assert.Equal(t, err != nil, tt.expectedErr)
+		})
+	}
+}
+
+func TestUserCreateRejectsPasswordFlag(t *testing.T) {
+	code, _, stderr := execute("user", "create", "--password", "correct-horse", "jane@example.com")
+
+	assert.Equal(t, code, ExitUsage)
+	assert.Equal(t, strings.Contains(stderr, "flag provided but not defined: -password"), true)
+}
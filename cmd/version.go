@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// Version and Commit are set at build time, e.g.
+// go build -ldflags "-X github.com/Fortress-Digital/go-rest-skeleton/cmd.Version=1.2.0".
+var (
+	Version = "dev"
+	Commit  = ""
+)
+
+func versionCommand() *command {
+	return &command{
+		name:    "version",
+		summary: "Print the build version.",
+		run: func(c *cli, args []string) error {
+			if err := exactArgs(args, 0); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(c.stdout, "%s %s (commit %s, %s)\n", binaryName, Version, commit(), runtime.Version())
+
+			return nil
+		},
+	}
+}
+
+// commit falls back to the VCS revision embedded by the Go toolchain.
+func commit() string {
+	if Commit != "" {
+		return Commit
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				return setting.Value
+			}
+		}
+	}
+
+	return "unknown"
+}
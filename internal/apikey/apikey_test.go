@@ -0,0 +1,104 @@
+package apikey
+
+import (
+	"context"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/auth"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/model"
+	"github.com/go-playground/assert/v2"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGenerate(t *testing.T) {
+	key, prefix, hash, err := Generate("sk")
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, strings.HasPrefix(key, prefix+"_"), true)
+	assert.Equal(t, strings.HasPrefix(prefix, "sk_"), true)
+	assert.Equal(t, strings.Contains(hash, strings.TrimPrefix(key, prefix+"_")), false)
+
+	parsedPrefix, secret, ok := Parse(key)
+
+	assert.Equal(t, ok, true)
+	assert.Equal(t, parsedPrefix, prefix)
+	assert.Equal(t, Verify(secret, hash), true)
+	assert.Equal(t, Verify(secret+"0", hash), false)
+}
+
+func TestParseRejectsMalformedKeys(t *testing.T) {
+	for _, key := range []string{"", "secret", "_secret", "sk_abc_"} {
+		_, _, ok := Parse(key)
+
+		assert.Equal(t, ok, false)
+	}
+}
+
+func TestAuthenticator(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	recently := now.Add(-time.Second)
+
+	store := NewMemoryStore()
+	create := func(key *model.APIKey) string {
+		token, prefix, hash, err := Generate("sk")
+		assert.Equal(t, err, nil)
+
+		key.UserID = "123"
+		key.Prefix = prefix
+		key.SecretHash = hash
+		assert.Equal(t, store.Create(context.Background(), key), nil)
+
+		return token
+	}
+
+	valid := create(&model.APIKey{Name: "valid", Scopes: []string{"files:read"}})
+	touched := create(&model.APIKey{Name: "touched", LastUsedAt: &recently})
+	revoked := create(&model.APIKey{Name: "revoked", RevokedAt: &past})
+	expired := create(&model.APIKey{Name: "expired", ExpiresAt: &past})
+
+	authenticator := NewAuthenticator(store, time.Minute)
+	authenticator.now = func() time.Time { return now }
+
+	tests := []struct {
+		name              string
+		header            string
+		value             string
+		expectedPrincipal *auth.Principal
+		expectedErr       error
+	}{
+		{"No key", "", "", nil, nil},
+		{"Bearer token is ignored", echo.HeaderAuthorization, "Bearer token", nil, nil},
+		{"X-API-Key header", "X-API-Key", valid, &auth.Principal{Type: auth.PrincipalAPIKey, Method: auth.MethodAPIKey, ID: "123", Scopes: []string{"files:read"}}, nil},
+		{"Authorization header", echo.HeaderAuthorization, "ApiKey " + touched, &auth.Principal{Type: auth.PrincipalAPIKey, Method: auth.MethodAPIKey, ID: "123"}, nil},
+		{"Malformed key", "X-API-Key", "secret", nil, ErrInvalidKey},
+		{"Unknown key", "X-API-Key", "sk_000000000000_secret", nil, ErrInvalidKey},
+		{"Wrong secret", "X-API-Key", valid[:strings.LastIndex(valid, "_")] + "_secret", nil, ErrInvalidKey},
+		{"Revoked key", "X-API-Key", revoked, nil, ErrRevokedKey},
+		{"Expired key", "X-API-Key", expired, nil, ErrExpiredKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			c := echo.New().NewContext(req, httptest.NewRecorder())
+
+			principal, err := authenticator.Authenticate(c)
+
+			assert.Equal(t, principal, tt.expectedPrincipal)
+			assert.Equal(t, err, tt.expectedErr)
+		})
+	}
+
+	keys, _ := store.List(context.Background(), "123")
+
+	assert.Equal(t, *keys[0].LastUsedAt, now)
+	assert.Equal(t, *keys[1].LastUsedAt, recently)
+	assert.Equal(t, keys[2].LastUsedAt, nil)
+}
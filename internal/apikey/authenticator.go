@@ -0,0 +1,74 @@
+package apikey
+
+import (
+	"errors"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/auth"
+	"github.com/labstack/echo/v4"
+	"time"
+)
+
+var (
+	ErrInvalidKey = errors.New("invalid API key")
+	ErrRevokedKey = errors.New("API key has been revoked")
+	ErrExpiredKey = errors.New("API key has expired")
+)
+
+// Authenticator accepts keys given in an X-API-Key header or an
+// "Authorization: ApiKey ..." header. The principal acts for the owning user,
+// limited to the scopes of the key.
+type Authenticator struct {
+	store         Store
+	touchInterval time.Duration
+	now           func() time.Time
+}
+
+// NewAuthenticator records when keys are used at most once per
+// touchInterval, to avoid a database write on every request.
+func NewAuthenticator(store Store, touchInterval time.Duration) *Authenticator {
+	return &Authenticator{store: store, touchInterval: touchInterval, now: time.Now}
+}
+
+func (a *Authenticator) Authenticate(c echo.Context) (*auth.Principal, error) {
+	token, ok := auth.APIKey(c.Request())
+	if !ok {
+		return nil, nil
+	}
+
+	prefix, secret, ok := Parse(token)
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+
+	ctx := c.Request().Context()
+
+	key, err := a.store.FindByPrefix(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	if key == nil || !Verify(secret, key.SecretHash) {
+		return nil, ErrInvalidKey
+	}
+
+	now := a.now()
+
+	if key.RevokedAt != nil {
+		return nil, ErrRevokedKey
+	}
+
+	if key.ExpiresAt != nil && !now.Before(*key.ExpiresAt) {
+		return nil, ErrExpiredKey
+	}
+
+	// Usage tracking is best effort and never fails the request.
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= a.touchInterval {
+		_ = a.store.Touch(ctx, key.ID, now)
+	}
+
+	return &auth.Principal{
+		Type:   auth.PrincipalAPIKey,
+		Method: auth.MethodAPIKey,
+		ID:     key.UserID,
+		Scopes: key.Scopes,
+	}, nil
+}
@@ -0,0 +1,57 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	publicLength = 6
+	secretLength = 32
+)
+
+// Generate returns a new key of the form <prefix>_<public>_<secret>, its
+// visible prefix <prefix>_<public> used to look it up, and the hash of the
+// secret to store. The key itself is only shown once, when it is created.
+func Generate(prefix string) (key string, visible string, hash string, err error) {
+	public := make([]byte, publicLength)
+	if _, err = rand.Read(public); err != nil {
+		return "", "", "", err
+	}
+
+	secret := make([]byte, secretLength)
+	if _, err = rand.Read(secret); err != nil {
+		return "", "", "", err
+	}
+
+	visible = prefix + "_" + hex.EncodeToString(public)
+	encoded := hex.EncodeToString(secret)
+
+	return visible + "_" + encoded, visible, Hash(encoded), nil
+}
+
+// Parse splits a key into its visible prefix and secret.
+func Parse(key string) (visible string, secret string, ok bool) {
+	i := strings.LastIndex(key, "_")
+	if i <= 0 || i == len(key)-1 {
+		return "", "", false
+	}
+
+	return key[:i], key[i+1:], true
+}
+
+// Hash returns the hex encoded SHA-256 of secret. A fast hash is enough, as
+// secrets are random and long rather than chosen by users.
+func Hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// Verify compares secret with the stored hash in constant time.
+func Verify(secret string, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(Hash(secret)), []byte(hash)) == 1
+}
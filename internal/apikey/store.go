@@ -0,0 +1,162 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/model"
+	"gorm.io/gorm"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Store persists API keys. Lookups return nil without an error when no key
+// matches.
+type Store interface {
+	Create(ctx context.Context, key *model.APIKey) error
+	Update(ctx context.Context, key *model.APIKey) error
+	FindByPrefix(ctx context.Context, prefix string) (*model.APIKey, error)
+	Find(ctx context.Context, userID string, id uint) (*model.APIKey, error)
+	List(ctx context.Context, userID string) ([]model.APIKey, error)
+	Touch(ctx context.Context, id uint, at time.Time) error
+}
+
+type DatabaseStore struct {
+	db *gorm.DB
+}
+
+func NewDatabaseStore(db *gorm.DB) *DatabaseStore {
+	return &DatabaseStore{db: db}
+}
+
+func (s *DatabaseStore) Create(ctx context.Context, key *model.APIKey) error {
+	return s.db.WithContext(ctx).Create(key).Error
+}
+
+func (s *DatabaseStore) Update(ctx context.Context, key *model.APIKey) error {
+	return s.db.WithContext(ctx).Save(key).Error
+}
+
+func (s *DatabaseStore) FindByPrefix(ctx context.Context, prefix string) (*model.APIKey, error) {
+	return s.first(s.db.WithContext(ctx).Where("prefix = ?", prefix))
+}
+
+func (s *DatabaseStore) Find(ctx context.Context, userID string, id uint) (*model.APIKey, error) {
+	return s.first(s.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID))
+}
+
+func (s *DatabaseStore) List(ctx context.Context, userID string) ([]model.APIKey, error) {
+	var keys []model.APIKey
+
+	err := s.db.WithContext(ctx).Where("user_id = ?", userID).Order("id").Find(&keys).Error
+
+	return keys, err
+}
+
+// Touch only updates last_used_at, so it never overwrites a concurrent
+// revocation.
+func (s *DatabaseStore) Touch(ctx context.Context, id uint, at time.Time) error {
+	return s.db.WithContext(ctx).Model(&model.APIKey{}).Where("id = ?", id).UpdateColumn("last_used_at", at).Error
+}
+
+func (s *DatabaseStore) first(query *gorm.DB) (*model.APIKey, error) {
+	var key model.APIKey
+
+	err := query.First(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+// MemoryStore keeps keys in the current process. It is meant for tests.
+type MemoryStore struct {
+	mu     sync.Mutex
+	nextID uint
+	keys   map[uint]model.APIKey
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{keys: map[uint]model.APIKey{}}
+}
+
+func (s *MemoryStore) Create(_ context.Context, key *model.APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	key.ID = s.nextID
+	key.CreatedAt = time.Now()
+	key.UpdatedAt = key.CreatedAt
+	s.keys[key.ID] = *key
+
+	return nil
+}
+
+func (s *MemoryStore) Update(_ context.Context, key *model.APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key.UpdatedAt = time.Now()
+	s.keys[key.ID] = *key
+
+	return nil
+}
+
+func (s *MemoryStore) FindByPrefix(_ context.Context, prefix string) (*model.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range s.keys {
+		if key.Prefix == prefix {
+			return &key, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (s *MemoryStore) Find(_ context.Context, userID string, id uint) (*model.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.keys[id]
+	if !ok || key.UserID != userID {
+		return nil, nil
+	}
+
+	return &key, nil
+}
+
+func (s *MemoryStore) List(_ context.Context, userID string) ([]model.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var keys []model.APIKey
+	for _, key := range s.keys {
+		if key.UserID == userID {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+
+	return keys, nil
+}
+
+func (s *MemoryStore) Touch(_ context.Context, id uint, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key, ok := s.keys[id]; ok {
+		key.LastUsedAt = &at
+		s.keys[id] = key
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+// Package audit records security relevant events, such as lockouts, apart
+// from the request log.
+package audit
+
+import (
+	"context"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/log"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// Event describes what happened and to whom. ActorID is the user acting, when
+// it differs from the subject, e.g. an administrator clearing a lockout.
+type Event struct {
+	Type    string
+	ActorID string
+	Email   string
+	IP      string
+	Details map[string]any
+}
+
+type Recorder interface {
+	Record(ctx context.Context, event Event)
+}
+
+// LogRecorder writes events to the application log, for shipping to a log
+// store or SIEM.
+type LogRecorder struct {
+	log log.LoggerInterface
+}
+
+func NewLogRecorder(log log.LoggerInterface) *LogRecorder {
+	return &LogRecorder{log: log}
+}
+
+func (r *LogRecorder) Record(_ context.Context, event Event) {
+	args := []any{"audit", true, "event", event.Type}
+
+	if event.ActorID != "" {
+		args = append(args, "actor_id", event.ActorID)
+	}
+
+	if event.Email != "" {
+		args = append(args, "email", event.Email)
+	}
+
+	if event.IP != "" {
+		args = append(args, "ip", event.IP)
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(event.Details)) {
+		args = append(args, key, event.Details[key])
+	}
+
+	r.log.Info("Audit event", args...)
+}
+
+// MemoryRecorder keeps events in memory, meant for tests.
+type MemoryRecorder struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (r *MemoryRecorder) Record(_ context.Context, event Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = append(r.events, event)
+}
+
+// Types returns the type of every event recorded, in order.
+func (r *MemoryRecorder) Types() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	types := make([]string, len(r.events))
+	for i, event := range r.events {
+		types[i] = event.Type
+	}
+
+	return types
+}
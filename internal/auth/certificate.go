@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"crypto/x509"
+	"errors"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/config"
+	"github.com/labstack/echo/v4"
+)
+
+var ErrUnknownService = errors.New("client certificate does not belong to a known service")
+
+// CertificateAuthenticator authenticates services by the client certificate
+// verified during the TLS handshake. Only identities listed in services are
+// accepted, as the CA may issue certificates to other workloads.
+type CertificateAuthenticator struct {
+	services map[string]config.Service
+}
+
+func NewCertificateAuthenticator(services map[string]config.Service) *CertificateAuthenticator {
+	return &CertificateAuthenticator{services: services}
+}
+
+func (a *CertificateAuthenticator) Authenticate(c echo.Context) (*Principal, error) {
+	state := c.Request().TLS
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil, nil
+	}
+
+	for _, identity := range CertificateIdentities(state.VerifiedChains[0][0]) {
+		if service, ok := a.services[identity]; ok {
+			return &Principal{
+				Type:   PrincipalService,
+				Method: MethodCertificate,
+				ID:     identity,
+				Role:   service.Role,
+				Scopes: service.Scopes,
+			}, nil
+		}
+	}
+
+	return nil, ErrUnknownService
+}
+
+// CertificateIdentities lists the names a certificate is known by, most
+// specific first: URI SANs such as SPIFFE IDs, DNS SANs, email SANs and the
+// subject common name.
+func CertificateIdentities(certificate *x509.Certificate) []string {
+	var identities []string
+
+	for _, uri := range certificate.URIs {
+		identities = append(identities, uri.String())
+	}
+
+	identities = append(identities, certificate.DNSNames...)
+	identities = append(identities, certificate.EmailAddresses...)
+
+	if certificate.Subject.CommonName != "" {
+		identities = append(identities, certificate.Subject.CommonName)
+	}
+
+	return identities
+}
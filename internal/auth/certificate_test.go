@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/config"
+	"github.com/go-playground/assert/v2"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestCertificateIdentities(t *testing.T) {
+	spiffe, _ := url.Parse("spiffe://cluster.local/ns/billing/sa/payments")
+	certificate := &x509.Certificate{
+		Subject:        pkix.Name{CommonName: "payments"},
+		URIs:           []*url.URL{spiffe},
+		DNSNames:       []string{"payments.billing.svc"},
+		EmailAddresses: []string{"payments@example.com"},
+	}
+
+	assert.Equal(t, CertificateIdentities(certificate), []string{
+		"spiffe://cluster.local/ns/billing/sa/payments",
+		"payments.billing.svc",
+		"payments@example.com",
+		"payments",
+	})
+}
+
+func TestCertificateAuthenticator(t *testing.T) {
+	authenticator := NewCertificateAuthenticator(map[string]config.Service{
+		"payments.billing.svc": {Role: "service", Scopes: []string{"files:read"}},
+	})
+
+	tests := []struct {
+		name              string
+		state             *tls.ConnectionState
+		expectedPrincipal *Principal
+		expectedErr       error
+	}{
+		{
+			name:  "Plain HTTP request",
+			state: nil,
+		},
+		{
+			name:  "No client certificate",
+			state: &tls.ConnectionState{},
+		},
+		{
+			name: "Known service",
+			state: &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{
+				Subject:  pkix.Name{CommonName: "payments"},
+				DNSNames: []string{"payments.billing.svc"},
+			}}}},
+			expectedPrincipal: &Principal{
+				Type:   PrincipalService,
+				Method: MethodCertificate,
+				ID:     "payments.billing.svc",
+				Role:   "service",
+				Scopes: []string{"files:read"},
+			},
+		},
+		{
+			name: "Unknown service",
+			state: &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{
+				Subject: pkix.Name{CommonName: "reports"},
+			}}}},
+			expectedErr: ErrUnknownService,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.TLS = tt.state
+			c := echo.New().NewContext(req, httptest.NewRecorder())
+
+			principal, err := authenticator.Authenticate(c)
+
+			assert.Equal(t, principal, tt.expectedPrincipal)
+			assert.Equal(t, err, tt.expectedErr)
+		})
+	}
+}
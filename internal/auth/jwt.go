@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"context"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/hook"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/supabase"
+	"github.com/labstack/echo/v4"
+)
+
+// Revocations reports whether a Supabase session was revoked, so its access
+// tokens can be rejected before they expire.
+type Revocations interface {
+	Revoked(ctx context.Context, sessionID string) (bool, error)
+}
+
+type JWTAuthenticator struct {
+	secret      string
+	revocations Revocations
+}
+
+// NewJWTAuthenticator accepts Supabase access tokens. Revocations are only
+// checked when revocations is not nil.
+func NewJWTAuthenticator(secret string, revocations Revocations) *JWTAuthenticator {
+	return &JWTAuthenticator{secret: secret, revocations: revocations}
+}
+
+func (a *JWTAuthenticator) Authenticate(c echo.Context) (*Principal, error) {
+	token, ok := BearerToken(c.Request())
+	if !ok {
+		return nil, nil
+	}
+
+	claims, err := supabase.ParseAccessToken(token, a.secret)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.revocations != nil && claims.SessionID != "" {
+		revoked, err := a.revocations.Revoked(c.Request().Context(), claims.SessionID)
+		if err != nil {
+			return nil, err
+		}
+
+		if revoked {
+			return nil, ErrRevokedSession
+		}
+	}
+
+	principal := PrincipalFromClaims(claims, token)
+	principal.Method = MethodBearer
+
+	return principal, nil
+}
+
+// PrincipalFromClaims returns the user principal of a Supabase access token.
+func PrincipalFromClaims(claims *supabase.AccessTokenClaims, token string) *Principal {
+	role, _ := claims.Custom[hook.RoleClaim].(string)
+
+	return &Principal{
+		Type:      PrincipalUser,
+		ID:        claims.Subject,
+		Email:     claims.Email,
+		Role:      role,
+		Token:     token,
+		SessionID: claims.SessionID,
+	}
+}
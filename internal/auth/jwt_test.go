@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"context"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/supabase"
+	"github.com/go-playground/assert/v2"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type revocations map[string]bool
+
+func (r revocations) Revoked(_ context.Context, sessionID string) (bool, error) {
+	return r[sessionID], nil
+}
+
+func TestJWTAuthenticator(t *testing.T) {
+	claims := supabase.AccessTokenClaims{
+		Subject:   "123",
+		Role:      supabase.AuthenticatedRole,
+		Audience:  supabase.Audience{supabase.AuthenticatedRole},
+		Email:     "test@example.com",
+		ExpiresAt: time.Now().Add(time.Hour).Unix(),
+	}
+	claims.Set("user_role", "admin")
+	token, _ := supabase.SignAccessToken(claims, "secret")
+
+	claims.SessionID = "revoked"
+	revokedToken, _ := supabase.SignAccessToken(claims, "secret")
+
+	anonKey, _ := supabase.SignAccessToken(supabase.AccessTokenClaims{
+		Issuer:    "supabase",
+		Role:      "anon",
+		ExpiresAt: time.Now().Add(time.Hour).Unix(),
+	}, "secret")
+
+	claims.SessionID = ""
+	claims.ExpiresAt = 0
+	withoutExpiry, _ := supabase.SignAccessToken(claims, "secret")
+
+	tests := []struct {
+		name              string
+		header            string
+		expectedPrincipal *Principal
+		expectedErr       error
+	}{
+		{
+			name:   "Valid bearer token",
+			header: "Bearer " + token,
+			expectedPrincipal: &Principal{
+				Type:   PrincipalUser,
+				Method: MethodBearer,
+				ID:     "123",
+				Email:  "test@example.com",
+				Role:   "admin",
+				Token:  token,
+			},
+		},
+		{
+			name:              "No header",
+			header:            "",
+			expectedPrincipal: nil,
+		},
+		{
+			name:              "Other scheme",
+			header:            "Basic dXNlcjpwYXNz",
+			expectedPrincipal: nil,
+		},
+		{
+			name:              "Revoked session",
+			header:            "Bearer " + revokedToken,
+			expectedPrincipal: nil,
+			expectedErr:       ErrRevokedSession,
+		},
+		{
+			name:              "Anon key",
+			header:            "Bearer " + anonKey,
+			expectedPrincipal: nil,
+			expectedErr:       supabase.ErrInvalidToken,
+		},
+		{
+			name:              "Token without expiry",
+			header:            "Bearer " + withoutExpiry,
+			expectedPrincipal: nil,
+			expectedErr:       supabase.ErrInvalidToken,
+		},
+		{
+			name:              "Invalid token",
+			header:            "Bearer invalid",
+			expectedPrincipal: nil,
+			expectedErr:       supabase.ErrInvalidToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(echo.HeaderAuthorization, tt.header)
+			}
+			c := echo.New().NewContext(req, httptest.NewRecorder())
+
+			principal, err := NewJWTAuthenticator("secret", revocations{"revoked": true}).Authenticate(c)
+
+			assert.Equal(t, principal, tt.expectedPrincipal)
+			assert.Equal(t, err, tt.expectedErr)
+		})
+	}
+}
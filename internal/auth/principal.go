@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+const principalContextKey = "auth.principal"
+
+type PrincipalType string
+
+const (
+	PrincipalUser    PrincipalType = "user"
+	PrincipalService PrincipalType = "service"
+	PrincipalAPIKey  PrincipalType = "api_key"
+)
+
+// Method is how a principal authenticated.
+type Method string
+
+const (
+	MethodBearer      Method = "bearer"
+	MethodAPIKey      Method = "api_key"
+	MethodSession     Method = "session"
+	MethodCertificate Method = "certificate"
+)
+
+// RoleAdmin is the application role, set by the custom access token hook,
+// allowed to use the administration endpoints.
+const RoleAdmin = "admin"
+
+var (
+	ErrUnauthenticated = errors.New("authentication required")
+	ErrRevokedSession  = errors.New("session has been revoked")
+)
+
+// Principal is the authenticated caller of a request, regardless of how it
+// authenticated. SessionID is the Supabase session of user principals.
+type Principal struct {
+	Type      PrincipalType
+	Method    Method
+	ID        string
+	Email     string
+	Role      string
+	Scopes    []string
+	Token     string
+	SessionID string
+}
+
+// HeaderCredentials reports whether the principal authenticated with a bearer
+// token or API key, which browsers never send on their own.
+func (p *Principal) HeaderCredentials() bool {
+	return p.Method == MethodBearer || p.Method == MethodAPIKey
+}
+
+func (p *Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+// Authenticator resolves the principal of a request. It returns nil without
+// an error when the request carries no credentials it recognises, so the next
+// authenticator can be tried.
+type Authenticator interface {
+	Authenticate(c echo.Context) (*Principal, error)
+}
+
+func SetPrincipal(c echo.Context, principal *Principal) {
+	c.Set(principalContextKey, principal)
+}
+
+func FromContext(c echo.Context) *Principal {
+	principal, _ := c.Get(principalContextKey).(*Principal)
+
+	return principal
+}
+
+// BearerToken extracts the token from an "Authorization: Bearer ..." header.
+func BearerToken(req *http.Request) (string, bool) {
+	scheme, token, found := strings.Cut(req.Header.Get(echo.HeaderAuthorization), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+
+	return token, true
+}
+
+// APIKey extracts the key from an "X-API-Key" header or an
+// "Authorization: ApiKey ..." header.
+func APIKey(req *http.Request) (string, bool) {
+	if key := req.Header.Get("X-API-Key"); key != "" {
+		return key, true
+	}
+
+	scheme, key, found := strings.Cut(req.Header.Get(echo.HeaderAuthorization), " ")
+	if !found || !strings.EqualFold(scheme, "ApiKey") || key == "" {
+		return "", false
+	}
+
+	return key, true
+}
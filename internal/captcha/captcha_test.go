@@ -0,0 +1,60 @@
+package captcha
+
+import (
+	"context"
+	"github.com/go-playground/assert/v2"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSiteVerifier(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.PostFormValue("secret"), "secret")
+		assert.Equal(t, r.PostFormValue("remoteip"), "192.0.2.1")
+
+		switch r.PostFormValue("response") {
+		case "valid":
+			_, _ = w.Write([]byte(`{"success": true}`))
+		case "broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			_, _ = w.Write([]byte(`{"success": false, "error-codes": ["invalid-input-response"]}`))
+		}
+	}))
+	defer server.Close()
+
+	verifier := NewSiteVerifier(server.URL, "secret", time.Second)
+	ctx := context.Background()
+
+	assert.Equal(t, verifier.Verify(ctx, "valid", "192.0.2.1"), nil)
+	assert.Equal(t, verifier.Verify(ctx, "invalid", "192.0.2.1"), ErrInvalidToken)
+	assert.Equal(t, verifier.Verify(ctx, "", "192.0.2.1"), ErrMissingToken)
+
+	err := verifier.Verify(ctx, "broken", "192.0.2.1")
+	assert.NotEqual(t, err, nil)
+	assert.NotEqual(t, err, ErrInvalidToken)
+}
+
+func TestNewVerifier(t *testing.T) {
+	hcaptcha, err := NewVerifier(ProviderHCaptcha, "secret", time.Second)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, hcaptcha.url, HCaptchaURL)
+
+	turnstile, err := NewVerifier(ProviderTurnstile, "secret", time.Second)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, turnstile.url, TurnstileURL)
+
+	_, err = NewVerifier("recaptcha", "secret", time.Second)
+	assert.NotEqual(t, err, nil)
+}
+
+func TestFake(t *testing.T) {
+	fake := NewFake("pass")
+
+	assert.Equal(t, fake.Verify(context.Background(), "pass", ""), nil)
+	assert.Equal(t, fake.Verify(context.Background(), "fail", ""), ErrInvalidToken)
+	assert.Equal(t, fake.Verify(context.Background(), "", ""), ErrMissingToken)
+	assert.Equal(t, fake.Tokens(), []string{"pass", "fail", ""})
+}
@@ -1,76 +1,608 @@
 package config
 
 import (
-	"flag"
 	"fmt"
-	"gopkg.in/yaml.v3"
 	"os"
+	"slices"
+	"strings"
 )
 
 type Application struct {
-	Name    string `yaml:"name"`
+	Name    string `yaml:"name" validate:"required"`
 	Version string `yaml:"version"`
-	Env     string `yaml:"env"`
+	Env     string `yaml:"env" validate:"oneof=dev test staging production"`
 	Debug   bool   `yaml:"debug"`
 }
 
+// Server configures the HTTP listener. H2C serves HTTP/2 without TLS, for
+// running behind a proxy or service mesh that terminates TLS itself.
+// Server takes the client IP from X-Forwarded-For only on requests of the
+// TrustedProxies, IPs or CIDR ranges, and from the connection otherwise.
 type Server struct {
-	Port         int `yaml:"port"`
-	Timeout      int `yaml:"timeout"`
-	ReadTimeout  int `yaml:"read_timeout"`
-	WriteTimeout int `yaml:"write_timeout"`
+	Port           int      `yaml:"port" validate:"min=1,max=65535"`
+	Timeout        int      `yaml:"timeout" validate:"gt=0"`
+	ReadTimeout    int      `yaml:"read_timeout" validate:"gt=0"`
+	WriteTimeout   int      `yaml:"write_timeout" validate:"gt=0"`
+	H2C            bool     `yaml:"h2c"`
+	TrustedProxies []string `yaml:"trusted_proxies" validate:"dive,cidr|ip"`
+	TLS            TLS      `yaml:"tls"`
+}
+
+// TLS terminates HTTPS in the server. The certificate and key are reloaded
+// when their files change, so renewed certificates apply without a restart.
+// A non zero RedirectPort listens for plain HTTP and redirects to HTTPS.
+type TLS struct {
+	Enabled      bool      `yaml:"enabled"`
+	CertFile     string    `yaml:"cert_file" validate:"required_if=Enabled true"`
+	KeyFile      string    `yaml:"key_file" validate:"required_if=Enabled true"`
+	MinVersion   string    `yaml:"min_version" validate:"oneof=1.2 1.3"`
+	CipherSuites []string  `yaml:"cipher_suites"`
+	RedirectPort int       `yaml:"redirect_port" validate:"min=0,max=65535"`
+	Client       ClientTLS `yaml:"client"`
+}
+
+const (
+	ClientAuthRequire       = "require"
+	ClientAuthVerifyIfGiven = "verify_if_given"
+)
+
+// ClientTLS verifies client certificates against the CA bundle, for services
+// calling the API. The handshake accepts connections without a certificate;
+// Groups sets whether the auth, files and hooks route groups require one or
+// only verify it when given. Services lists the identities allowed to
+// authenticate, taken from the certificate URI, DNS or email SANs or its
+// subject common name.
+type ClientTLS struct {
+	Enabled  bool               `yaml:"enabled"`
+	CAFile   string             `yaml:"ca_file" validate:"required_if=Enabled true"`
+	Groups   map[string]string  `yaml:"groups" validate:"dive,keys,oneof=auth files hooks,endkeys,oneof=require verify_if_given"`
+	Services map[string]Service `yaml:"services" validate:"dive"`
+}
+
+type Service struct {
+	Role   string   `yaml:"role"`
+	Scopes []string `yaml:"scopes"`
 }
 
 type Database struct {
-	Driver string `yaml:"driver"`
-	Dsn    string `yaml:"dsn"`
+	Enabled bool   `yaml:"enabled"`
+	Driver  string `yaml:"driver" validate:"required_if=Enabled true,omitempty,oneof=mysql"`
+	Dsn     string `yaml:"dsn" validate:"required_if=Enabled true" secret:"true"`
+}
+
+type Hooks struct {
+	Secret    string `yaml:"secret" secret:"true"`
+	Tolerance int    `yaml:"tolerance" validate:"min=0"`
 }
 
 type Supabase struct {
-	Url string `yaml:"url"`
-	Key string `yaml:"key"`
+	Url       string `yaml:"url" validate:"required,url"`
+	Key       string `yaml:"key" validate:"required" secret:"true"`
+	JwtSecret string `yaml:"jwt_secret" validate:"required" secret:"true"`
+	Hooks     Hooks  `yaml:"hooks"`
 }
 
-type Config struct {
-	Application Application `yaml:"application"`
-	Server      Server      `yaml:"server"`
-	Database    Database    `yaml:"database"`
-	Supabase    Supabase    `yaml:"supabase"`
+// Storage only accepts uploads whose content, sniffed as by
+// http.DetectContentType, is one of the AllowedContentTypes.
+type Storage struct {
+	Bucket              string   `yaml:"bucket" validate:"required"`
+	MaxUploadSize       int64    `yaml:"max_upload_size" validate:"gt=0"`
+	AllowedContentTypes []string `yaml:"allowed_content_types"`
+	SignedURLExpiry     int      `yaml:"signed_url_expiry" validate:"gt=0"`
 }
 
-func NewConfig() (*Config, error) {
-	configPath, err := parseConfig()
-	if err != nil {
-		return nil, err
+type SMTP struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port" validate:"min=0,max=65535"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password" secret:"true"`
+}
+
+type Mail struct {
+	Driver        string `yaml:"driver" validate:"oneof=smtp file memory"`
+	From          string `yaml:"from" validate:"required_unless=Driver memory"`
+	DefaultLocale string `yaml:"default_locale" validate:"required"`
+	Directory     string `yaml:"directory" validate:"required_if=Driver file"`
+	Workers       int    `yaml:"workers" validate:"min=0"`
+	MaxRetries    int    `yaml:"max_retries" validate:"min=0"`
+	RetryBackoff  int    `yaml:"retry_backoff" validate:"min=0"`
+	SMTP          SMTP   `yaml:"smtp"`
+}
+
+type Queue struct {
+	Driver          string `yaml:"driver" validate:"omitempty,oneof=database memory"`
+	Concurrency     int    `yaml:"concurrency" validate:"gt=0"`
+	PollInterval    int    `yaml:"poll_interval" validate:"gt=0"`
+	MaxAttempts     int    `yaml:"max_attempts" validate:"gt=0"`
+	RetryBackoff    int    `yaml:"retry_backoff" validate:"min=0"`
+	MaxRetryBackoff int    `yaml:"max_retry_backoff" validate:"min=0"`
+	ReserveTimeout  int    `yaml:"reserve_timeout" validate:"min=0"`
+	Retention       int    `yaml:"retention" validate:"min=0"`
+}
+
+type Scheduler struct {
+	Enabled  bool              `yaml:"enabled"`
+	Timezone string            `yaml:"timezone" validate:"omitempty,timezone"`
+	Tasks    map[string]string `yaml:"tasks" validate:"dive,omitempty,cron"`
+}
+
+type Log struct {
+	Level string `yaml:"level" validate:"oneof=debug info warn error"`
+}
+
+// RateLimit maps route groups to their policy. The global policy applies to
+// every request, the others to the login, register, forgotten_password and
+// resend_confirmation routes and the files and api_keys groups. Groups without a policy are
+// only subject to the global one.
+type RateLimit struct {
+	Enabled  bool                       `yaml:"enabled"`
+	Policies map[string]RateLimitPolicy `yaml:"policies" validate:"dive,keys,oneof=global login register forgotten_password resend_confirmation files api_keys,endkeys"`
+}
+
+// RateLimitPolicy allows Limit requests per Window seconds. The token bucket
+// also allows bursts of up to Burst requests, Limit when unset. Requests are
+// counted per client IP, user or API key; the global policy runs before
+// authentication, so it falls back to the IP.
+type RateLimitPolicy struct {
+	Algorithm string `yaml:"algorithm" validate:"oneof=token_bucket sliding_window"`
+	Limit     int    `yaml:"limit" validate:"gt=0"`
+	Window    int    `yaml:"window" validate:"gt=0"`
+	Burst     int    `yaml:"burst" validate:"min=0"`
+	Key       string `yaml:"key" validate:"oneof=ip user api_key"`
+}
+
+// RateLimitStore selects where counters are kept. The memory store only
+// limits within one instance, the database and redis stores are shared.
+type RateLimitStore struct {
+	Driver string `yaml:"driver" validate:"oneof=memory database redis"`
+	Redis  Redis  `yaml:"redis"`
+}
+
+type Redis struct {
+	Addr     string `yaml:"addr" validate:"omitempty,hostname_port"`
+	Password string `yaml:"password" secret:"true"`
+	DB       int    `yaml:"db" validate:"min=0"`
+	Timeout  int    `yaml:"timeout" validate:"gt=0"`
+}
+
+// Lockout slows down and then locks out repeated failed logins, counted per
+// email address and per client IP in the rate limit store. RecoveryEmails
+// limits the password recovery emails sent to one address.
+type Lockout struct {
+	Enabled        bool          `yaml:"enabled"`
+	Email          LockoutPolicy `yaml:"email"`
+	IP             LockoutPolicy `yaml:"ip"`
+	RecoveryEmails Throttle      `yaml:"recovery_emails"`
+}
+
+// LockoutPolicy locks a client out for Duration seconds after MaxAttempts
+// failures within Window seconds, zero never locking it. Past FreeAttempts
+// failures, each attempt must wait Delay seconds, doubled for every further
+// failure up to MaxDelay.
+type LockoutPolicy struct {
+	MaxAttempts  int `yaml:"max_attempts" validate:"min=0"`
+	Window       int `yaml:"window" validate:"gt=0"`
+	Duration     int `yaml:"duration" validate:"min=0"`
+	FreeAttempts int `yaml:"free_attempts" validate:"min=0"`
+	Delay        int `yaml:"delay" validate:"min=0"`
+	MaxDelay     int `yaml:"max_delay" validate:"min=0"`
+}
+
+// Throttle allows Limit actions per Window seconds.
+type Throttle struct {
+	Limit  int `yaml:"limit" validate:"gt=0"`
+	Window int `yaml:"window" validate:"gt=0"`
+}
+
+// Registration selects who may sign up: anyone (open), addresses of the
+// AllowedDomains (domains), holders of an invitation code (invite) or nobody
+// (disabled). Invitations last InvitationLifetime seconds and can be used
+// InvitationMaxUses times unless issued otherwise. Invitations require the
+// database.
+//
+// Supabase Auth accepts signups made with the public key directly, so modes
+// other than open require supabase.hooks.secret and the before-user-created
+// hook of Supabase Auth pointing at /hooks/before-user-created. The hook
+// applies the mode to every user created, including from the dashboard.
+type Registration struct {
+	Mode               string   `yaml:"mode" validate:"oneof=open domains invite disabled"`
+	AllowedDomains     []string `yaml:"allowed_domains" validate:"required_if=Mode domains,dive,fqdn"`
+	InvitationLifetime int      `yaml:"invitation_lifetime" validate:"gt=0"`
+	InvitationMaxUses  int      `yaml:"invitation_max_uses" validate:"gt=0"`
+}
+
+// AllowsDomain reports whether the domain of the email is one of the
+// AllowedDomains.
+func (r Registration) AllowsDomain(email string) bool {
+	_, domain, _ := strings.Cut(strings.ToLower(strings.TrimSpace(email)), "@")
+
+	return slices.ContainsFunc(r.AllowedDomains, func(allowed string) bool { return strings.EqualFold(allowed, domain) })
+}
+
+// Confirmation completes the confirmation links that Supabase Auth emails,
+// pointing at GET /verify with token_hash and type: the page shown posts them
+// back to /verify, then redirects to RedirectURL. ResendEmails limits the
+// confirmation emails resent to one address.
+type Confirmation struct {
+	RedirectURL  string   `yaml:"redirect_url" validate:"required,url"`
+	ResendEmails Throttle `yaml:"resend_emails"`
+}
+
+// Captcha protects the given endpoints from bots, in the given environments
+// or in all of them when none is listed. Clients send the token of the
+// hCaptcha or Turnstile widget in the X-Captcha-Token header. Tokens can only
+// be checked once: with Forward they are passed on to Supabase Auth as
+// gotrue_meta_security.captcha_token for its own captcha protection to check,
+// otherwise the application checks them with the provider. Timeout is in
+// seconds.
+type Captcha struct {
+	Enabled      bool     `yaml:"enabled"`
+	Provider     string   `yaml:"provider" validate:"oneof=hcaptcha turnstile"`
+	Secret       string   `yaml:"secret" validate:"required_if=Enabled true Forward false" secret:"true"`
+	Forward      bool     `yaml:"forward"`
+	Endpoints    []string `yaml:"endpoints" validate:"dive,oneof=register login forgotten_password resend_confirmation"`
+	Environments []string `yaml:"environments" validate:"dive,oneof=dev test staging production"`
+	Timeout      int      `yaml:"timeout" validate:"gt=0"`
+}
+
+// Protects reports whether captcha is required on the endpoint in the
+// environment.
+func (c Captcha) Protects(env string, endpoint string) bool {
+	if !c.Enabled || !slices.Contains(c.Endpoints, endpoint) {
+		return false
 	}
 
-	config := &Config{}
+	return len(c.Environments) == 0 || slices.Contains(c.Environments, env)
+}
 
-	file, err := os.ReadFile(configPath)
-	if err != nil {
-		return nil, err
+// Passwords is the policy of new passwords, on registration and reset.
+// MinClasses counts the lowercase, uppercase, digit and symbol classes used,
+// and MinEntropy is an estimate in bits. DisallowEmail rejects passwords
+// containing the email address or a part of it. MinLength counts characters,
+// MaxLength bytes, as Supabase Auth hashes at most 72 bytes.
+type Passwords struct {
+	MinLength     int             `yaml:"min_length" validate:"min=1"`
+	MaxLength     int             `yaml:"max_length" validate:"omitempty,gtefield=MinLength,max=72"`
+	MinClasses    int             `yaml:"min_classes" validate:"min=0,max=4"`
+	MinEntropy    float64         `yaml:"min_entropy" validate:"min=0"`
+	DisallowEmail bool            `yaml:"disallow_email"`
+	Breached      BreachedDataset `yaml:"breached"`
+}
+
+// BreachedDataset rejects passwords found in a local copy of the Pwned
+// Passwords range files, one <PREFIX>.txt file per SHA-1 prefix in Path.
+type BreachedDataset struct {
+	Enabled bool   `yaml:"enabled"`
+	Path    string `yaml:"path" validate:"required_if=Enabled true"`
+}
+
+// Sessions tracks the Supabase session of every login, so users can list and
+// revoke them, and lets browser clients log in with "session": true, keeping
+// the Supabase tokens server side behind an HttpOnly cookie. The access token
+// of cookie sessions is refreshed when it expires within RefreshBefore
+// seconds. Cookie sessions end Lifetime seconds after login, other sessions
+// Lifetime seconds after their last refresh. Revoked sessions are kept for
+// RevokedRetention seconds, so their refresh token is rejected instead of
+// tracked as a new session; it should be at least the lifetime of Supabase
+// refresh tokens, set by the session timebox. The driver defaults to the
+// database whenever it is enabled. The tokens are stored encrypted with
+// EncryptionKey, generated like the key of the secret store; changing it ends
+// the cookie sessions.
+type Sessions struct {
+	Enabled          bool   `yaml:"enabled"`
+	Driver           string `yaml:"driver" validate:"omitempty,oneof=database memory"`
+	EncryptionKey    string `yaml:"encryption_key" validate:"required_if=Enabled true" secret:"true"`
+	CookieName       string `yaml:"cookie_name" validate:"required"`
+	CookieDomain     string `yaml:"cookie_domain"`
+	CookieSecure     bool   `yaml:"cookie_secure"`
+	CookieSameSite   string `yaml:"cookie_same_site" validate:"oneof=lax strict none"`
+	Lifetime         int    `yaml:"lifetime" validate:"gt=0"`
+	RefreshBefore    int    `yaml:"refresh_before" validate:"min=0"`
+	RevokedRetention int    `yaml:"revoked_retention" validate:"gt=0"`
+}
+
+// CSRF protects cookie authenticated requests with double submit tokens.
+// Browser clients get the token from GET /csrf, which also sets it in the
+// cookie, and send it back in the X-CSRF-Token header. Requests authenticated
+// by a bearer token or API key carry no ambient credentials and are exempt.
+// MaxAge is in seconds.
+type CSRF struct {
+	CookieName     string `yaml:"cookie_name" validate:"required"`
+	CookieDomain   string `yaml:"cookie_domain"`
+	CookieSameSite string `yaml:"cookie_same_site" validate:"oneof=lax strict none"`
+	CookieMaxAge   int    `yaml:"cookie_max_age" validate:"gt=0"`
+}
+
+// SecurityHeaders sets the browser security headers of every response. Empty
+// values leave their header out; environments differ through the overlay
+// files, e.g. config.production.yml.
+type SecurityHeaders struct {
+	Enabled            bool   `yaml:"enabled"`
+	HSTS               HSTS   `yaml:"hsts"`
+	ContentTypeOptions bool   `yaml:"content_type_options"`
+	FrameOptions       string `yaml:"frame_options" validate:"omitempty,oneof=DENY SAMEORIGIN"`
+	ReferrerPolicy     string `yaml:"referrer_policy" validate:"omitempty,oneof=no-referrer no-referrer-when-downgrade origin origin-when-cross-origin same-origin strict-origin strict-origin-when-cross-origin unsafe-url"`
+	PermissionsPolicy  string `yaml:"permissions_policy"`
+	CSP                CSP    `yaml:"csp"`
+}
+
+// HSTS is only sent over HTTPS, for MaxAge seconds. Zero leaves it out.
+type HSTS struct {
+	MaxAge            int  `yaml:"max_age" validate:"min=0"`
+	IncludeSubdomains bool `yaml:"include_subdomains"`
+	Preload           bool `yaml:"preload"`
+}
+
+// CSP maps Content Security Policy directives to their sources. The 'nonce'
+// source is replaced with a nonce generated for each request. Report points
+// browsers at the POST /csp-report endpoint, which logs violations.
+type CSP struct {
+	Enabled    bool                `yaml:"enabled"`
+	ReportOnly bool                `yaml:"report_only"`
+	Report     bool                `yaml:"report"`
+	Directives map[string][]string `yaml:"directives" validate:"dive,keys,required,endkeys"`
+}
+
+// CORS lets browser clients on other origins call the API. Origins are exact,
+// such as "https://app.example.com", wildcard subdomain patterns, such as
+// "https://*.example.com", or "*" for any origin, which is ignored when
+// credentials are allowed. Environments replaces the origins for the given
+// application environments, and Groups overrides the policy of the auth,
+// files, api_keys, sessions, admin and hooks route groups. MaxAge is in
+// seconds.
+type CORS struct {
+	AllowOrigins     []string                `yaml:"allow_origins" validate:"dive,required"`
+	AllowMethods     []string                `yaml:"allow_methods" validate:"dive,required"`
+	AllowHeaders     []string                `yaml:"allow_headers" validate:"dive,required"`
+	AllowCredentials bool                    `yaml:"allow_credentials"`
+	ExposeHeaders    []string                `yaml:"expose_headers" validate:"dive,required"`
+	MaxAge           int                     `yaml:"max_age" validate:"min=0"`
+	Environments     map[string][]string     `yaml:"environments" validate:"dive,keys,oneof=dev test staging production,endkeys,dive,required"`
+	Groups           map[string]CORSOverride `yaml:"groups" validate:"dive,keys,oneof=auth files api_keys sessions admin hooks,endkeys"`
+}
+
+// CORSOverride replaces the values it sets in the policy of a route group.
+type CORSOverride struct {
+	AllowOrigins     []string `yaml:"allow_origins" validate:"dive,required"`
+	AllowMethods     []string `yaml:"allow_methods" validate:"dive,required"`
+	AllowHeaders     []string `yaml:"allow_headers" validate:"dive,required"`
+	AllowCredentials *bool    `yaml:"allow_credentials"`
+	ExposeHeaders    []string `yaml:"expose_headers" validate:"dive,required"`
+	MaxAge           *int     `yaml:"max_age" validate:"omitempty,min=0"`
+}
+
+// Policy returns the CORS policy for a route group in an environment.
+func (c CORS) Policy(env string, group string) CORS {
+	policy := c
+	policy.Environments, policy.Groups = nil, nil
+
+	if origins, ok := c.Environments[env]; ok {
+		policy.AllowOrigins = origins
 	}
 
-	replaced := os.ExpandEnv(string(file))
-	err = yaml.Unmarshal([]byte(replaced), config)
-	if err != nil {
-		return nil, err
+	override, ok := c.Groups[group]
+	if !ok {
+		return policy
 	}
 
-	return config, nil
-}
+	if override.AllowOrigins != nil {
+		policy.AllowOrigins = override.AllowOrigins
+	}
+
+	if override.AllowMethods != nil {
+		policy.AllowMethods = override.AllowMethods
+	}
 
-func parseConfig() (string, error) {
-	var configPath string
+	if override.AllowHeaders != nil {
+		policy.AllowHeaders = override.AllowHeaders
+	}
 
-	flag.StringVar(&configPath, "config", "./config/config.yml", "path to config file")
-	flag.Parse()
+	if override.AllowCredentials != nil {
+		policy.AllowCredentials = *override.AllowCredentials
+	}
 
-	if err := validateConfigPath(configPath); err != nil {
-		return "", err
+	if override.ExposeHeaders != nil {
+		policy.ExposeHeaders = override.ExposeHeaders
 	}
 
-	return configPath, nil
+	if override.MaxAge != nil {
+		policy.MaxAge = *override.MaxAge
+	}
+
+	return policy
+}
+
+// APIKeys configures long-lived keys for integrations. Scopes lists what a
+// key may be granted. Usage is recorded at most once per TouchInterval
+// seconds.
+type APIKeys struct {
+	Enabled       bool     `yaml:"enabled"`
+	Prefix        string   `yaml:"prefix" validate:"required,alphanum"`
+	Scopes        []string `yaml:"scopes" validate:"dive,required"`
+	TouchInterval int      `yaml:"touch_interval" validate:"min=0"`
+}
+
+// Reload controls the file watcher. A SIGHUP always triggers a reload.
+type Reload struct {
+	Watch    bool `yaml:"watch"`
+	Interval int  `yaml:"interval" validate:"required_if=Watch true,omitempty,gt=0"`
+}
+
+// Secrets locates the encrypted store used by secret://store/<name> values.
+// The key is best given through SECRETS_KEY or SECRETS_KEY_FILE.
+type Secrets struct {
+	Store string `yaml:"store"`
+	Key   string `yaml:"key" secret:"true"`
+}
+
+type Config struct {
+	Application    Application    `yaml:"application"`
+	Server         Server         `yaml:"server"`
+	Database       Database       `yaml:"database"`
+	Supabase       Supabase       `yaml:"supabase"`
+	Storage        Storage        `yaml:"storage"`
+	Mail           Mail           `yaml:"mail"`
+	Queue          Queue          `yaml:"queue"`
+	Scheduler      Scheduler      `yaml:"scheduler"`
+	Secrets        Secrets        `yaml:"secrets"`
+	Reload         Reload         `yaml:"reload"`
+	APIKeys        APIKeys        `yaml:"api_keys"`
+	RateLimitStore RateLimitStore `yaml:"rate_limit_store"`
+	Lockout        Lockout        `yaml:"lockout"`
+	Passwords      Passwords      `yaml:"passwords"`
+	Captcha        Captcha        `yaml:"captcha"`
+	Registration   Registration   `yaml:"registration"`
+	Confirmation   Confirmation   `yaml:"confirmation"`
+	CSRF           CSRF           `yaml:"csrf"`
+	Sessions       Sessions       `yaml:"sessions"`
+
+	// Sections tagged reload:"true" are swapped in while the server runs,
+	// any other change needs a restart.
+	Log             Log             `yaml:"log" reload:"true"`
+	RateLimit       RateLimit       `yaml:"rate_limit" reload:"true"`
+	CORS            CORS            `yaml:"cors" reload:"true"`
+	Features        map[string]bool `yaml:"features" reload:"true"`
+	SecurityHeaders SecurityHeaders `yaml:"security_headers" reload:"true"`
+
+	resolvedSecrets []string
+}
+
+const DefaultPath = "./config/config.yml"
+
+// Default returns the built-in values used for any key the configuration
+// files leave out.
+func Default() *Config {
+	return &Config{
+		Application: Application{
+			Name: "rest-api-skeleton",
+			Env:  "dev",
+		},
+		Server: Server{
+			Port:         8080,
+			Timeout:      30,
+			ReadTimeout:  5,
+			WriteTimeout: 10,
+			TLS:          TLS{MinVersion: "1.2"},
+		},
+		Database: Database{
+			Driver: "mysql",
+		},
+		Supabase: Supabase{
+			Hooks: Hooks{Tolerance: 300},
+		},
+		Storage: Storage{
+			MaxUploadSize:   10 << 20,
+			SignedURLExpiry: 3600,
+		},
+		Mail: Mail{
+			Driver:        "memory",
+			DefaultLocale: "en",
+			Directory:     "./storage/mail",
+			MaxRetries:    3,
+			RetryBackoff:  2,
+			SMTP:          SMTP{Port: 25},
+		},
+		Queue: Queue{
+			Concurrency:     4,
+			PollInterval:    1,
+			MaxAttempts:     5,
+			RetryBackoff:    10,
+			MaxRetryBackoff: 3600,
+			ReserveTimeout:  300,
+			Retention:       7,
+		},
+		Scheduler: Scheduler{
+			Timezone: "UTC",
+		},
+		Secrets: Secrets{
+			Store: "./config/secrets.enc",
+		},
+		Reload: Reload{
+			Interval: 2,
+		},
+		APIKeys: APIKeys{
+			Prefix:        "sk",
+			Scopes:        []string{"files:read", "files:write"},
+			TouchInterval: 60,
+		},
+		Log: Log{
+			Level: "info",
+		},
+		RateLimit: RateLimit{
+			Enabled: true,
+			Policies: map[string]RateLimitPolicy{
+				"global": {Algorithm: "token_bucket", Limit: 20, Window: 1, Burst: 40, Key: "ip"},
+			},
+		},
+		RateLimitStore: RateLimitStore{
+			Driver: "memory",
+			Redis:  Redis{Timeout: 5},
+		},
+		Lockout: Lockout{
+			Enabled:        true,
+			Email:          LockoutPolicy{MaxAttempts: 5, Window: 900, Duration: 900, FreeAttempts: 2, Delay: 1, MaxDelay: 30},
+			IP:             LockoutPolicy{MaxAttempts: 50, Window: 900, Duration: 900, FreeAttempts: 10, Delay: 1, MaxDelay: 30},
+			RecoveryEmails: Throttle{Limit: 3, Window: 3600},
+		},
+		Registration: Registration{
+			Mode:               "open",
+			InvitationLifetime: 7 * 24 * 3600,
+			InvitationMaxUses:  1,
+		},
+		Confirmation: Confirmation{
+			RedirectURL:  "http://localhost:3000/",
+			ResendEmails: Throttle{Limit: 3, Window: 3600},
+		},
+		Captcha: Captcha{
+			Provider:  "hcaptcha",
+			Endpoints: []string{"register", "login", "forgotten_password", "resend_confirmation"},
+			Timeout:   5,
+		},
+		Passwords: Passwords{
+			MinLength:     10,
+			MaxLength:     72,
+			MinClasses:    2,
+			MinEntropy:    40,
+			DisallowEmail: true,
+		},
+		Sessions: Sessions{
+			CookieName:       "session",
+			CookieSecure:     true,
+			CookieSameSite:   "lax",
+			Lifetime:         30 * 24 * 3600,
+			RefreshBefore:    60,
+			RevokedRetention: 90 * 24 * 3600,
+		},
+		CSRF: CSRF{
+			CookieName:     "_csrf",
+			CookieSameSite: "lax",
+			CookieMaxAge:   86400,
+		},
+		SecurityHeaders: SecurityHeaders{
+			Enabled:            true,
+			ContentTypeOptions: true,
+			FrameOptions:       "DENY",
+			ReferrerPolicy:     "no-referrer",
+			PermissionsPolicy:  "camera=(), geolocation=(), microphone=()",
+			CSP: CSP{
+				Enabled: true,
+				Directives: map[string][]string{
+					"default-src":     {"'none'"},
+					"frame-ancestors": {"'none'"},
+				},
+			},
+		},
+		CORS: CORS{
+			AllowMethods:     []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE"},
+			AllowHeaders:     []string{"Accept", "Authorization", "Content-Type", "X-API-Key", "X-Captcha-Token", "X-CSRF-Token"},
+			AllowCredentials: true,
+			ExposeHeaders:    []string{"RateLimit-Limit", "RateLimit-Policy", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After"},
+			MaxAge:           600,
+			Environments: map[string][]string{
+				"dev": {"http://localhost:3000", "http://localhost:5173"},
+			},
+		},
+	}
 }
 
 func validateConfigPath(path string) error {
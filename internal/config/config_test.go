@@ -0,0 +1,20 @@
+package config
+
+import (
+	"github.com/go-playground/assert/v2"
+	"testing"
+)
+
+func TestCaptchaProtects(t *testing.T) {
+	cfg := Captcha{Enabled: true, Endpoints: []string{"login"}, Environments: []string{"production"}}
+
+	assert.Equal(t, cfg.Protects("production", "login"), true)
+	assert.Equal(t, cfg.Protects("production", "register"), false)
+	assert.Equal(t, cfg.Protects("dev", "login"), false)
+
+	cfg.Environments = nil
+	assert.Equal(t, cfg.Protects("dev", "login"), true)
+
+	cfg.Enabled = false
+	assert.Equal(t, cfg.Protects("production", "login"), false)
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/secret"
+	"os"
+	"strings"
+)
+
+// Expand replaces environment variables in s. On top of $VAR and ${VAR} it
+// supports ${VAR:-default}, used when VAR is unset or empty, and
+// ${VAR:?message}, which fails when VAR is unset or empty. A variable left
+// empty is read from the file named by VAR_FILE when set. Every missing
+// required variable is reported at once.
+func Expand(s string) (string, error) {
+	var errs []error
+
+	getenv := func(name string) string {
+		value, _, err := secret.LookupEnv(name)
+		if err != nil {
+			errs = append(errs, err)
+		}
+
+		return value
+	}
+
+	expanded := os.Expand(s, func(expr string) string {
+		if name, fallback, ok := strings.Cut(expr, ":-"); ok {
+			if value := getenv(name); value != "" {
+				return value
+			}
+
+			return fallback
+		}
+
+		if name, message, ok := strings.Cut(expr, ":?"); ok {
+			value := getenv(name)
+			if value == "" {
+				if message == "" {
+					message = "must be set"
+				}
+
+				errs = append(errs, fmt.Errorf("%s: %s", name, message))
+			}
+
+			return value
+		}
+
+		return getenv(expr)
+	})
+
+	return expanded, errors.Join(errs...)
+}
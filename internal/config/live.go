@@ -0,0 +1,125 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Live holds the running configuration. Readers always see a complete
+// Config, as reloads swap it atomically.
+type Live struct {
+	current     atomic.Pointer[Config]
+	mu          sync.Mutex
+	subscribers []func(previous *Config, current *Config)
+}
+
+func NewLive(cfg *Config) *Live {
+	l := &Live{}
+	l.current.Store(cfg)
+
+	return l
+}
+
+func (l *Live) Current() *Config {
+	return l.current.Load()
+}
+
+// Feature reports whether the named toggle is on. Unknown toggles are off.
+func (l *Live) Feature(name string) bool {
+	return l.Current().Features[name]
+}
+
+// Subscribe registers fn to be called after every applied reload.
+func (l *Live) Subscribe(fn func(previous *Config, current *Config)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.subscribers = append(l.subscribers, fn)
+}
+
+// Apply swaps in the reloadable sections of loaded. It returns the changed
+// keys that need a restart, which are left untouched, and whether anything
+// was applied.
+func (l *Live) Apply(loaded *Config) (rejected []string, applied bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	previous := l.Current()
+	next := *previous
+
+	target := reflect.ValueOf(&next).Elem()
+	source := reflect.ValueOf(loaded).Elem()
+
+	for _, key := range Diff(previous, loaded) {
+		section, _, _ := strings.Cut(key, ".")
+		field, ok := reloadableField(section)
+		if !ok {
+			rejected = append(rejected, key)
+			continue
+		}
+
+		target.FieldByIndex(field.Index).Set(source.FieldByIndex(field.Index))
+		applied = true
+	}
+
+	if !applied {
+		return rejected, false
+	}
+
+	l.current.Store(&next)
+
+	for _, fn := range l.subscribers {
+		fn(previous, &next)
+	}
+
+	return rejected, true
+}
+
+func reloadableField(section string) (reflect.StructField, bool) {
+	t := reflect.TypeOf(Config{})
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+
+		if name == section {
+			return field, field.Tag.Get("reload") == "true"
+		}
+	}
+
+	return reflect.StructField{}, false
+}
+
+// Diff returns the sorted keys whose value differs between a and b.
+func Diff(a *Config, b *Config) []string {
+	treeA, errA := toTree(a)
+	treeB, errB := toTree(b)
+	if errA != nil || errB != nil {
+		return nil
+	}
+
+	seen := map[string]bool{}
+	var changed []string
+
+	for _, tree := range []map[string]any{treeA, treeB} {
+		for _, key := range keyPaths(reflect.TypeOf(Config{}), nil, tree) {
+			name := strings.Join(key.path, ".")
+			if key.isMap || seen[name] {
+				continue
+			}
+
+			seen[name] = true
+
+			if !reflect.DeepEqual(getPath(treeA, key.path), getPath(treeB, key.path)) {
+				changed = append(changed, name)
+			}
+		}
+	}
+
+	sort.Strings(changed)
+
+	return changed
+}
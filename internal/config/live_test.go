@@ -0,0 +1,112 @@
+package config
+
+import (
+	"context"
+	"github.com/go-playground/assert/v2"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	a := Default()
+	b := Default()
+	b.Server.Port = 9090
+	b.Features = map[string]bool{"beta": true}
+	b.CORS.AllowOrigins = []string{"https://example.com"}
+
+	assert.Equal(t, Diff(a, a), []string(nil))
+	assert.Equal(t, Diff(a, b), []string{"cors.allow_origins", "features.beta", "server.port"})
+}
+
+func TestLiveAppliesReloadableSections(t *testing.T) {
+	live := NewLive(Default())
+
+	var notified *Config
+	live.Subscribe(func(_ *Config, current *Config) { notified = current })
+
+	loaded := Default()
+	loaded.Log.Level = "debug"
+	loaded.Features = map[string]bool{"beta": true}
+	loaded.Server.Port = 9090
+
+	rejected, applied := live.Apply(loaded)
+
+	assert.Equal(t, applied, true)
+	assert.Equal(t, rejected, []string{"server.port"})
+	assert.Equal(t, live.Current().Log.Level, "debug")
+	assert.Equal(t, live.Current().Server.Port, 8080)
+	assert.Equal(t, live.Feature("beta"), true)
+	assert.Equal(t, live.Feature("unknown"), false)
+	assert.Equal(t, notified, live.Current())
+}
+
+func TestLiveIgnoresNonReloadableChanges(t *testing.T) {
+	initial := Default()
+	live := NewLive(initial)
+
+	called := false
+	live.Subscribe(func(*Config, *Config) { called = true })
+
+	loaded := Default()
+	loaded.Database.Enabled = true
+
+	rejected, applied := live.Apply(loaded)
+
+	assert.Equal(t, applied, false)
+	assert.Equal(t, rejected, []string{"database.enabled"})
+	assert.Equal(t, called, false)
+	assert.Equal(t, live.Current(), initial)
+}
+
+func TestWatcherReloadsOnFileChange(t *testing.T) {
+	path := writeConfig(t, t.TempDir(), "config.yml", `
+supabase: {url: "https://project.supabase.co", key: key, jwt_secret: secret}
+storage: {bucket: attachments}
+log: {level: info}
+`)
+
+	options := LoadOptions{Paths: []string{path}}
+	cfg, err := NewConfig(options)
+	assert.Equal(t, err, nil)
+
+	live := NewLive(cfg)
+	reloaded := make(chan *Config, 1)
+	live.Subscribe(func(_ *Config, current *Config) { reloaded <- current })
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError + 1}))
+	watcher := NewWatcher(live, options, logger, 10*time.Millisecond)
+	watcher.Start()
+	defer func() { _ = watcher.Shutdown(context.Background()) }()
+
+	// Make sure the modification time changes on coarse grained filesystems.
+	time.Sleep(20 * time.Millisecond)
+	writeConfig(t, filepath.Dir(path), "config.yml", `
+supabase: {url: "https://project.supabase.co", key: key, jwt_secret: secret}
+storage: {bucket: attachments}
+log: {level: debug}
+server: {port: 9999}
+`)
+	_ = os.Chtimes(path, time.Now().Add(time.Second), time.Now().Add(time.Second))
+
+	select {
+	case current := <-reloaded:
+		assert.Equal(t, current.Log.Level, "debug")
+		assert.Equal(t, current.Server.Port, 8080)
+	case <-time.After(2 * time.Second):
+		t.Fatal("configuration was not reloaded")
+	}
+}
+
+func TestWatcherKeepsConfigWhenInvalid(t *testing.T) {
+	path := writeConfig(t, t.TempDir(), "config.yml", "log: {level: loud}\n")
+
+	live := NewLive(Default())
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError + 1}))
+
+	NewWatcher(live, LoadOptions{Paths: []string{path}}, logger, 0).Reload()
+
+	assert.Equal(t, live.Current().Log.Level, "info")
+}
@@ -0,0 +1,391 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/secret"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// EnvPrefix marks environment variables overriding a single configuration
+// key, e.g. APP_SERVER_PORT sets server.port and APP_MAIL_SMTP_HOST sets
+// mail.smtp.host.
+const EnvPrefix = "APP_"
+
+// LoadOptions selects the configuration layers. Each layer overrides the
+// previous one:
+//
+//  1. built-in defaults
+//  2. the files in Paths, in order
+//  3. the environment overlay of each file, e.g. config.production.yml
+//  4. APP_ prefixed environment variables
+//  5. Overrides, usually given on the command line as key.path=value
+//
+// Files are merged section by section, a list or map they set replacing the
+// one below as a whole. Environment variables and overrides set single keys.
+//
+// Values of the form secret://<provider>/<name> are then resolved. The file,
+// env and store providers are always available; SecretProviders adds to or
+// replaces them.
+type LoadOptions struct {
+	Paths           []string
+	Env             string
+	Overrides       map[string]string
+	SecretProviders map[string]secret.Provider
+}
+
+// NewConfig builds the configuration from every layer described by options.
+func NewConfig(options LoadOptions) (*Config, error) {
+	tree, err := toTree(Default())
+	if err != nil {
+		return nil, err
+	}
+
+	paths := options.Paths
+	if len(paths) == 0 {
+		paths = []string{DefaultPath}
+	}
+
+	for _, path := range paths {
+		if err = validateConfigPath(path); err != nil {
+			return nil, err
+		}
+
+		if err = mergeFile(tree, path); err != nil {
+			return nil, err
+		}
+	}
+
+	env := resolveEnv(options.Env, tree)
+	if env != "" {
+		for _, path := range paths {
+			overlay := overlayPath(path, env)
+			if _, err = os.Stat(overlay); errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+
+			if err = mergeFile(tree, overlay); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	keys := keyPaths(reflect.TypeOf(Config{}), nil, tree)
+
+	for _, key := range keys {
+		value, ok, err := secret.LookupEnv(envName(key.path))
+		if err != nil {
+			return nil, fmt.Errorf("config: %w", err)
+		}
+
+		if ok {
+			setPath(tree, key.path, decodeValue(value))
+		}
+	}
+
+	names := make([]string, 0, len(options.Overrides))
+	for name := range options.Overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		key := strings.Split(name, ".")
+		if !isKnownKey(keys, key) {
+			return nil, fmt.Errorf("config: unknown key %q", name)
+		}
+
+		setPath(tree, key, decodeValue(options.Overrides[name]))
+	}
+
+	if env != "" {
+		setPath(tree, []string{"application", "env"}, env)
+	}
+
+	resolved, err := resolveSecrets(tree, keys, options.SecretProviders)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := fromTree(tree)
+	if err != nil {
+		return nil, err
+	}
+
+	config.resolvedSecrets = resolved
+
+	return config, nil
+}
+
+// resolveSecrets replaces secret references with their value and returns the
+// keys that held one, so they are redacted along with the tagged fields.
+func resolveSecrets(tree map[string]any, keys []keyPath, providers map[string]secret.Provider) ([]string, error) {
+	all := map[string]secret.Provider{
+		"file":  secret.FileProvider(),
+		"env":   secret.EnvProvider(),
+		"store": secret.ProviderFunc(func(ref string) (string, error) { return resolveFromStore(tree, ref) }),
+	}
+
+	for name, provider := range providers {
+		all[name] = provider
+	}
+
+	resolver := secret.NewResolver(all)
+
+	var resolved []string
+	var errs []error
+
+	for _, key := range keys {
+		value, ok := getPath(tree, key.path).(string)
+		if !ok || !secret.IsReference(value) {
+			continue
+		}
+
+		value, err := resolver.Resolve(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", strings.Join(key.path, "."), err))
+			continue
+		}
+
+		setPath(tree, key.path, value)
+		resolved = append(resolved, strings.Join(key.path, "."))
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("config: %w", errors.Join(errs...))
+	}
+
+	return resolved, nil
+}
+
+// resolveFromStore opens the encrypted store configured under secrets.store,
+// with the key from secrets.key, only when a value refers to it.
+func resolveFromStore(tree map[string]any, name string) (string, error) {
+	path, _ := getPath(tree, []string{"secrets", "store"}).(string)
+	key, _ := getPath(tree, []string{"secrets", "key"}).(string)
+
+	if path == "" || key == "" {
+		return "", errors.New("secrets.store and secrets.key must be set to use the store provider")
+	}
+
+	store, err := secret.OpenStore(path, key)
+	if err != nil {
+		return "", err
+	}
+
+	return store.Resolve(name)
+}
+
+func getPath(tree map[string]any, key []string) any {
+	var value any = tree
+
+	for _, segment := range key {
+		node, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+
+		value = node[segment]
+	}
+
+	return value
+}
+
+// overlayPath returns the environment specific file layered over path, e.g.
+// config/config.yml becomes config/config.production.yml.
+func overlayPath(path string, env string) string {
+	ext := filepath.Ext(path)
+
+	return strings.TrimSuffix(path, ext) + "." + env + ext
+}
+
+// resolveEnv picks the environment from the explicit option, then the
+// APP_APPLICATION_ENV variable, then the base files.
+func resolveEnv(env string, tree map[string]any) string {
+	if env != "" {
+		return env
+	}
+
+	if value, ok := os.LookupEnv(envName([]string{"application", "env"})); ok && value != "" {
+		return value
+	}
+
+	if application, ok := tree["application"].(map[string]any); ok {
+		if value, ok := application["env"].(string); ok {
+			return value
+		}
+	}
+
+	return ""
+}
+
+func envName(key []string) string {
+	return EnvPrefix + strings.ToUpper(strings.Join(key, "_"))
+}
+
+func mergeFile(tree map[string]any, path string) error {
+	file, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	expanded, err := Expand(string(file))
+	if err != nil {
+		return fmt.Errorf("config: %s: %w", path, err)
+	}
+
+	layer := map[string]any{}
+	if err = yaml.Unmarshal([]byte(expanded), &layer); err != nil {
+		return fmt.Errorf("config: %s: %w", path, err)
+	}
+
+	merge(tree, layer, reflect.TypeOf(Config{}))
+
+	return nil
+}
+
+// merge copies src into dst, the section of type t. Nested sections are
+// merged key by key, any other value replaces the existing one. That includes
+// lists and map fields such as scheduler.tasks, so a layer setting a map
+// drops the entries of the layers below.
+func merge(dst map[string]any, src map[string]any, t reflect.Type) {
+	for key, value := range src {
+		srcMap, srcIsMap := value.(map[string]any)
+		dstMap, dstIsMap := dst[key].(map[string]any)
+
+		if section, ok := sectionType(t, key); ok && srcIsMap && dstIsMap {
+			merge(dstMap, srcMap, section)
+			continue
+		}
+
+		dst[key] = value
+	}
+}
+
+// sectionType returns the type of the struct field of t under the YAML key,
+// when it is a section rather than a value.
+func sectionType(t reflect.Type, key string) (reflect.Type, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == key {
+			return field.Type, field.Type.Kind() == reflect.Struct
+		}
+	}
+
+	return nil, false
+}
+
+// decodeValue decodes a value given as a string in YAML, so numbers, booleans
+// and flow lists such as "[a, b]" keep their type.
+func decodeValue(raw string) any {
+	var value any
+	if err := yaml.Unmarshal([]byte(raw), &value); err != nil {
+		return raw
+	}
+
+	return value
+}
+
+func setPath(tree map[string]any, key []string, value any) {
+	node := tree
+	for _, segment := range key[:len(key)-1] {
+		child, ok := node[segment].(map[string]any)
+		if !ok {
+			child = map[string]any{}
+			node[segment] = child
+		}
+
+		node = child
+	}
+
+	node[key[len(key)-1]] = value
+}
+
+type keyPath struct {
+	path   []string
+	isMap  bool
+	secret bool
+}
+
+// keyPaths lists the leaf keys of the configuration. Keys of map fields are
+// taken from the loaded tree, as they are not known from the type.
+func keyPaths(t reflect.Type, prefix []string, tree map[string]any) []keyPath {
+	var keys []keyPath
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		key := append(append([]string{}, prefix...), name)
+		isSecret := field.Tag.Get("secret") == "true"
+
+		switch field.Type.Kind() {
+		case reflect.Struct:
+			child, _ := tree[name].(map[string]any)
+			keys = append(keys, keyPaths(field.Type, key, child)...)
+		case reflect.Map:
+			keys = append(keys, keyPath{path: key, isMap: true})
+
+			child, _ := tree[name].(map[string]any)
+			for entry := range child {
+				keys = append(keys, keyPath{path: append(append([]string{}, key...), entry), secret: isSecret})
+			}
+		default:
+			keys = append(keys, keyPath{path: key, secret: isSecret})
+		}
+	}
+
+	return keys
+}
+
+// isKnownKey accepts the leaf keys of the configuration, plus new entries of
+// map fields.
+func isKnownKey(keys []keyPath, key []string) bool {
+	for _, known := range keys {
+		if slices.Equal(known.path, key) || known.isMap && slices.Equal(known.path, key[:len(key)-1]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func toTree(config *Config) (map[string]any, error) {
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := map[string]any{}
+	if err = yaml.Unmarshal(data, &tree); err != nil {
+		return nil, err
+	}
+
+	return tree, nil
+}
+
+func fromTree(tree map[string]any) (*Config, error) {
+	data, err := yaml.Marshal(tree)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Config{}
+	if err = yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+
+	return config, nil
+}
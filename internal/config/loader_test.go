@@ -0,0 +1,151 @@
+package config
+
+import (
+	"github.com/go-playground/assert/v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, dir string, name string, content string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestExpand(t *testing.T) {
+	t.Setenv("CONFIG_TEST_SET", "value")
+	t.Setenv("CONFIG_TEST_EMPTY", "")
+
+	expanded, err := Expand("${CONFIG_TEST_SET} $CONFIG_TEST_SET ${CONFIG_TEST_UNSET} ${CONFIG_TEST_UNSET:-fallback} ${CONFIG_TEST_EMPTY:-fallback} ${CONFIG_TEST_SET:-fallback}")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, expanded, "value value  fallback fallback value")
+
+	_, err = Expand("${CONFIG_TEST_UNSET:?is required} ${CONFIG_TEST_EMPTY:?}")
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, strings.Contains(err.Error(), "CONFIG_TEST_UNSET: is required"), true)
+	assert.Equal(t, strings.Contains(err.Error(), "CONFIG_TEST_EMPTY: must be set"), true)
+}
+
+func TestNewConfigUsesDefaults(t *testing.T) {
+	path := writeConfig(t, t.TempDir(), "config.yml", "application:\n  name: api\n")
+
+	cfg, err := NewConfig(LoadOptions{Paths: []string{path}})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, cfg.Application.Name, "api")
+	assert.Equal(t, cfg.Server.Port, 8080)
+	assert.Equal(t, cfg.Queue.MaxAttempts, 5)
+}
+
+func TestNewConfigLayers(t *testing.T) {
+	dir := t.TempDir()
+	base := writeConfig(t, dir, "config.yml", `
+application:
+  env: staging
+server:
+  port: 8000
+  timeout: 60
+storage:
+  allowed_content_types: [image/png, image/jpeg]
+scheduler:
+  tasks:
+    purge_jobs: "0 3 * * *"
+`)
+	local := writeConfig(t, dir, "local.yml", "server:\n  read_timeout: 7\n")
+	writeConfig(t, dir, "config.staging.yml", `
+server:
+  port: 9000
+storage:
+  allowed_content_types: [text/plain]
+`)
+
+	t.Setenv("APP_SERVER_TIMEOUT", "90")
+	t.Setenv("APP_MAIL_SMTP_HOST", "smtp.example.com")
+	t.Setenv("APP_SCHEDULER_TASKS_PURGE_JOBS", "0 4 * * *")
+
+	cfg, err := NewConfig(LoadOptions{
+		Paths:     []string{base, local},
+		Overrides: map[string]string{"server.write_timeout": "15", "scheduler.tasks.purge_task_locks": "@daily"},
+	})
+	assert.Equal(t, err, nil)
+
+	assert.Equal(t, cfg.Application.Env, "staging")
+	assert.Equal(t, cfg.Server.Port, 9000)
+	assert.Equal(t, cfg.Server.Timeout, 90)
+	assert.Equal(t, cfg.Server.ReadTimeout, 7)
+	assert.Equal(t, cfg.Server.WriteTimeout, 15)
+	assert.Equal(t, cfg.Storage.AllowedContentTypes, []string{"text/plain"})
+	assert.Equal(t, cfg.Mail.SMTP.Host, "smtp.example.com")
+	assert.Equal(t, cfg.Scheduler.Tasks, map[string]string{"purge_jobs": "0 4 * * *", "purge_task_locks": "@daily"})
+}
+
+func TestNewConfigReplacesMaps(t *testing.T) {
+	dir := t.TempDir()
+	base := writeConfig(t, dir, "config.yml", `
+application:
+  env: production
+rate_limit:
+  policies:
+    login: {algorithm: sliding_window, limit: 5, window: 60, key: ip}
+cors:
+  environments:
+    dev: [http://localhost:3000]
+scheduler:
+  tasks:
+    purge_jobs: "0 3 * * *"
+`)
+	writeConfig(t, dir, "config.production.yml", `
+cors:
+  environments:
+    production: [https://app.example.com]
+scheduler:
+  tasks: {}
+`)
+
+	cfg, err := NewConfig(LoadOptions{Paths: []string{base}})
+	assert.Equal(t, err, nil)
+
+	assert.Equal(t, cfg.RateLimit.Enabled, Default().RateLimit.Enabled)
+	assert.Equal(t, cfg.RateLimit.Policies, map[string]RateLimitPolicy{
+		"login": {Algorithm: "sliding_window", Limit: 5, Window: 60, Key: "ip"},
+	})
+	assert.Equal(t, cfg.CORS.Environments, map[string][]string{"production": {"https://app.example.com"}})
+	assert.Equal(t, cfg.CORS.MaxAge, Default().CORS.MaxAge)
+	assert.Equal(t, len(cfg.Scheduler.Tasks), 0)
+}
+
+func TestNewConfigEnvOption(t *testing.T) {
+	dir := t.TempDir()
+	base := writeConfig(t, dir, "config.yml", "application:\n  env: dev\n")
+	writeConfig(t, dir, "config.production.yml", "server:\n  port: 443\n")
+
+	cfg, err := NewConfig(LoadOptions{Paths: []string{base}, Env: "production"})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, cfg.Application.Env, "production")
+	assert.Equal(t, cfg.Server.Port, 443)
+}
+
+func TestNewConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+	base := writeConfig(t, dir, "config.yml", "supabase:\n  key: ${CONFIG_TEST_UNSET:?required}\n")
+
+	_, err := NewConfig(LoadOptions{Paths: []string{base}})
+	assert.NotEqual(t, err, nil)
+
+	_, err = NewConfig(LoadOptions{Paths: []string{filepath.Join(dir, "missing.yml")}})
+	assert.NotEqual(t, err, nil)
+
+	valid := writeConfig(t, dir, "valid.yml", "server:\n  port: 80\n")
+
+	_, err = NewConfig(LoadOptions{Paths: []string{valid}, Overrides: map[string]string{"server.unknown": "1"}})
+	assert.NotEqual(t, err, nil)
+
+	_, err = NewConfig(LoadOptions{Paths: []string{valid}, Overrides: map[string]string{"server.port.nested": "1"}})
+	assert.NotEqual(t, err, nil)
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"gopkg.in/yaml.v3"
+	"log/slog"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// RedactedValue replaces secrets when the configuration is printed or logged.
+const RedactedValue = "[REDACTED]"
+
+// Redacted returns a copy of the configuration with every field tagged
+// secret:"true", and every value resolved from a secret reference, masked.
+// Empty values are left as is so missing secrets remain visible.
+func (c *Config) Redacted() *Config {
+	tree, err := toTree(c)
+	if err != nil {
+		return &Config{}
+	}
+
+	for _, key := range keyPaths(reflect.TypeOf(Config{}), nil, tree) {
+		if !key.secret && !slices.Contains(c.resolvedSecrets, strings.Join(key.path, ".")) {
+			continue
+		}
+
+		if value, ok := getPath(tree, key.path).(string); ok && value != "" {
+			setPath(tree, key.path, RedactedValue)
+		}
+	}
+
+	redacted, err := fromTree(tree)
+	if err != nil {
+		return &Config{}
+	}
+
+	redacted.resolvedSecrets = c.resolvedSecrets
+
+	return redacted
+}
+
+// String renders the redacted configuration as YAML, so printing a Config
+// with fmt never leaks a secret.
+func (c *Config) String() string {
+	data, err := yaml.Marshal(c.Redacted())
+	if err != nil {
+		return err.Error()
+	}
+
+	return string(data)
+}
+
+// LogValue makes slog log the redacted configuration.
+func (c *Config) LogValue() slog.Value {
+	tree, err := toTree(c.Redacted())
+	if err != nil {
+		return slog.StringValue(err.Error())
+	}
+
+	return slog.AnyValue(tree)
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/secret"
+	"github.com/go-playground/assert/v2"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewConfigResolvesSecrets(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := writeConfig(t, dir, "jwt_secret", "from-file\n")
+
+	storeKey, _ := secret.GenerateKey()
+	store, _ := secret.OpenStore(filepath.Join(dir, "secrets.enc"), storeKey)
+	store.Set("smtp_password", "from-store")
+	_ = store.Save()
+
+	t.Setenv("SECRETS_TEST_STORE_KEY", storeKey)
+	t.Setenv("SECRETS_TEST_DB_PASSWORD_FILE", writeConfig(t, dir, "db_password", "from-env-file\n"))
+
+	path := writeConfig(t, dir, "config.yml", fmt.Sprintf(`
+database:
+  dsn: user:${SECRETS_TEST_DB_PASSWORD}@tcp(db)/app
+supabase:
+  jwt_secret: secret://file%s
+  url: secret://custom/url
+mail:
+  smtp:
+    password: secret://store/smtp_password
+secrets:
+  store: %s
+  key: ${SECRETS_TEST_STORE_KEY}
+`, keyFile, filepath.Join(dir, "secrets.enc")))
+
+	cfg, err := NewConfig(LoadOptions{
+		Paths: []string{path},
+		SecretProviders: map[string]secret.Provider{
+			"custom": secret.ProviderFunc(func(ref string) (string, error) { return "https://" + ref, nil }),
+		},
+	})
+	assert.Equal(t, err, nil)
+
+	assert.Equal(t, cfg.Database.Dsn, "user:from-env-file@tcp(db)/app")
+	assert.Equal(t, cfg.Supabase.JwtSecret, "from-file")
+	assert.Equal(t, cfg.Supabase.Url, "https://url")
+	assert.Equal(t, cfg.Mail.SMTP.Password, "from-store")
+}
+
+func TestNewConfigReportsUnresolvedSecrets(t *testing.T) {
+	path := writeConfig(t, t.TempDir(), "config.yml", `
+supabase:
+  key: secret://env/SECRETS_TEST_MISSING
+  jwt_secret: secret://store/jwt
+`)
+
+	_, err := NewConfig(LoadOptions{Paths: []string{path}})
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, strings.Contains(err.Error(), "supabase.key"), true)
+	assert.Equal(t, strings.Contains(err.Error(), "supabase.jwt_secret"), true)
+}
+
+func TestRedaction(t *testing.T) {
+	t.Setenv("SECRETS_TEST_URL", "https://private.example.com")
+
+	path := writeConfig(t, t.TempDir(), "config.yml", `
+supabase:
+  url: secret://env/SECRETS_TEST_URL
+  key: anon-key
+  jwt_secret: jwt-secret
+`)
+
+	cfg, err := NewConfig(LoadOptions{Paths: []string{path}})
+	assert.Equal(t, err, nil)
+
+	redacted := cfg.Redacted()
+	assert.Equal(t, redacted.Supabase.Key, RedactedValue)
+	assert.Equal(t, redacted.Supabase.JwtSecret, RedactedValue)
+	assert.Equal(t, redacted.Supabase.Url, RedactedValue)
+	assert.Equal(t, redacted.Supabase.Hooks.Secret, "")
+	assert.Equal(t, cfg.Supabase.Key, "anon-key")
+
+	printed := fmt.Sprint(cfg)
+	assert.Equal(t, strings.Contains(printed, "anon-key"), false)
+	assert.Equal(t, strings.Contains(printed, "jwt-secret"), false)
+	assert.Equal(t, strings.Contains(printed, "private.example.com"), false)
+
+	buf := &bytes.Buffer{}
+	slog.New(slog.NewJSONHandler(buf, nil)).Info("config", "config", cfg)
+	assert.Equal(t, strings.Contains(buf.String(), "jwt-secret"), false)
+	assert.Equal(t, strings.Contains(buf.String(), RedactedValue), true)
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"fmt"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/validation"
+	"strings"
+)
+
+// ValidationError lists every invalid value of the configuration, keyed by
+// its YAML path.
+type ValidationError struct {
+	Errors []validation.ValidationError
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%d invalid configuration value(s):", len(e.Errors))
+	for _, err := range e.Errors {
+		fmt.Fprintf(&b, "\n  %s: %s", err.Field, err.Message)
+	}
+
+	return b.String()
+}
+
+// Validate checks the loaded values, so mistakes are reported at startup
+// rather than by the first request that depends on them.
+func (c *Config) Validate() error {
+	errs := validation.NewValidator(validation.WithTagName("yaml")).Validate(c).ValidationErrors
+
+	if c.Registration.Mode != "open" && c.Supabase.Hooks.Secret == "" {
+		errs = append(errs, validation.ValidationError{
+			Field:   "supabase.hooks.secret",
+			Message: "supabase.hooks.secret is required unless registration is open",
+		})
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return &ValidationError{Errors: errs}
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"errors"
+	"github.com/go-playground/assert/v2"
+	"strings"
+	"testing"
+)
+
+func validConfig() *Config {
+	cfg := Default()
+	cfg.Supabase.Url = "https://project.supabase.co"
+	cfg.Supabase.Key = "key"
+	cfg.Supabase.JwtSecret = "secret"
+	cfg.Storage.Bucket = "attachments"
+
+	return cfg
+}
+
+func TestValidateAcceptsDefaults(t *testing.T) {
+	assert.Equal(t, validConfig().Validate(), nil)
+}
+
+func TestValidateReportsEveryError(t *testing.T) {
+	cfg := validConfig()
+	cfg.Application.Env = "prod"
+	cfg.Server.Port = 70000
+	cfg.Server.ReadTimeout = 0
+	cfg.Server.TrustedProxies = []string{"10.0.0.0/8", "192.0.2.1", "proxy.internal"}
+	cfg.Supabase.Url = "not a url"
+	cfg.Database.Enabled = true
+	cfg.Mail.Driver = "smtp"
+	cfg.Scheduler.Tasks = map[string]string{"purge_jobs": "every day"}
+
+	err := cfg.Validate()
+
+	var validationErr *ValidationError
+	assert.Equal(t, errors.As(err, &validationErr), true)
+
+	fields := []string{}
+	for _, e := range validationErr.Errors {
+		fields = append(fields, e.Field)
+	}
+
+	assert.Equal(t, fields, []string{
+		"application.env",
+		"server.port",
+		"server.read_timeout",
+		"server.trusted_proxies[2]",
+		"database.dsn",
+		"supabase.url",
+		"mail.from",
+		"scheduler.tasks[purge_jobs]",
+	})
+	assert.Equal(t, strings.Contains(err.Error(), "server.port: port must be 65,535 or less"), true)
+}
+
+func TestValidateClientTLS(t *testing.T) {
+	cfg := validConfig()
+	cfg.Server.TLS.Client = ClientTLS{
+		Enabled: true,
+		CAFile:  "ca.pem",
+		Groups:  map[string]string{"files": ClientAuthRequire, "hooks": ClientAuthVerifyIfGiven},
+	}
+	assert.Equal(t, cfg.Validate(), nil)
+
+	cfg.Server.TLS.Client.CAFile = ""
+	cfg.Server.TLS.Client.Groups = map[string]string{"admin": ClientAuthRequire, "files": "sometimes"}
+
+	var validationErr *ValidationError
+	assert.Equal(t, errors.As(cfg.Validate(), &validationErr), true)
+	assert.Equal(t, len(validationErr.Errors), 3)
+}
+
+func TestValidateRegistrationRequiresHooks(t *testing.T) {
+	cfg := validConfig()
+	cfg.Registration.Mode = "invite"
+
+	var validationErr *ValidationError
+	assert.Equal(t, errors.As(cfg.Validate(), &validationErr), true)
+	assert.Equal(t, validationErr.Errors[0].Field, "supabase.hooks.secret")
+
+	cfg.Supabase.Hooks.Secret = "v1,whsec_c2VjcmV0"
+	assert.Equal(t, cfg.Validate(), nil)
+}
@@ -0,0 +1,159 @@
+package config
+
+import (
+	"context"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/log"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Watcher reloads the configuration when one of its files changes or the
+// process receives SIGHUP. Reloaded values go through the same layers and
+// validation as at startup.
+type Watcher struct {
+	live     *Live
+	options  LoadOptions
+	log      log.LoggerInterface
+	interval time.Duration
+
+	stamps map[string]fileStamp
+	stop   chan struct{}
+	done   chan struct{}
+	once   sync.Once
+}
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// NewWatcher creates a watcher polling the files every interval. A zero
+// interval disables polling, leaving SIGHUP as the only trigger.
+func NewWatcher(live *Live, options LoadOptions, log log.LoggerInterface, interval time.Duration) *Watcher {
+	return &Watcher{
+		live:     live,
+		options:  options,
+		log:      log,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+func (w *Watcher) Start() {
+	w.stamps = w.stat()
+
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+
+	go func() {
+		defer close(w.done)
+		defer signal.Stop(hangup)
+
+		var tick <-chan time.Time
+		if w.interval > 0 {
+			ticker := time.NewTicker(w.interval)
+			defer ticker.Stop()
+
+			tick = ticker.C
+		}
+
+		for {
+			select {
+			case <-w.stop:
+				return
+			case <-hangup:
+				w.log.Info("Reloading configuration", "trigger", "SIGHUP")
+				w.Reload()
+			case <-tick:
+				stamps := w.stat()
+				if !sameStamps(stamps, w.stamps) {
+					w.stamps = stamps
+					w.log.Info("Reloading configuration", "trigger", "file change")
+					w.Reload()
+				}
+			}
+		}
+	}()
+}
+
+func (w *Watcher) Shutdown(ctx context.Context) error {
+	w.once.Do(func() { close(w.stop) })
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Reload loads and validates the configuration, then applies the reloadable
+// sections. Invalid configuration is logged and leaves the running one as is.
+func (w *Watcher) Reload() {
+	loaded, err := NewConfig(w.options)
+	if err == nil {
+		err = loaded.Validate()
+	}
+
+	if err != nil {
+		w.log.Error("Configuration reload failed", "error", err)
+		return
+	}
+
+	rejected, applied := w.live.Apply(loaded)
+	if len(rejected) > 0 {
+		w.log.Warn("Configuration changes need a restart and were ignored", "keys", strings.Join(rejected, ", "))
+	}
+
+	if applied {
+		w.log.Info("Configuration reloaded")
+	}
+}
+
+// files lists the base files and their overlay for the running environment.
+func (w *Watcher) files() []string {
+	paths := w.options.Paths
+	if len(paths) == 0 {
+		paths = []string{DefaultPath}
+	}
+
+	files := append([]string{}, paths...)
+	if env := w.live.Current().Application.Env; env != "" {
+		for _, path := range paths {
+			files = append(files, overlayPath(path, env))
+		}
+	}
+
+	return files
+}
+
+func (w *Watcher) stat() map[string]fileStamp {
+	stamps := map[string]fileStamp{}
+
+	for _, file := range w.files() {
+		if info, err := os.Stat(file); err == nil {
+			stamps[file] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+		}
+	}
+
+	return stamps
+}
+
+func sameStamps(a map[string]fileStamp, b map[string]fileStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for file, stamp := range a {
+		if other, ok := b[file]; !ok || !other.modTime.Equal(stamp.modTime) || other.size != stamp.size {
+			return false
+		}
+	}
+
+	return true
+}
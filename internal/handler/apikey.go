@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/apikey"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/auth"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/config"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/http/request"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/http/response"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/model"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/validation"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+var errAPIKeyNotFound = errors.New("API key not found")
+
+type createdAPIKey struct {
+	*model.APIKey
+	Key string `json:"key"`
+}
+
+// APIKeyHandler lets users manage their own API keys.
+type APIKeyHandler struct {
+	cfg       config.APIKeys
+	store     apikey.Store
+	validator validation.ValidatorInterface
+}
+
+func NewAPIKeyHandler(cfg *config.Config, store apikey.Store, validator validation.ValidatorInterface) *APIKeyHandler {
+	return &APIKeyHandler{
+		cfg:       cfg.APIKeys,
+		store:     store,
+		validator: validator,
+	}
+}
+
+func (h *APIKeyHandler) ListHandler(c echo.Context) error {
+	keys, err := h.store.List(c.Request().Context(), auth.FromContext(c).ID)
+	if err != nil {
+		return response.ServerErrorResponse(err)
+	}
+
+	if keys == nil {
+		keys = []model.APIKey{}
+	}
+
+	return response.SuccessResponse(c, keys)
+}
+
+// CreateHandler returns the new key in full. It cannot be retrieved again.
+func (h *APIKeyHandler) CreateHandler(c echo.Context) error {
+	var r request.CreateAPIKeyRequest
+
+	if err := decode(c.Request().Body, &r); err != nil {
+		return response.BadRequestResponse(err)
+	}
+
+	if err := h.validate(r, r.Scopes); err != nil {
+		return err
+	}
+
+	token, prefix, hash, err := apikey.Generate(h.cfg.Prefix)
+	if err != nil {
+		return response.ServerErrorResponse(err)
+	}
+
+	key := &model.APIKey{
+		UserID:     auth.FromContext(c).ID,
+		Name:       r.Name,
+		Prefix:     prefix,
+		SecretHash: hash,
+		Scopes:     r.Scopes,
+		ExpiresAt:  r.ExpiresAt,
+	}
+
+	if err = h.store.Create(c.Request().Context(), key); err != nil {
+		return response.ServerErrorResponse(err)
+	}
+
+	return response.CreatedResponse(c, createdAPIKey{APIKey: key, Key: token})
+}
+
+func (h *APIKeyHandler) ShowHandler(c echo.Context) error {
+	key, err := h.find(c)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(c, key)
+}
+
+func (h *APIKeyHandler) UpdateHandler(c echo.Context) error {
+	key, err := h.find(c)
+	if err != nil {
+		return err
+	}
+
+	var r request.UpdateAPIKeyRequest
+
+	if err = decode(c.Request().Body, &r); err != nil {
+		return response.BadRequestResponse(err)
+	}
+
+	if err = h.validate(r, r.Scopes); err != nil {
+		return err
+	}
+
+	key.Name = r.Name
+	key.Scopes = r.Scopes
+
+	if err = h.store.Update(c.Request().Context(), key); err != nil {
+		return response.ServerErrorResponse(err)
+	}
+
+	return response.SuccessResponse(c, key)
+}
+
+// RevokeHandler keeps the key for the record but stops it from
+// authenticating. Revoking a revoked key is a no-op.
+func (h *APIKeyHandler) RevokeHandler(c echo.Context) error {
+	key, err := h.find(c)
+	if err != nil {
+		return err
+	}
+
+	if key.RevokedAt == nil {
+		now := time.Now()
+		key.RevokedAt = &now
+
+		if err = h.store.Update(c.Request().Context(), key); err != nil {
+			return response.ServerErrorResponse(err)
+		}
+	}
+
+	return response.NoContentResponse(c)
+}
+
+func (h *APIKeyHandler) find(c echo.Context) (*model.APIKey, error) {
+	notFound := response.ErrorResponse(http.StatusNotFound, response.Error{Message: errAPIKeyNotFound.Error()})
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return nil, notFound
+	}
+
+	key, err := h.store.Find(c.Request().Context(), auth.FromContext(c).ID, uint(id))
+	if err != nil {
+		return nil, response.ServerErrorResponse(err)
+	}
+
+	if key == nil {
+		return nil, notFound
+	}
+
+	return key, nil
+}
+
+// validate checks the request, then that every scope may be granted.
+func (h *APIKeyHandler) validate(r any, scopes []string) error {
+	validationErrors := h.validator.Validate(r)
+
+	for _, scope := range scopes {
+		if scope != "" && !slices.Contains(h.cfg.Scopes, scope) {
+			validationErrors.Message = "Validation error"
+			validationErrors.ValidationErrors = append(validationErrors.ValidationErrors, validation.ValidationError{
+				Message: fmt.Sprintf("%s is not a known scope", scope),
+				Field:   "scopes",
+			})
+		}
+	}
+
+	if len(validationErrors.ValidationErrors) > 0 {
+		return response.ValidationErrorResponse(validationErrors)
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/apikey"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/auth"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/config"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/validation"
+	"github.com/go-playground/assert/v2"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func apiKeyContext(method string, body string, userID string) (echo.Context, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(method, "/", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	c := echo.New().NewContext(req, rec)
+	auth.SetPrincipal(c, &auth.Principal{Type: auth.PrincipalUser, ID: userID})
+
+	return c, rec
+}
+
+func TestAPIKeyHandlerLifecycle(t *testing.T) {
+	store := apikey.NewMemoryStore()
+	h := NewAPIKeyHandler(config.Default(), store, validation.NewValidator())
+
+	c, rec := apiKeyContext(http.MethodPost, `{"name": "CI", "scopes": ["files:read"]}`, "123")
+	assert.Equal(t, h.CreateHandler(c), nil)
+	assert.Equal(t, rec.Code, http.StatusCreated)
+
+	var created struct {
+		ID     uint     `json:"id"`
+		Key    string   `json:"key"`
+		Prefix string   `json:"prefix"`
+		Scopes []string `json:"scopes"`
+	}
+	assert.Equal(t, json.Unmarshal(rec.Body.Bytes(), &created), nil)
+	assert.Equal(t, strings.HasPrefix(created.Key, created.Prefix+"_"), true)
+	assert.Equal(t, created.Scopes, []string{"files:read"})
+	assert.Equal(t, strings.Contains(rec.Body.String(), "secretHash"), false)
+
+	c, rec = apiKeyContext(http.MethodGet, "", "123")
+	assert.Equal(t, h.ListHandler(c), nil)
+	assert.Equal(t, strings.Contains(rec.Body.String(), created.Prefix), true)
+	assert.Equal(t, strings.Contains(rec.Body.String(), created.Key), false)
+
+	c, _ = apiKeyContext(http.MethodGet, "", "456")
+	c.SetParamNames("id")
+	c.SetParamValues("1")
+	err := h.ShowHandler(c)
+	assert.Equal(t, err.(*echo.HTTPError).Code, http.StatusNotFound)
+
+	c, rec = apiKeyContext(http.MethodDelete, "", "123")
+	c.SetParamNames("id")
+	c.SetParamValues("1")
+	assert.Equal(t, h.RevokeHandler(c), nil)
+	assert.Equal(t, rec.Code, http.StatusNoContent)
+
+	key, _ := store.Find(c.Request().Context(), "123", created.ID)
+	assert.NotEqual(t, key.RevokedAt, nil)
+}
+
+func TestAPIKeyHandlerRejectsUnknownScopes(t *testing.T) {
+	h := NewAPIKeyHandler(config.Default(), apikey.NewMemoryStore(), validation.NewValidator())
+
+	c, _ := apiKeyContext(http.MethodPost, `{"name": "CI", "scopes": ["admin"]}`, "123")
+	err := h.CreateHandler(c)
+
+	httpErr := err.(*echo.HTTPError)
+	assert.Equal(t, httpErr.Code, http.StatusUnprocessableEntity)
+	assert.Equal(t, httpErr.Message.(validation.ValidationErrors).ValidationErrors, []validation.ValidationError{
+		{Message: "admin is not a known scope", Field: "scopes"},
+	})
+}
@@ -1,17 +1,24 @@
 package handler
 
 import (
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/audit"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/auth"
 	"github.com/Fortress-Digital/go-rest-skeleton/internal/http/request"
 	"github.com/Fortress-Digital/go-rest-skeleton/internal/http/response"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/invite"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/lockout"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/mail"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/middleware"
 	"github.com/Fortress-Digital/go-rest-skeleton/internal/supabase"
 	"github.com/labstack/echo/v4"
 	"net/http"
+	"time"
 )
 
 func (h *Handler) RegisterHandler(c echo.Context) error {
 	var r request.RegisterRequest
 
-	err := h.decode(c.Request().Body, &r)
+	err := decode(c.Request().Body, &r)
 	if err != nil {
 		return response.ServerErrorResponse(err)
 	}
@@ -22,27 +29,61 @@ func (h *Handler) RegisterHandler(c echo.Context) error {
 		return response.ValidationErrorResponse(validationErrors)
 	}
 
+	invitation, err := h.admitRegistration(c, r)
+	if err != nil {
+		return err
+	}
+
 	uc := supabase.UserCredentials{
 		Email:    r.Email,
 		Password: r.Password,
+		Security: metaSecurity(c),
+	}
+
+	if invitation != nil {
+		admission := invite.Admit(h.cfg.Supabase.JwtSecret, r.Email, time.Now().Add(invite.AdmissionLifetime))
+		uc.Data = map[string]string{invite.AdmissionKey: admission}
 	}
 
 	user, serviceErr, err := h.auth.SignUp(uc)
 	if err != nil {
+		h.releaseInvitation(c, invitation)
 		return response.ServerErrorResponse(err)
 	}
 
 	if serviceErr != nil {
+		h.releaseInvitation(c, invitation)
 		return response.BadRequestResponse(serviceErr)
 	}
 
+	// Supabase Auth answers a signup for a registered address as if it
+	// succeeded, which must neither use up the invitation nor email the
+	// registered user.
+	if user.Existing() {
+		h.releaseInvitation(c, invitation)
+
+		return response.CreatedResponse(c, user)
+	}
+
+	if invitation != nil {
+		h.audit.Record(c.Request().Context(), audit.Event{
+			Type:    EventInvitationRedeemed,
+			ActorID: user.ID,
+			Email:   user.Email,
+			IP:      c.RealIP(),
+			Details: map[string]any{"invitation": invitation.ID},
+		})
+	}
+
+	h.sendWelcomeEmail(c, user)
+
 	return response.CreatedResponse(c, user)
 }
 
 func (h *Handler) LoginHandler(c echo.Context) error {
 	var r request.LoginRequest
 
-	err := h.decode(c.Request().Body, &r)
+	err := decode(c.Request().Body, &r)
 	if err != nil {
 		return response.ServerErrorResponse(err)
 	}
@@ -53,9 +94,15 @@ func (h *Handler) LoginHandler(c echo.Context) error {
 		return response.ValidationErrorResponse(validationErrors)
 	}
 
+	subjects := []lockout.Subject{lockout.Email(r.Email), lockout.IP(c.RealIP())}
+	if err = h.checkLockout(c, subjects); err != nil {
+		return err
+	}
+
 	uc := supabase.UserCredentials{
 		Email:    r.Email,
 		Password: r.Password,
+		Security: metaSecurity(c),
 	}
 	user, serviceErr, err := h.auth.SignIn(uc)
 	if err != nil {
@@ -63,6 +110,10 @@ func (h *Handler) LoginHandler(c echo.Context) error {
 	}
 
 	if serviceErr != nil {
+		if invalidCredentials(serviceErr) {
+			h.recordLoginFailure(c, subjects)
+		}
+
 		if serviceErr.Code == http.StatusUnauthorized {
 			return response.UnauthorizedResponse(c, serviceErr)
 		}
@@ -70,14 +121,39 @@ func (h *Handler) LoginHandler(c echo.Context) error {
 		return response.BadRequestResponse(serviceErr)
 	}
 
+	h.resetLockout(c, subjects[0])
+
+	if r.Session && h.sessions != nil {
+		return h.startSession(c, user)
+	}
+
+	// A session just started cannot have been revoked.
+	_ = h.trackSession(c, user)
+
 	return response.SuccessResponse(c, user)
 }
 
+// LogoutHandler destroys the session of a session cookie, or else signs out
+// the session of the bearer token. Other sessions of the user are kept.
 func (h *Handler) LogoutHandler(c echo.Context) error {
-	token := c.Request().Header.Get("Authorization")
-	token = token[7:]
+	if h.sessions != nil {
+		if cookie, err := c.Cookie(h.cfg.Sessions.CookieName); err == nil && cookie.Value != "" {
+			if err = middleware.VerifyCSRF(c); err != nil {
+				return err
+			}
+
+			return h.endSession(c, cookie)
+		}
+	}
+
+	token, ok := auth.BearerToken(c.Request())
+	if !ok {
+		return response.ErrorResponse(http.StatusUnauthorized, response.Error{
+			Message: auth.ErrUnauthenticated.Error(),
+		})
+	}
 
-	serviceErr, err := h.auth.SignOut(token)
+	serviceErr, err := h.auth.SignOut(token, supabase.SignOutLocal)
 
 	if err != nil {
 		return response.ServerErrorResponse(err)
@@ -87,13 +163,20 @@ func (h *Handler) LogoutHandler(c echo.Context) error {
 		return response.BadRequestResponse(serviceErr)
 	}
 
+	// Revoking the tracked session rejects the access token right away.
+	if claims, err := supabase.ParseAccessToken(token, h.cfg.Supabase.JwtSecret); err == nil && h.sessions != nil {
+		if err = h.sessions.RevokeSessionID(c.Request().Context(), claims.SessionID); err != nil {
+			h.log.Warn("Session revocation error", "user", claims.Subject, "error", err)
+		}
+	}
+
 	return response.NoContentResponse(c)
 }
 
 func (h *Handler) RefreshTokenHandler(c echo.Context) error {
 	var r request.RefreshTokenRequest
 
-	err := h.decode(c.Request().Body, &r)
+	err := decode(c.Request().Body, &r)
 	if err != nil {
 		return response.ServerErrorResponse(err)
 	}
@@ -113,13 +196,20 @@ func (h *Handler) RefreshTokenHandler(c echo.Context) error {
 		return response.BadRequestResponse(serviceErr)
 	}
 
+	if err = h.trackSession(c, user); err != nil {
+		// Sign out the tokens just issued to the revoked session.
+		_, _ = h.auth.SignOut(user.AccessToken, supabase.SignOutLocal)
+
+		return response.ErrorResponse(http.StatusUnauthorized, response.Error{Message: err.Error()})
+	}
+
 	return response.SuccessResponse(c, user)
 }
 
 func (h *Handler) ForgottenPasswordHandler(c echo.Context) error {
 	var r request.ForgottenPasswordRequest
 
-	err := h.decode(c.Request().Body, &r)
+	err := decode(c.Request().Body, &r)
 
 	if err != nil {
 		return response.ServerErrorResponse(err)
@@ -131,7 +221,11 @@ func (h *Handler) ForgottenPasswordHandler(c echo.Context) error {
 		return response.ValidationErrorResponse(validationErrors)
 	}
 
-	serviceErr, err := h.auth.ForgottenPassword(r.Email)
+	if err = h.throttleRecoveryEmail(c, r.Email); err != nil {
+		return err
+	}
+
+	serviceErr, err := h.auth.ForgottenPassword(r.Email, metaSecurity(c))
 	if err != nil {
 		return response.ServerErrorResponse(err)
 	}
@@ -146,20 +240,30 @@ func (h *Handler) ForgottenPasswordHandler(c echo.Context) error {
 func (h *Handler) ResetPasswordHandler(c echo.Context) error {
 	var r request.ResetPasswordRequest
 
-	err := h.decode(c.Request().Body, &r)
+	err := decode(c.Request().Body, &r)
 	if err != nil {
 		return response.ServerErrorResponse(err)
 	}
 
+	token, ok := auth.BearerToken(c.Request())
+	if !ok {
+		return response.ErrorResponse(http.StatusUnauthorized, response.Error{
+			Message: auth.ErrUnauthenticated.Error(),
+		})
+	}
+
+	// Supabase verifies the token, the claims are only read here.
+	claims, claimsErr := supabase.ParseAccessToken(token, h.cfg.Supabase.JwtSecret)
+	if claimsErr == nil {
+		r.Email = claims.Email
+	}
+
 	validationErrors := h.validator.Validate(r)
 
 	if len(validationErrors.ValidationErrors) > 0 {
 		return response.ValidationErrorResponse(validationErrors)
 	}
 
-	token := c.Request().Header.Get("Authorization")
-	token = token[7:]
-
 	serviceErr, err := h.auth.ResetPassword(token, r.Password)
 	if err != nil {
 		return response.ServerErrorResponse(err)
@@ -169,5 +273,40 @@ func (h *Handler) ResetPasswordHandler(c echo.Context) error {
 		return response.BadRequestResponse(serviceErr)
 	}
 
+	// Whoever could reset the password owns the account, so its lockout is
+	// lifted.
+	if claimsErr == nil {
+		h.resetLockout(c, lockout.Email(claims.Email))
+		h.audit.Record(c.Request().Context(), audit.Event{
+			Type:    EventLockoutReset,
+			ActorID: claims.Subject,
+			Email:   claims.Email,
+			IP:      c.RealIP(),
+		})
+	}
+
 	return response.NoContentResponse(c)
 }
+
+// metaSecurity returns the captcha token to forward to Supabase Auth, or nil
+// when captcha tokens are not forwarded.
+func metaSecurity(c echo.Context) *supabase.MetaSecurity {
+	token := middleware.CaptchaToken(c)
+	if token == "" {
+		return nil
+	}
+
+	return &supabase.MetaSecurity{CaptchaToken: token}
+}
+
+// sendWelcomeEmail queues the welcome email. A failure is logged rather than
+// returned, as the account has already been created.
+func (h *Handler) sendWelcomeEmail(c echo.Context, user *supabase.User) {
+	locale := mail.ParseLocale(c.Request().Header.Get("Accept-Language"))
+	data := map[string]string{"Email": user.Email}
+
+	err := h.mailer.SendTemplate(c.Request().Context(), "welcome", locale, []string{user.Email}, data)
+	if err != nil {
+		h.log.Error("Welcome email error", "email", user.Email, "error", err)
+	}
+}
@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"bytes"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/http/request"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/http/response"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/middleware"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/supabase"
+	"github.com/labstack/echo/v4"
+	"html/template"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+)
+
+// confirmationTypes are the email links VerifyHandler completes. Recovery
+// and magic links sign users in, so they are left to the frontend.
+var confirmationTypes = []string{supabase.VerifySignup, supabase.VerifyEmail, supabase.VerifyEmailChange, supabase.VerifyInvite}
+
+// confirmationPage posts the confirmation link back to VerifyHandler. It has
+// no scripts or styles, which the default Content-Security-Policy blocks.
+var confirmationPage = template.Must(template.New("confirmation").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Confirm your email - {{.Application}}</title>
+</head>
+<body>
+<h1>Confirm your email</h1>
+<form method="post" action="{{.Action}}">
+<input type="hidden" name="token_hash" value="{{.TokenHash}}">
+<input type="hidden" name="type" value="{{.Type}}">
+<input type="hidden" name="{{.CSRFField}}" value="{{.CSRFToken}}">
+<button type="submit">Continue to {{.Application}}</button>
+</form>
+</body>
+</html>
+`))
+
+// ResendConfirmationHandler sends the signup confirmation email again,
+// throttled per address like the recovery emails.
+func (h *Handler) ResendConfirmationHandler(c echo.Context) error {
+	var r request.ResendConfirmationRequest
+
+	err := decode(c.Request().Body, &r)
+	if err != nil {
+		return response.ServerErrorResponse(err)
+	}
+
+	validationErrors := h.validator.Validate(r)
+
+	if len(validationErrors.ValidationErrors) > 0 {
+		return response.ValidationErrorResponse(validationErrors)
+	}
+
+	cfg := h.cfg.Confirmation
+	if err = h.throttleEmail(c, "confirmation_emails", r.Email, cfg.ResendEmails, EventConfirmThrottled, "too many confirmation emails, please retry later"); err != nil {
+		return err
+	}
+
+	serviceErr, err := h.auth.ResendConfirmation(r.Email, metaSecurity(c))
+	if err != nil {
+		return response.ServerErrorResponse(err)
+	}
+
+	if serviceErr != nil {
+		return response.BadRequestResponse(serviceErr)
+	}
+
+	return response.NoContentResponse(c)
+}
+
+// ConfirmationPageHandler shows the page of the confirmation link, with the
+// token_hash and type of its query. Opening the link does not use it up, as
+// mail scanners follow links too: the page posts them to VerifyHandler.
+func (h *Handler) ConfirmationPageHandler(c echo.Context) error {
+	tokenHash := c.QueryParam("token_hash")
+	verificationType := c.QueryParam("type")
+
+	if !validConfirmation(tokenHash, verificationType) {
+		return h.invalidConfirmation(c)
+	}
+
+	var page bytes.Buffer
+	err := confirmationPage.Execute(&page, map[string]string{
+		"Application": h.cfg.Application.Name,
+		"Action":      c.Request().URL.Path,
+		"TokenHash":   tokenHash,
+		"Type":        verificationType,
+		"CSRFField":   middleware.CSRFFormField,
+		"CSRFToken":   middleware.CSRFToken(c),
+	})
+	if err != nil {
+		return response.ServerErrorResponse(err)
+	}
+
+	c.Response().Header().Set(echo.HeaderCacheControl, "no-store")
+
+	return c.HTMLBlob(http.StatusOK, page.Bytes())
+}
+
+// VerifyHandler completes the confirmation link of the token_hash and type
+// posted by the confirmation page, then redirects to the frontend. Like the
+// redirects of Supabase Auth, the URL fragment carries the tokens, or error
+// and error_description when the link is invalid or expired. With sessions
+// enabled the tokens are kept server side behind the session cookie instead.
+func (h *Handler) VerifyHandler(c echo.Context) error {
+	tokenHash := c.FormValue("token_hash")
+	verificationType := c.FormValue("type")
+
+	if !validConfirmation(tokenHash, verificationType) {
+		return h.invalidConfirmation(c)
+	}
+
+	details, serviceErr, err := h.auth.Verify(tokenHash, verificationType)
+	if err != nil {
+		h.log.Error("Verification error", "type", verificationType, "error", err)
+
+		return h.redirectVerification(c, url.Values{
+			"error":             {"server_error"},
+			"error_description": {"The confirmation could not be completed"},
+		})
+	}
+
+	if serviceErr != nil {
+		return h.redirectVerification(c, url.Values{
+			"error":             {"access_denied"},
+			"error_code":        {serviceErr.ErrorCode},
+			"error_description": {serviceErr.Message},
+		})
+	}
+
+	if h.sessions != nil {
+		token, _, err := h.sessions.Create(c.Request().Context(), details, device(c))
+		if err != nil {
+			h.log.Error("Session error", "user", details.User.ID, "error", err)
+		} else {
+			c.SetCookie(sessionCookie(h.cfg.Sessions, token, int(h.sessions.Lifetime().Seconds())))
+		}
+
+		return h.redirectVerification(c, url.Values{"type": {verificationType}})
+	}
+
+	return h.redirectVerification(c, url.Values{
+		"access_token":  {details.AccessToken},
+		"refresh_token": {details.RefreshToken},
+		"expires_in":    {strconv.Itoa(details.ExpiresIn)},
+		"token_type":    {details.TokenType},
+		"type":          {verificationType},
+	})
+}
+
+func validConfirmation(tokenHash string, verificationType string) bool {
+	return tokenHash != "" && slices.Contains(confirmationTypes, verificationType)
+}
+
+func (h *Handler) invalidConfirmation(c echo.Context) error {
+	return h.redirectVerification(c, url.Values{
+		"error":             {"invalid_request"},
+		"error_description": {"The confirmation link is invalid"},
+	})
+}
+
+func (h *Handler) redirectVerification(c echo.Context, fragment url.Values) error {
+	redirect, err := url.Parse(h.cfg.Confirmation.RedirectURL)
+	if err != nil {
+		return response.ServerErrorResponse(err)
+	}
+
+	redirect.Fragment = ""
+
+	return c.Redirect(http.StatusSeeOther, redirect.String()+"#"+fragment.Encode())
+}
@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"context"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/config"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/session"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/supabase"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/validation"
+	"github.com/go-playground/assert/v2"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// confirmationAuth accepts the token hash "valid" only, signing in with
+// details when set.
+type confirmationAuth struct {
+	fakeAuth
+	details  *supabase.AuthenticatedDetails
+	resent   []string
+	verified int
+}
+
+func (a *confirmationAuth) ResendConfirmation(email string, _ *supabase.MetaSecurity) (*supabase.ErrorResponse, error) {
+	a.resent = append(a.resent, email)
+
+	return nil, nil
+}
+
+func (a *confirmationAuth) Verify(tokenHash string, _ string) (*supabase.AuthenticatedDetails, *supabase.ErrorResponse, error) {
+	a.verified++
+
+	if tokenHash != "valid" {
+		return nil, &supabase.ErrorResponse{Code: http.StatusForbidden, ErrorCode: "otp_expired", Message: "Email link is invalid or has expired"}, nil
+	}
+
+	if a.details != nil {
+		return a.details, nil, nil
+	}
+
+	return &supabase.AuthenticatedDetails{
+		AccessToken:  "access",
+		RefreshToken: "refresh",
+		TokenType:    "bearer",
+		ExpiresIn:    3600,
+		User:         supabase.User{ID: "123", Email: "jane@example.com"},
+	}, nil, nil
+}
+
+func verify(h *Handler, form string) (*url.URL, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(http.MethodPost, "/verify", strings.NewReader(form))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+
+	_ = h.VerifyHandler(c)
+
+	location, _ := url.Parse(rec.Header().Get(echo.HeaderLocation))
+
+	return location, rec
+}
+
+func TestResendConfirmationThrottle(t *testing.T) {
+	cfg := config.Default()
+	cfg.Lockout.Enabled = false
+	cfg.Confirmation.ResendEmails = config.Throttle{Limit: 2, Window: 3600}
+
+	client := &confirmationAuth{}
+	h, _, recorder := newLockoutHandler(cfg, &client.fakeAuth)
+	h.auth = client
+
+	for range 2 {
+		rec, err := post(h.ResendConfirmationHandler, `{"email": "jane@example.com"}`, nil)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, rec.Code, http.StatusNoContent)
+	}
+
+	_, err := post(h.ResendConfirmationHandler, `{"email": "Jane@Example.com"}`, nil)
+	assert.Equal(t, err.(*echo.HTTPError).Code, http.StatusTooManyRequests)
+	assert.Equal(t, len(client.resent), 2)
+	assert.Equal(t, recorder.Types(), []string{EventConfirmThrottled})
+
+	_, err = post(h.ResendConfirmationHandler, `{"email": "not-an-email"}`, nil)
+	assert.Equal(t, err.(*echo.HTTPError).Code, http.StatusUnprocessableEntity)
+}
+
+func TestConfirmationPageHandler(t *testing.T) {
+	cfg := config.Default()
+	cfg.Confirmation.RedirectURL = "https://app.example.com/confirmed"
+
+	client := &confirmationAuth{}
+	h := NewHandler(cfg, client, validation.NewValidator(), nil, discardLogger, nil, nil, nil, nil)
+
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/verify?token_hash=valid%22&type=signup", nil), rec)
+	c.Set("csrf", "csrf-token")
+
+	err := h.ConfirmationPageHandler(c)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, rec.Header().Get(echo.HeaderCacheControl), "no-store")
+	assert.Equal(t, strings.Contains(rec.Body.String(), `<form method="post" action="/verify">`), true)
+	assert.Equal(t, strings.Contains(rec.Body.String(), `name="token_hash" value="valid&#34;"`), true)
+	assert.Equal(t, strings.Contains(rec.Body.String(), `name="_csrf" value="csrf-token"`), true)
+	assert.Equal(t, client.verified, 0)
+
+	rec = httptest.NewRecorder()
+	c = echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/verify?token_hash=valid&type=recovery", nil), rec)
+
+	_ = h.ConfirmationPageHandler(c)
+
+	location, _ := url.Parse(rec.Header().Get(echo.HeaderLocation))
+	assert.Equal(t, rec.Code, http.StatusSeeOther)
+	assert.Equal(t, location.Fragment, "error=invalid_request&error_description=The+confirmation+link+is+invalid")
+	assert.Equal(t, client.verified, 0)
+}
+
+func TestVerifyHandler(t *testing.T) {
+	cfg := config.Default()
+	cfg.Confirmation.RedirectURL = "https://app.example.com/confirmed?from=email"
+
+	h := NewHandler(cfg, &confirmationAuth{}, validation.NewValidator(), nil, discardLogger, nil, nil, nil, nil)
+
+	tests := []struct {
+		name             string
+		form             string
+		expectedFragment url.Values
+	}{
+		{
+			name: "Valid link",
+			form: "token_hash=valid&type=signup",
+			expectedFragment: url.Values{
+				"access_token":  {"access"},
+				"refresh_token": {"refresh"},
+				"expires_in":    {"3600"},
+				"token_type":    {"bearer"},
+				"type":          {"signup"},
+			},
+		},
+		{
+			name: "Expired link",
+			form: "token_hash=expired&type=email",
+			expectedFragment: url.Values{
+				"error":             {"access_denied"},
+				"error_code":        {"otp_expired"},
+				"error_description": {"Email link is invalid or has expired"},
+			},
+		},
+		{
+			name: "Missing token hash",
+			form: "type=signup",
+			expectedFragment: url.Values{
+				"error":             {"invalid_request"},
+				"error_description": {"The confirmation link is invalid"},
+			},
+		},
+		{
+			name: "Recovery link",
+			form: "token_hash=valid&type=recovery",
+			expectedFragment: url.Values{
+				"error":             {"invalid_request"},
+				"error_description": {"The confirmation link is invalid"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			location, rec := verify(h, tt.form)
+			fragment, _ := url.ParseQuery(location.Fragment)
+
+			assert.Equal(t, rec.Code, http.StatusSeeOther)
+			assert.Equal(t, location.Host, "app.example.com")
+			assert.Equal(t, location.RawQuery, "from=email")
+			assert.Equal(t, fragment, tt.expectedFragment)
+		})
+	}
+}
+
+func TestVerifyHandlerSession(t *testing.T) {
+	cfg := config.Default()
+	cfg.Supabase.JwtSecret = "secret"
+
+	details := sessionDetails("verified")
+	client := &confirmationAuth{details: details}
+	manager := session.NewManager(session.NewMemoryStore(), client, "secret", sessionCipher(), time.Hour, time.Minute)
+	h := NewHandler(cfg, client, validation.NewValidator(), nil, discardLogger, nil, nil, manager, nil)
+
+	location, rec := verify(h, "token_hash=valid&type=signup")
+
+	assert.Equal(t, rec.Code, http.StatusSeeOther)
+	assert.Equal(t, location.Fragment, "type=signup")
+
+	cookies := rec.Result().Cookies()
+	assert.Equal(t, len(cookies), 1)
+	assert.Equal(t, cookies[0].Name, "session")
+
+	s, err := manager.Resolve(context.Background(), cookies[0].Value)
+	assert.Equal(t, err, nil)
+	accessToken, _ := manager.AccessToken(s)
+	assert.Equal(t, accessToken, details.AccessToken)
+}
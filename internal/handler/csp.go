@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/http/response"
+	"github.com/labstack/echo/v4"
+	"io"
+)
+
+// maxCSPReportSize bounds the reports read, as anyone can post them.
+const maxCSPReportSize = 64 << 10
+
+type cspViolation struct {
+	DocumentURI string
+	BlockedURI  string
+	Directive   string
+	Disposition string
+	SourceFile  string
+	LineNumber  int
+}
+
+// legacyCSPReport is the body sent to report-uri endpoints as
+// application/csp-report.
+type legacyCSPReport struct {
+	Report *struct {
+		DocumentURI        string `json:"document-uri"`
+		BlockedURI         string `json:"blocked-uri"`
+		ViolatedDirective  string `json:"violated-directive"`
+		EffectiveDirective string `json:"effective-directive"`
+		Disposition        string `json:"disposition"`
+		SourceFile         string `json:"source-file"`
+		LineNumber         int    `json:"line-number"`
+	} `json:"csp-report"`
+}
+
+// cspReport is a report of the Reporting API, sent in application/reports+json
+// lists.
+type cspReport struct {
+	Type string `json:"type"`
+	Body struct {
+		DocumentURL        string `json:"documentURL"`
+		BlockedURL         string `json:"blockedURL"`
+		EffectiveDirective string `json:"effectiveDirective"`
+		Disposition        string `json:"disposition"`
+		SourceFile         string `json:"sourceFile"`
+		LineNumber         int    `json:"lineNumber"`
+	} `json:"body"`
+}
+
+// CSPReportHandler logs the Content Security Policy violations reported by
+// browsers. Malformed reports are ignored, as browsers never retry.
+func (h *Handler) CSPReportHandler(c echo.Context) error {
+	body, err := io.ReadAll(io.LimitReader(c.Request().Body, maxCSPReportSize))
+	if err != nil {
+		return response.NoContentResponse(c)
+	}
+
+	for _, violation := range parseCSPReports(body) {
+		h.log.Warn("CSP violation",
+			"document_uri", violation.DocumentURI,
+			"blocked_uri", violation.BlockedURI,
+			"directive", violation.Directive,
+			"disposition", violation.Disposition,
+			"source_file", violation.SourceFile,
+			"line_number", violation.LineNumber,
+			"user_agent", c.Request().UserAgent(),
+		)
+	}
+
+	return response.NoContentResponse(c)
+}
+
+// parseCSPReports accepts either report format, keeping only CSP violations
+// from Reporting API lists.
+func parseCSPReports(body []byte) []cspViolation {
+	var legacy legacyCSPReport
+
+	if err := json.Unmarshal(body, &legacy); err == nil && legacy.Report != nil {
+		r := legacy.Report
+
+		directive := r.EffectiveDirective
+		if directive == "" {
+			directive = r.ViolatedDirective
+		}
+
+		return []cspViolation{{
+			DocumentURI: r.DocumentURI,
+			BlockedURI:  r.BlockedURI,
+			Directive:   directive,
+			Disposition: r.Disposition,
+			SourceFile:  r.SourceFile,
+			LineNumber:  r.LineNumber,
+		}}
+	}
+
+	var reports []cspReport
+	if err := json.Unmarshal(body, &reports); err != nil {
+		return nil
+	}
+
+	var violations []cspViolation
+	for _, report := range reports {
+		if report.Type != "csp-violation" {
+			continue
+		}
+
+		violations = append(violations, cspViolation{
+			DocumentURI: report.Body.DocumentURL,
+			BlockedURI:  report.Body.BlockedURL,
+			Directive:   report.Body.EffectiveDirective,
+			Disposition: report.Body.Disposition,
+			SourceFile:  report.Body.SourceFile,
+			LineNumber:  report.Body.LineNumber,
+		})
+	}
+
+	return violations
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"github.com/go-playground/assert/v2"
+	"testing"
+)
+
+func TestParseCSPReports(t *testing.T) {
+	legacy := `{"csp-report": {
+		"document-uri": "https://app.example.com/",
+		"blocked-uri": "https://evil.test/x.js",
+		"violated-directive": "script-src",
+		"effective-directive": "script-src-elem",
+		"disposition": "enforce",
+		"line-number": 12
+	}}`
+
+	assert.Equal(t, parseCSPReports([]byte(legacy)), []cspViolation{{
+		DocumentURI: "https://app.example.com/",
+		BlockedURI:  "https://evil.test/x.js",
+		Directive:   "script-src-elem",
+		Disposition: "enforce",
+		LineNumber:  12,
+	}})
+
+	reporting := `[
+		{"type": "deprecation", "body": {}},
+		{"type": "csp-violation", "body": {
+			"documentURL": "https://app.example.com/",
+			"blockedURL": "inline",
+			"effectiveDirective": "style-src-attr",
+			"disposition": "report",
+			"sourceFile": "https://app.example.com/app.js",
+			"lineNumber": 3
+		}}
+	]`
+
+	assert.Equal(t, parseCSPReports([]byte(reporting)), []cspViolation{{
+		DocumentURI: "https://app.example.com/",
+		BlockedURI:  "inline",
+		Directive:   "style-src-attr",
+		Disposition: "report",
+		SourceFile:  "https://app.example.com/app.js",
+		LineNumber:  3,
+	}})
+
+	assert.Equal(t, len(parseCSPReports([]byte("not json"))), 0)
+}
@@ -3,29 +3,54 @@ package handler
 import (
 	"encoding/json"
 	"fmt"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/audit"
 	"github.com/Fortress-Digital/go-rest-skeleton/internal/config"
 	"github.com/Fortress-Digital/go-rest-skeleton/internal/http/response"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/invite"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/lockout"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/log"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/mail"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/middleware"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/ratelimit"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/session"
 	"github.com/Fortress-Digital/go-rest-skeleton/internal/supabase"
 	"github.com/Fortress-Digital/go-rest-skeleton/internal/validation"
 	"github.com/labstack/echo/v4"
 	"io"
 )
 
+// Handler serves the account routes. The lockout tracker, limiter, session
+// manager and invitation store are optional, a nil value disabling login
+// lockouts, the recovery email throttle, sessions and invitations.
 type Handler struct {
-	cfg       *config.Config
-	auth      supabase.AuthClientInterface
-	validator validation.ValidatorInterface
+	cfg         *config.Config
+	auth        supabase.AuthClientInterface
+	validator   validation.ValidatorInterface
+	mailer      mail.TemplateMailerInterface
+	log         log.LoggerInterface
+	lockout     *lockout.Tracker
+	limiter     *ratelimit.Limiter
+	sessions    *session.Manager
+	invitations invite.Store
+	audit       audit.Recorder
 }
 
-func NewHandler(cfg *config.Config, auth supabase.AuthClientInterface, validator validation.ValidatorInterface) *Handler {
+func NewHandler(cfg *config.Config, auth supabase.AuthClientInterface, validator validation.ValidatorInterface, mailer mail.TemplateMailerInterface, log log.LoggerInterface, lockout *lockout.Tracker, limiter *ratelimit.Limiter, sessions *session.Manager, invitations invite.Store) *Handler {
 	return &Handler{
-		cfg:       cfg,
-		auth:      auth,
-		validator: validator,
+		cfg:         cfg,
+		auth:        auth,
+		validator:   validator,
+		mailer:      mailer,
+		log:         log,
+		lockout:     lockout,
+		limiter:     limiter,
+		sessions:    sessions,
+		invitations: invitations,
+		audit:       audit.NewLogRecorder(log),
 	}
 }
 
-func (h *Handler) decode(data io.ReadCloser, v interface{}) error {
+func decode(data io.ReadCloser, v interface{}) error {
 	decoder := json.NewDecoder(data)
 	err := decoder.Decode(v)
 	if err != nil {
@@ -41,3 +66,11 @@ func (h *Handler) HomeHandler(c echo.Context) error {
 	}
 	return response.SuccessResponse(c, r)
 }
+
+// CSRFHandler returns the CSRF token, also set in the cookie, for browser
+// clients to send back in the X-CSRF-Token header.
+func (h *Handler) CSRFHandler(c echo.Context) error {
+	return response.SuccessResponse(c, map[string]string{
+		"token": middleware.CSRFToken(c),
+	})
+}
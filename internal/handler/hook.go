@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/log"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/supabase"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+type hookErrorResponse struct {
+	Error *supabase.HookError `json:"error"`
+}
+
+type HookHandler struct {
+	hooks supabase.Hooks
+	log   log.LoggerInterface
+}
+
+func NewHookHandler(hooks supabase.Hooks, log log.LoggerInterface) *HookHandler {
+	return &HookHandler{hooks: hooks, log: log}
+}
+
+func (h *HookHandler) CustomAccessTokenHandler(c echo.Context) error {
+	if h.hooks.CustomAccessToken == nil {
+		return h.hookError(c, errHookNotConfigured)
+	}
+
+	var input supabase.CustomAccessTokenInput
+	if err := decode(c.Request().Body, &input); err != nil {
+		return h.hookError(c, supabase.NewHookError(http.StatusBadRequest, err.Error()))
+	}
+
+	output, err := h.hooks.CustomAccessToken.CustomAccessToken(c.Request().Context(), input)
+	if err != nil {
+		return h.hookError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, output)
+}
+
+func (h *HookHandler) SendEmailHandler(c echo.Context) error {
+	if h.hooks.SendEmail == nil {
+		return h.hookError(c, errHookNotConfigured)
+	}
+
+	var input supabase.SendEmailInput
+	if err := decode(c.Request().Body, &input); err != nil {
+		return h.hookError(c, supabase.NewHookError(http.StatusBadRequest, err.Error()))
+	}
+
+	if err := h.hooks.SendEmail.SendEmail(c.Request().Context(), input); err != nil {
+		return h.hookError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, struct{}{})
+}
+
+func (h *HookHandler) MFAVerificationAttemptHandler(c echo.Context) error {
+	if h.hooks.MFAVerificationAttempt == nil {
+		return h.hookError(c, errHookNotConfigured)
+	}
+
+	var input supabase.MFAVerificationAttemptInput
+	if err := decode(c.Request().Body, &input); err != nil {
+		return h.hookError(c, supabase.NewHookError(http.StatusBadRequest, err.Error()))
+	}
+
+	output, err := h.hooks.MFAVerificationAttempt.MFAVerificationAttempt(c.Request().Context(), input)
+	if err != nil {
+		return h.hookError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, output)
+}
+
+func (h *HookHandler) PasswordVerificationAttemptHandler(c echo.Context) error {
+	if h.hooks.PasswordVerificationAttempt == nil {
+		return h.hookError(c, errHookNotConfigured)
+	}
+
+	var input supabase.PasswordVerificationAttemptInput
+	if err := decode(c.Request().Body, &input); err != nil {
+		return h.hookError(c, supabase.NewHookError(http.StatusBadRequest, err.Error()))
+	}
+
+	output, err := h.hooks.PasswordVerificationAttempt.PasswordVerificationAttempt(c.Request().Context(), input)
+	if err != nil {
+		return h.hookError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, output)
+}
+
+func (h *HookHandler) BeforeUserCreatedHandler(c echo.Context) error {
+	if h.hooks.BeforeUserCreated == nil {
+		return h.hookError(c, errHookNotConfigured)
+	}
+
+	var input supabase.BeforeUserCreatedInput
+	if err := decode(c.Request().Body, &input); err != nil {
+		return h.hookError(c, supabase.NewHookError(http.StatusBadRequest, err.Error()))
+	}
+
+	if err := h.hooks.BeforeUserCreated.BeforeUserCreated(c.Request().Context(), input); err != nil {
+		return h.hookError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, struct{}{})
+}
+
+var errHookNotConfigured = supabase.NewHookError(http.StatusNotFound, "hook is not configured")
+
+// hookError responds in the format Supabase Auth expects, so the message is
+// surfaced to the end user instead of a generic hook failure. Unexpected
+// errors are only logged, the user getting a generic message.
+func (h *HookHandler) hookError(c echo.Context, err error) error {
+	hookErr := supabase.AsHookError(err)
+	if hookErr == supabase.ErrHookFailed {
+		h.log.Error("Hook error", "path", c.Path(), "error", err)
+	}
+
+	return c.JSON(hookErr.HTTPCode, hookErrorResponse{Error: hookErr})
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/supabase"
+	"github.com/go-playground/assert/v2"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type failingClaimsHook struct {
+	err error
+}
+
+func (h failingClaimsHook) CustomAccessToken(context.Context, supabase.CustomAccessTokenInput) (*supabase.CustomAccessTokenOutput, error) {
+	return nil, h.err
+}
+
+func TestHookHandlerHidesUnexpectedErrors(t *testing.T) {
+	tests := []struct {
+		name            string
+		err             error
+		expectedStatus  int
+		expectedMessage string
+	}{
+		{"Hook error", supabase.NewHookError(http.StatusForbidden, "account suspended"), http.StatusForbidden, "account suspended"},
+		{"Unexpected error", errors.New("Error 1146 (42S02): Table 'app.user_claims' doesn't exist"), http.StatusInternalServerError, "the request could not be completed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHookHandler(supabase.Hooks{CustomAccessToken: failingClaimsHook{err: tt.err}}, discardLogger)
+
+			rec, err := post(h.CustomAccessTokenHandler, `{"user_id": "123"}`, nil)
+
+			assert.Equal(t, err, nil)
+			assert.Equal(t, rec.Code, tt.expectedStatus)
+			assert.Equal(t, strings.Contains(rec.Body.String(), tt.expectedMessage), true)
+			assert.Equal(t, strings.Contains(rec.Body.String(), "user_claims"), false)
+		})
+	}
+}
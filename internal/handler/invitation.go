@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"errors"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/audit"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/auth"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/config"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/http/request"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/http/response"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/invite"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/model"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/validation"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Audit event types.
+const (
+	EventInvitationCreated  = "invitation.created"
+	EventInvitationRevoked  = "invitation.revoked"
+	EventInvitationRedeemed = "invitation.redeemed"
+)
+
+var (
+	errRegistrationDisabled = errors.New("registration is disabled")
+	errDomainNotAllowed     = errors.New("registration is restricted to approved email domains")
+	errInvitationRequired   = errors.New("an invitation code is required")
+	errInvitationNotFound   = errors.New("invitation not found")
+)
+
+type createdInvitation struct {
+	*model.Invitation
+	Code string `json:"code"`
+}
+
+// InvitationHandler lets administrators issue and revoke the invitations
+// required to register in invite mode.
+type InvitationHandler struct {
+	cfg       config.Registration
+	store     invite.Store
+	validator validation.ValidatorInterface
+	audit     audit.Recorder
+}
+
+func NewInvitationHandler(cfg *config.Config, store invite.Store, validator validation.ValidatorInterface, recorder audit.Recorder) *InvitationHandler {
+	return &InvitationHandler{cfg: cfg.Registration, store: store, validator: validator, audit: recorder}
+}
+
+func (h *InvitationHandler) ListHandler(c echo.Context) error {
+	invitations, err := h.store.List(c.Request().Context())
+	if err != nil {
+		return response.ServerErrorResponse(err)
+	}
+
+	if invitations == nil {
+		invitations = []model.Invitation{}
+	}
+
+	return response.SuccessResponse(c, invitations)
+}
+
+// CreateHandler returns the new invitation with its code. The code cannot
+// be retrieved again.
+func (h *InvitationHandler) CreateHandler(c echo.Context) error {
+	var r request.CreateInvitationRequest
+
+	if err := decode(c.Request().Body, &r); err != nil {
+		return response.BadRequestResponse(err)
+	}
+
+	validationErrors := h.validator.Validate(r)
+
+	if len(validationErrors.ValidationErrors) > 0 {
+		return response.ValidationErrorResponse(validationErrors)
+	}
+
+	code, hash, err := invite.Generate()
+	if err != nil {
+		return response.ServerErrorResponse(err)
+	}
+
+	invitation := &model.Invitation{
+		CodeHash:  hash,
+		Email:     strings.ToLower(strings.TrimSpace(r.Email)),
+		MaxUses:   h.cfg.InvitationMaxUses,
+		CreatedBy: auth.FromContext(c).ID,
+		ExpiresAt: time.Now().Add(time.Duration(h.cfg.InvitationLifetime) * time.Second),
+	}
+
+	if r.MaxUses > 0 {
+		invitation.MaxUses = r.MaxUses
+	}
+
+	if r.ExpiresAt != nil {
+		invitation.ExpiresAt = *r.ExpiresAt
+	}
+
+	if err = h.store.Create(c.Request().Context(), invitation); err != nil {
+		return response.ServerErrorResponse(err)
+	}
+
+	h.record(c, EventInvitationCreated, invitation)
+
+	return response.CreatedResponse(c, createdInvitation{Invitation: invitation, Code: code})
+}
+
+// RevokeHandler keeps the invitation for the record but stops it from being
+// redeemed. Revoking a revoked invitation is a no-op.
+func (h *InvitationHandler) RevokeHandler(c echo.Context) error {
+	notFound := response.ErrorResponse(http.StatusNotFound, response.Error{Message: errInvitationNotFound.Error()})
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return notFound
+	}
+
+	invitation, err := h.store.Find(c.Request().Context(), uint(id))
+	if err != nil {
+		return response.ServerErrorResponse(err)
+	}
+
+	if invitation == nil {
+		return notFound
+	}
+
+	if invitation.RevokedAt == nil {
+		now := time.Now()
+		invitation.RevokedAt = &now
+
+		if err = h.store.Update(c.Request().Context(), invitation); err != nil {
+			return response.ServerErrorResponse(err)
+		}
+
+		h.record(c, EventInvitationRevoked, invitation)
+	}
+
+	return response.NoContentResponse(c)
+}
+
+func (h *InvitationHandler) record(c echo.Context, event string, invitation *model.Invitation) {
+	h.audit.Record(c.Request().Context(), audit.Event{
+		Type:    event,
+		ActorID: auth.FromContext(c).ID,
+		Email:   invitation.Email,
+		IP:      c.RealIP(),
+		Details: map[string]any{"invitation": invitation.ID},
+	})
+}
+
+// admitRegistration applies the registration mode to the request, redeeming
+// its invitation code in invite mode. The invitation is nil in other modes.
+func (h *Handler) admitRegistration(c echo.Context, r request.RegisterRequest) (*model.Invitation, error) {
+	forbidden := func(err error) error {
+		return response.ErrorResponse(http.StatusForbidden, response.Error{Message: err.Error()})
+	}
+
+	cfg := h.cfg.Registration
+
+	switch cfg.Mode {
+	case "disabled":
+		return nil, forbidden(errRegistrationDisabled)
+	case "domains":
+		if !cfg.AllowsDomain(r.Email) {
+			return nil, forbidden(errDomainNotAllowed)
+		}
+	case "invite":
+		if r.InvitationCode == "" {
+			return nil, forbidden(errInvitationRequired)
+		}
+
+		if h.invitations == nil {
+			return nil, response.ServerErrorResponse(errors.New("invitations require the database"))
+		}
+
+		invitation, err := invite.Redeem(c.Request().Context(), h.invitations, r.InvitationCode, r.Email, time.Now())
+		if errors.Is(err, invite.ErrInvalidInvitation) {
+			return nil, forbidden(err)
+		}
+
+		if err != nil {
+			return nil, response.ServerErrorResponse(err)
+		}
+
+		return invitation, nil
+	}
+
+	return nil, nil
+}
+
+// releaseInvitation gives back the use of an invitation whose registration
+// failed. A failure is logged, costing the invitation a use at worst.
+func (h *Handler) releaseInvitation(c echo.Context, invitation *model.Invitation) {
+	if invitation == nil {
+		return
+	}
+
+	if err := h.invitations.Release(c.Request().Context(), invitation.ID); err != nil {
+		h.log.Error("Invitation release error", "invitation", invitation.ID, "error", err)
+	}
+}
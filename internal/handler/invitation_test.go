@@ -0,0 +1,269 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/audit"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/auth"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/config"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/invite"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/model"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/supabase"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/validation"
+	"github.com/go-playground/assert/v2"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// signUpAuth registers every user, unless failing is set. Existing answers
+// like Supabase Auth does for registered addresses.
+type signUpAuth struct {
+	supabase.AuthClientInterface
+	failing  bool
+	existing bool
+	signUps  []supabase.UserCredentials
+}
+
+func (a *signUpAuth) SignUp(credentials supabase.UserCredentials) (*supabase.User, *supabase.ErrorResponse, error) {
+	if a.failing {
+		return nil, &supabase.ErrorResponse{Code: http.StatusBadRequest, Message: "User already registered"}, nil
+	}
+
+	a.signUps = append(a.signUps, credentials)
+
+	if a.existing {
+		return &supabase.User{ID: "obfuscated", Email: credentials.Email, Identities: []supabase.UserIdentity{}}, nil, nil
+	}
+
+	return &supabase.User{ID: "user", Email: credentials.Email, Identities: []supabase.UserIdentity{{ID: "user", Provider: "email"}}}, nil, nil
+}
+
+type discardMailer struct{}
+
+func (discardMailer) SendTemplate(context.Context, string, string, []string, any) error {
+	return nil
+}
+
+// recordingMailer records the recipients of every email.
+type recordingMailer struct {
+	sent [][]string
+}
+
+func (m *recordingMailer) SendTemplate(_ context.Context, _ string, _ string, to []string, _ any) error {
+	m.sent = append(m.sent, to)
+
+	return nil
+}
+
+func TestRegistrationModes(t *testing.T) {
+	const code = "AAAA-BBBB-CCCC-DDDD"
+
+	tests := []struct {
+		name           string
+		mode           string
+		domains        []string
+		body           string
+		expectedStatus int
+		expectedSignUp bool
+	}{
+		{
+			name:           "Open",
+			mode:           "open",
+			body:           `{"email":"test@example.com","password":"correct-horse-battery"}`,
+			expectedStatus: http.StatusCreated,
+			expectedSignUp: true,
+		},
+		{
+			name:           "Disabled",
+			mode:           "disabled",
+			body:           `{"email":"test@example.com","password":"correct-horse-battery"}`,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Allowed domain",
+			mode:           "domains",
+			domains:        []string{"example.com"},
+			body:           `{"email":"test@Example.com","password":"correct-horse-battery"}`,
+			expectedStatus: http.StatusCreated,
+			expectedSignUp: true,
+		},
+		{
+			name:           "Other domain",
+			mode:           "domains",
+			domains:        []string{"example.com"},
+			body:           `{"email":"test@example.org","password":"correct-horse-battery"}`,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Subdomain of allowed domain",
+			mode:           "domains",
+			domains:        []string{"example.com"},
+			body:           `{"email":"test@mail.example.com","password":"correct-horse-battery"}`,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Invitation code",
+			mode:           "invite",
+			body:           `{"email":"test@example.com","password":"correct-horse-battery","invitationCode":"aaaa-bbbb-cccc-dddd"}`,
+			expectedStatus: http.StatusCreated,
+			expectedSignUp: true,
+		},
+		{
+			name:           "Missing invitation code",
+			mode:           "invite",
+			body:           `{"email":"test@example.com","password":"correct-horse-battery"}`,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Unknown invitation code",
+			mode:           "invite",
+			body:           `{"email":"test@example.com","password":"correct-horse-battery","invitationCode":"AAAA-BBBB-CCCC-EEEE"}`,
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Default()
+			cfg.Registration.Mode = tt.mode
+			cfg.Registration.AllowedDomains = tt.domains
+
+			store := invite.NewMemoryStore()
+			_ = store.Create(context.Background(), &model.Invitation{CodeHash: invite.Hash(code), MaxUses: 1, ExpiresAt: time.Now().Add(time.Hour)})
+
+			client := &signUpAuth{}
+			h := NewHandler(cfg, client, validation.NewValidator(), discardMailer{}, discardLogger, nil, nil, nil, store)
+
+			rec, err := post(h.RegisterHandler, tt.body, http.Header{echo.HeaderContentType: {echo.MIMEApplicationJSON}})
+
+			status := rec.Code
+			if he, ok := err.(*echo.HTTPError); ok && he != nil {
+				status = he.Code
+			}
+
+			assert.Equal(t, status, tt.expectedStatus)
+			assert.Equal(t, len(client.signUps) == 1, tt.expectedSignUp)
+		})
+	}
+}
+
+func TestRegistrationReleasesInvitation(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.Default()
+	cfg.Registration.Mode = "invite"
+
+	store := invite.NewMemoryStore()
+	invitation := &model.Invitation{CodeHash: invite.Hash("AAAA-BBBB"), MaxUses: 1, ExpiresAt: time.Now().Add(time.Hour)}
+	_ = store.Create(ctx, invitation)
+
+	h := NewHandler(cfg, &signUpAuth{failing: true}, validation.NewValidator(), discardMailer{}, discardLogger, nil, nil, nil, store)
+
+	_, err := post(h.RegisterHandler, `{"email":"test@example.com","password":"correct-horse-battery","invitationCode":"AAAA-BBBB"}`, nil)
+	assert.Equal(t, err.(*echo.HTTPError).Code, http.StatusBadRequest)
+
+	stored, _ := store.Find(ctx, invitation.ID)
+	assert.Equal(t, stored.Uses, 0)
+
+	h = NewHandler(cfg, &signUpAuth{existing: true}, validation.NewValidator(), discardMailer{}, discardLogger, nil, nil, nil, store)
+
+	rec, err := post(h.RegisterHandler, `{"email":"test@example.com","password":"correct-horse-battery","invitationCode":"AAAA-BBBB"}`, nil)
+	if he, ok := err.(*echo.HTTPError); ok && he != nil {
+		t.Fatal(he)
+	}
+	assert.Equal(t, rec.Code, http.StatusCreated)
+
+	stored, _ = store.Find(ctx, invitation.ID)
+	assert.Equal(t, stored.Uses, 0)
+}
+
+func TestRegistrationWelcomesNewUsersOnly(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing bool
+		expected [][]string
+	}{
+		{"New user", false, [][]string{{"test@example.com"}}},
+		{"Registered user", true, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mailer := &recordingMailer{}
+			h := NewHandler(config.Default(), &signUpAuth{existing: tt.existing}, validation.NewValidator(), mailer, discardLogger, nil, nil, nil, nil)
+
+			rec, err := post(h.RegisterHandler, `{"email":"test@example.com","password":"correct-horse-battery"}`, nil)
+			if he, ok := err.(*echo.HTTPError); ok && he != nil {
+				t.Fatal(he)
+			}
+
+			assert.Equal(t, rec.Code, http.StatusCreated)
+			assert.Equal(t, mailer.sent, tt.expected)
+		})
+	}
+}
+
+func TestRegistrationPassesAdmission(t *testing.T) {
+	cfg := config.Default()
+	cfg.Registration.Mode = "invite"
+	cfg.Supabase.JwtSecret = "secret"
+
+	store := invite.NewMemoryStore()
+	_ = store.Create(context.Background(), &model.Invitation{CodeHash: invite.Hash("AAAA-BBBB"), MaxUses: 1, ExpiresAt: time.Now().Add(time.Hour)})
+
+	client := &signUpAuth{}
+	h := NewHandler(cfg, client, validation.NewValidator(), discardMailer{}, discardLogger, nil, nil, nil, store)
+
+	_, _ = post(h.RegisterHandler, `{"email":"test@example.com","password":"correct-horse-battery","invitationCode":"AAAA-BBBB"}`, nil)
+
+	assert.Equal(t, len(client.signUps), 1)
+
+	data := client.signUps[0].Data.(map[string]string)
+	assert.Equal(t, invite.Admitted("secret", "test@example.com", data[invite.AdmissionKey], time.Now()), true)
+}
+
+func TestInvitationHandler(t *testing.T) {
+	ctx := context.Background()
+	store := invite.NewMemoryStore()
+	recorder := &audit.MemoryRecorder{}
+	h := NewInvitationHandler(config.Default(), store, validation.NewValidator(), recorder)
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"email":"test@example.com"}`))
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	auth.SetPrincipal(c, &auth.Principal{Type: auth.PrincipalUser, ID: "admin"})
+
+	assert.Equal(t, h.CreateHandler(c), nil)
+	assert.Equal(t, rec.Code, http.StatusCreated)
+
+	var created struct {
+		ID      uint   `json:"id"`
+		Code    string `json:"code"`
+		MaxUses int    `json:"maxUses"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &created)
+
+	invitation, _ := store.Find(ctx, created.ID)
+	assert.Equal(t, invitation.CodeHash, invite.Hash(created.Code))
+	assert.Equal(t, invitation.Email, "test@example.com")
+	assert.Equal(t, invitation.CreatedBy, "admin")
+	assert.Equal(t, invitation.MaxUses, 1)
+
+	req = httptest.NewRequest(http.MethodDelete, "/", nil)
+	c = echo.New().NewContext(req, httptest.NewRecorder())
+	c.SetParamNames("id")
+	c.SetParamValues("1")
+	auth.SetPrincipal(c, &auth.Principal{Type: auth.PrincipalUser, ID: "admin"})
+
+	assert.Equal(t, h.RevokeHandler(c), nil)
+
+	_, err := invite.Redeem(ctx, store, created.Code, "test@example.com", time.Now())
+	assert.Equal(t, err, invite.ErrInvalidInvitation)
+	assert.Equal(t, recorder.Types(), []string{EventInvitationCreated, EventInvitationRevoked})
+
+	c.SetParamValues("2")
+	assert.Equal(t, h.RevokeHandler(c).(*echo.HTTPError).Code, http.StatusNotFound)
+}
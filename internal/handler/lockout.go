@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"errors"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/audit"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/auth"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/config"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/http/response"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/lockout"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/ratelimit"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/supabase"
+	"github.com/labstack/echo/v4"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Audit event types.
+const (
+	EventLoginBlocked      = "login.blocked"
+	EventLoginFailed       = "login.failed"
+	EventLoginLocked       = "login.locked"
+	EventLockoutReset      = "lockout.reset"
+	EventLockoutCleared    = "lockout.cleared"
+	EventRecoveryThrottled = "recovery_email.throttled"
+	EventConfirmThrottled  = "confirmation_email.throttled"
+)
+
+var errLockoutSubjectRequired = errors.New("email or ip is required")
+
+// checkLockout rejects the login while any subject has to wait. The tracker
+// failing lets the login through, like the rate limits.
+func (h *Handler) checkLockout(c echo.Context, subjects []lockout.Subject) error {
+	if h.lockout == nil {
+		return nil
+	}
+
+	status, blocked, err := h.lockout.Check(c.Request().Context(), subjects...)
+	if err != nil {
+		h.log.Warn("Lockout check error", "error", err)
+		return nil
+	}
+
+	if !blocked {
+		return nil
+	}
+
+	h.audit.Record(c.Request().Context(), audit.Event{
+		Type:    EventLoginBlocked,
+		Email:   subjects[0].Value,
+		IP:      c.RealIP(),
+		Details: map[string]any{"subject": status.Kind, "locked": status.Locked},
+	})
+
+	return tooManyRequests(c, status.RetryAfter(time.Now()), "too many failed login attempts, please retry later")
+}
+
+func (h *Handler) recordLoginFailure(c echo.Context, subjects []lockout.Subject) {
+	if h.lockout == nil {
+		return
+	}
+
+	ctx := c.Request().Context()
+
+	statuses, err := h.lockout.Fail(ctx, subjects...)
+	if err != nil {
+		h.log.Warn("Lockout failure error", "error", err)
+	}
+
+	h.audit.Record(ctx, audit.Event{Type: EventLoginFailed, Email: subjects[0].Value, IP: c.RealIP()})
+
+	for _, status := range statuses {
+		if status.Locked {
+			h.audit.Record(ctx, audit.Event{
+				Type:    EventLoginLocked,
+				Email:   subjects[0].Value,
+				IP:      c.RealIP(),
+				Details: map[string]any{"subject": status.Kind, "failures": status.Failures, "until": status.RetryAt},
+			})
+		}
+	}
+}
+
+func (h *Handler) resetLockout(c echo.Context, subject lockout.Subject) {
+	if h.lockout == nil {
+		return
+	}
+
+	if err := h.lockout.Reset(c.Request().Context(), subject); err != nil {
+		h.log.Warn("Lockout reset error", "error", err)
+	}
+}
+
+// throttleRecoveryEmail limits the recovery emails sent to one address, on
+// top of the per IP rate limit, so nobody can flood an inbox.
+func (h *Handler) throttleRecoveryEmail(c echo.Context, email string) error {
+	if !h.cfg.Lockout.Enabled {
+		return nil
+	}
+
+	return h.throttleEmail(c, "recovery_emails", email, h.cfg.Lockout.RecoveryEmails, EventRecoveryThrottled, "too many password recovery requests, please retry later")
+}
+
+// throttleEmail limits the emails of the kind sent to one address. The
+// limiter failing lets the email through, like the rate limits.
+func (h *Handler) throttleEmail(c echo.Context, kind string, email string, throttle config.Throttle, event string, message string) error {
+	if h.limiter == nil {
+		return nil
+	}
+
+	subject := lockout.Email(email)
+
+	result, err := h.limiter.Allow(c.Request().Context(), kind+":email:"+subject.Value, ratelimit.Policy{
+		Algorithm: ratelimit.SlidingWindow,
+		Limit:     throttle.Limit,
+		Window:    time.Duration(throttle.Window) * time.Second,
+	})
+	if err != nil {
+		h.log.Warn("Email throttle error", "kind", kind, "error", err)
+		return nil
+	}
+
+	if result.Allowed {
+		return nil
+	}
+
+	h.audit.Record(c.Request().Context(), audit.Event{Type: event, Email: subject.Value, IP: c.RealIP()})
+
+	return tooManyRequests(c, result.RetryAfter, message)
+}
+
+// invalidCredentials reports whether Supabase rejected the email and
+// password, rather than e.g. an unconfirmed email.
+func invalidCredentials(err *supabase.ErrorResponse) bool {
+	if err.ErrorCode == "invalid_credentials" {
+		return true
+	}
+
+	return err.ErrorCode == "" && (err.Code == http.StatusBadRequest || err.Code == http.StatusUnauthorized)
+}
+
+func tooManyRequests(c echo.Context, retryAfter time.Duration, message string) error {
+	c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+
+	return response.ErrorResponse(http.StatusTooManyRequests, response.Error{Message: message})
+}
+
+// LockoutHandler lets administrators inspect and lift lockouts.
+type LockoutHandler struct {
+	tracker *lockout.Tracker
+	audit   audit.Recorder
+}
+
+func NewLockoutHandler(tracker *lockout.Tracker, recorder audit.Recorder) *LockoutHandler {
+	return &LockoutHandler{tracker: tracker, audit: recorder}
+}
+
+// ShowHandler returns the state of the email and IP given in the query.
+func (h *LockoutHandler) ShowHandler(c echo.Context) error {
+	subjects, err := lockoutSubjects(c)
+	if err != nil {
+		return err
+	}
+
+	statuses := make([]lockout.Status, 0, len(subjects))
+	for _, subject := range subjects {
+		status, err := h.tracker.Status(c.Request().Context(), subject)
+		if err != nil {
+			return response.ServerErrorResponse(err)
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return response.SuccessResponse(c, statuses)
+}
+
+// ClearHandler forgets the failures of the email and IP given in the query.
+func (h *LockoutHandler) ClearHandler(c echo.Context) error {
+	subjects, err := lockoutSubjects(c)
+	if err != nil {
+		return err
+	}
+
+	if err = h.tracker.Reset(c.Request().Context(), subjects...); err != nil {
+		return response.ServerErrorResponse(err)
+	}
+
+	for _, subject := range subjects {
+		event := audit.Event{Type: EventLockoutCleared, ActorID: auth.FromContext(c).ID}
+		if subject.Kind == lockout.KindEmail {
+			event.Email = subject.Value
+		} else {
+			event.IP = subject.Value
+		}
+
+		h.audit.Record(c.Request().Context(), event)
+	}
+
+	return response.NoContentResponse(c)
+}
+
+func lockoutSubjects(c echo.Context) ([]lockout.Subject, error) {
+	var subjects []lockout.Subject
+
+	if email := c.QueryParam("email"); email != "" {
+		subjects = append(subjects, lockout.Email(email))
+	}
+
+	if ip := c.QueryParam("ip"); ip != "" {
+		subjects = append(subjects, lockout.IP(ip))
+	}
+
+	if len(subjects) == 0 {
+		return nil, response.BadRequestResponse(errLockoutSubjectRequired)
+	}
+
+	return subjects, nil
+}
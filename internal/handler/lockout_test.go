@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/audit"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/auth"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/config"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/lockout"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/ratelimit"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/supabase"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/validation"
+	"github.com/go-playground/assert/v2"
+	"github.com/labstack/echo/v4"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+var discardLogger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError + 1}))
+
+// fakeAuth accepts a single password and counts the calls it receives.
+type fakeAuth struct {
+	supabase.AuthClientInterface
+	password  string
+	signIns   int
+	recovered []string
+}
+
+func (a *fakeAuth) SignIn(credentials supabase.UserCredentials) (*supabase.AuthenticatedDetails, *supabase.ErrorResponse, error) {
+	a.signIns++
+
+	if credentials.Password != a.password {
+		return nil, &supabase.ErrorResponse{Code: http.StatusBadRequest, ErrorCode: "invalid_credentials", Message: "Invalid login credentials"}, nil
+	}
+
+	return &supabase.AuthenticatedDetails{AccessToken: "token"}, nil, nil
+}
+
+func (a *fakeAuth) ForgottenPassword(email string, _ *supabase.MetaSecurity) (*supabase.ErrorResponse, error) {
+	a.recovered = append(a.recovered, email)
+
+	return nil, nil
+}
+
+func (a *fakeAuth) ResetPassword(string, string) (*supabase.ErrorResponse, error) {
+	return nil, nil
+}
+
+func newLockoutHandler(cfg *config.Config, client *fakeAuth) (*Handler, *lockout.Tracker, *audit.MemoryRecorder) {
+	store := ratelimit.NewMemoryStore()
+	tracker := lockout.NewTracker(store, map[string]lockout.Policy{
+		lockout.KindEmail: {MaxAttempts: 3, Window: time.Hour, Duration: time.Hour},
+	})
+
+	h := NewHandler(cfg, client, validation.NewValidator(), nil, discardLogger, tracker, ratelimit.NewLimiter(store), nil, nil)
+	recorder := &audit.MemoryRecorder{}
+	h.audit = recorder
+
+	return h, tracker, recorder
+}
+
+func post(h echo.HandlerFunc, body string, header http.Header) (*httptest.ResponseRecorder, error) {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	rec := httptest.NewRecorder()
+
+	return rec, h(echo.New().NewContext(req, rec))
+}
+
+func TestLoginLockout(t *testing.T) {
+	client := &fakeAuth{password: "correct-horse"}
+	h, _, recorder := newLockoutHandler(config.Default(), client)
+
+	wrong := `{"email": "jane@example.com", "password": "wrong-password"}`
+
+	for range 3 {
+		_, err := post(h.LoginHandler, wrong, nil)
+		assert.Equal(t, err.(*echo.HTTPError).Code, http.StatusBadRequest)
+	}
+
+	// Locked out, even with the right password, without asking Supabase.
+	rec, err := post(h.LoginHandler, `{"email": "JANE@example.com", "password": "correct-horse"}`, nil)
+	assert.Equal(t, err.(*echo.HTTPError).Code, http.StatusTooManyRequests)
+	assert.Equal(t, rec.Header().Get("Retry-After"), "3600")
+	assert.Equal(t, client.signIns, 3)
+
+	assert.Equal(t, recorder.Types(), []string{
+		EventLoginFailed,
+		EventLoginFailed,
+		EventLoginFailed,
+		EventLoginLocked,
+		EventLoginBlocked,
+	})
+}
+
+func TestResetPasswordLiftsLockout(t *testing.T) {
+	cfg := config.Default()
+	cfg.Supabase.JwtSecret = "secret"
+
+	client := &fakeAuth{password: "correct-horse"}
+	h, tracker, _ := newLockoutHandler(cfg, client)
+
+	for range 3 {
+		_, _ = post(h.LoginHandler, `{"email": "jane@example.com", "password": "wrong-password"}`, nil)
+	}
+
+	token, err := supabase.SignAccessToken(supabase.AccessTokenClaims{
+		Subject:   "user-1",
+		Role:      supabase.AuthenticatedRole,
+		Audience:  supabase.Audience{supabase.AuthenticatedRole},
+		Email:     "jane@example.com",
+		ExpiresAt: time.Now().Add(time.Hour).Unix(),
+	}, cfg.Supabase.JwtSecret)
+	assert.Equal(t, err, nil)
+
+	rec, err := post(h.ResetPasswordHandler, `{"password": "new-password-123"}`, http.Header{"Authorization": {"Bearer " + token}})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, rec.Code, http.StatusNoContent)
+
+	_, blocked, _ := tracker.Check(context.Background(), lockout.Email("jane@example.com"))
+	assert.Equal(t, blocked, false)
+
+	rec, err = post(h.LoginHandler, `{"email": "jane@example.com", "password": "correct-horse"}`, nil)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, rec.Code, http.StatusOK)
+}
+
+func TestForgottenPasswordThrottle(t *testing.T) {
+	cfg := config.Default()
+	cfg.Lockout.RecoveryEmails = config.Throttle{Limit: 2, Window: 3600}
+
+	client := &fakeAuth{}
+	h, _, recorder := newLockoutHandler(cfg, client)
+
+	for range 2 {
+		_, err := post(h.ForgottenPasswordHandler, `{"email": "jane@example.com"}`, nil)
+		assert.Equal(t, err, nil)
+	}
+
+	_, err := post(h.ForgottenPasswordHandler, `{"email": "Jane@Example.com"}`, nil)
+	assert.Equal(t, err.(*echo.HTTPError).Code, http.StatusTooManyRequests)
+	assert.Equal(t, len(client.recovered), 2)
+	assert.Equal(t, recorder.Types(), []string{EventRecoveryThrottled})
+
+	// Other addresses are not affected.
+	_, err = post(h.ForgottenPasswordHandler, `{"email": "john@example.com"}`, nil)
+	assert.Equal(t, err, nil)
+}
+
+func TestLockoutHandler(t *testing.T) {
+	_, tracker, _ := newLockoutHandler(config.Default(), &fakeAuth{})
+	recorder := &audit.MemoryRecorder{}
+	h := NewLockoutHandler(tracker, recorder)
+
+	for range 3 {
+		_, _ = tracker.Fail(context.Background(), lockout.Email("jane@example.com"))
+	}
+
+	request := func(method string, query string) (*httptest.ResponseRecorder, error) {
+		req := httptest.NewRequest(method, "/admin/lockouts?"+query, nil)
+		rec := httptest.NewRecorder()
+
+		c := echo.New().NewContext(req, rec)
+		auth.SetPrincipal(c, &auth.Principal{Type: auth.PrincipalUser, ID: "admin-1", Role: auth.RoleAdmin})
+
+		if method == http.MethodDelete {
+			return rec, h.ClearHandler(c)
+		}
+
+		return rec, h.ShowHandler(c)
+	}
+
+	rec, err := request(http.MethodGet, "email=jane@example.com")
+	assert.Equal(t, err, nil)
+
+	var statuses []lockout.Status
+	assert.Equal(t, json.Unmarshal(rec.Body.Bytes(), &statuses), nil)
+	assert.Equal(t, len(statuses), 1)
+	assert.Equal(t, statuses[0].Failures, 3)
+	assert.Equal(t, statuses[0].Locked, true)
+
+	rec, err = request(http.MethodDelete, "email=jane@example.com")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, rec.Code, http.StatusNoContent)
+	assert.Equal(t, recorder.Types(), []string{EventLockoutCleared})
+
+	status, _ := tracker.Status(context.Background(), lockout.Email("jane@example.com"))
+	assert.Equal(t, status.Failures, 0)
+
+	_, err = request(http.MethodGet, "")
+	assert.Equal(t, err.(*echo.HTTPError).Code, http.StatusBadRequest)
+}
@@ -0,0 +1,207 @@
+package handler
+
+import (
+	"errors"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/auth"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/config"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/http/response"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/log"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/model"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/session"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/supabase"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var errSessionNotFound = errors.New("session not found")
+
+var sessionSameSite = map[string]http.SameSite{
+	"lax":    http.SameSiteLaxMode,
+	"strict": http.SameSiteStrictMode,
+	"none":   http.SameSiteNoneMode,
+}
+
+type listedSession struct {
+	model.Session
+	Current bool `json:"current"`
+}
+
+// SessionHandler lets users see where they are logged in and revoke
+// sessions.
+type SessionHandler struct {
+	cfg      config.Sessions
+	auth     supabase.AuthClientInterface
+	sessions *session.Manager
+	log      log.LoggerInterface
+}
+
+func NewSessionHandler(cfg *config.Config, auth supabase.AuthClientInterface, sessions *session.Manager, log log.LoggerInterface) *SessionHandler {
+	return &SessionHandler{cfg: cfg.Sessions, auth: auth, sessions: sessions, log: log}
+}
+
+// ListHandler returns the active sessions of the user, flagging the one of
+// the request.
+func (h *SessionHandler) ListHandler(c echo.Context) error {
+	principal := auth.FromContext(c)
+
+	sessions, err := h.sessions.List(c.Request().Context(), principal.ID)
+	if err != nil {
+		return response.ServerErrorResponse(err)
+	}
+
+	listed := make([]listedSession, 0, len(sessions))
+	for _, s := range sessions {
+		listed = append(listed, listedSession{Session: s, Current: s.SessionID == principal.SessionID})
+	}
+
+	return response.SuccessResponse(c, listed)
+}
+
+// RevokeHandler revokes one session of the user. Access tokens of the
+// session are rejected from then on, even before they expire.
+func (h *SessionHandler) RevokeHandler(c echo.Context) error {
+	notFound := response.ErrorResponse(http.StatusNotFound, response.Error{Message: errSessionNotFound.Error()})
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return notFound
+	}
+
+	principal := auth.FromContext(c)
+
+	s, err := h.sessions.Find(c.Request().Context(), principal.ID, uint(id))
+	if err != nil {
+		return response.ServerErrorResponse(err)
+	}
+
+	if s == nil {
+		return notFound
+	}
+
+	if err = h.sessions.Revoke(c.Request().Context(), s); err != nil {
+		return response.ServerErrorResponse(err)
+	}
+
+	if s.SessionID == principal.SessionID {
+		c.SetCookie(sessionCookie(h.cfg, "", -1))
+	}
+
+	return response.NoContentResponse(c)
+}
+
+// RevokeAllHandler logs the user out everywhere, signing every session out
+// of Supabase.
+func (h *SessionHandler) RevokeAllHandler(c echo.Context) error {
+	principal := auth.FromContext(c)
+
+	serviceErr, err := h.auth.SignOut(principal.Token, supabase.SignOutGlobal)
+	if err != nil {
+		return response.ServerErrorResponse(err)
+	}
+
+	if serviceErr != nil {
+		return response.BadRequestResponse(serviceErr)
+	}
+
+	if err = h.sessions.RevokeAll(c.Request().Context(), principal.ID); err != nil {
+		return response.ServerErrorResponse(err)
+	}
+
+	c.SetCookie(sessionCookie(h.cfg, "", -1))
+
+	return response.NoContentResponse(c)
+}
+
+// startSession keeps the tokens of a login server side and sets the session
+// cookie. Only the user and the end of the session are returned, so the
+// tokens never reach the browser.
+func (h *Handler) startSession(c echo.Context, details *supabase.AuthenticatedDetails) error {
+	token, s, err := h.sessions.Create(c.Request().Context(), details, device(c))
+	if err != nil {
+		return response.ServerErrorResponse(err)
+	}
+
+	c.SetCookie(sessionCookie(h.cfg.Sessions, token, int(h.sessions.Lifetime().Seconds())))
+
+	return response.SuccessResponse(c, map[string]any{
+		"user":      details.User,
+		"expiresAt": s.ExpiresAt,
+	})
+}
+
+// trackSession records a login or token refresh of a client keeping its own
+// tokens. It fails for revoked sessions only, as tracking is best effort.
+func (h *Handler) trackSession(c echo.Context, details *supabase.AuthenticatedDetails) error {
+	if h.sessions == nil {
+		return nil
+	}
+
+	_, err := h.sessions.Track(c.Request().Context(), details, device(c))
+	if errors.Is(err, auth.ErrRevokedSession) {
+		return err
+	}
+
+	if err != nil {
+		h.log.Warn("Session tracking error", "user", details.User.ID, "error", err)
+	}
+
+	return nil
+}
+
+// endSession destroys the session of the cookie, signs its tokens out of
+// Supabase and clears the cookie. A session already gone is not an error.
+func (h *Handler) endSession(c echo.Context, cookie *http.Cookie) error {
+	s, err := h.sessions.Destroy(c.Request().Context(), cookie.Value)
+	if err != nil {
+		return response.ServerErrorResponse(err)
+	}
+
+	c.SetCookie(sessionCookie(h.cfg.Sessions, "", -1))
+
+	if s == nil {
+		return response.NoContentResponse(c)
+	}
+
+	// The session is gone either way, the access token merely expiring later.
+	accessToken, err := h.sessions.AccessToken(s)
+	if err == nil {
+		_, err = h.auth.SignOut(accessToken, supabase.SignOutLocal)
+	}
+
+	if err != nil {
+		h.log.Warn("Session sign out error", "user", s.UserID, "error", err)
+	}
+
+	return response.NoContentResponse(c)
+}
+
+func device(c echo.Context) session.Device {
+	return session.Device{UserAgent: c.Request().UserAgent(), IP: c.RealIP()}
+}
+
+// sessionCookie returns the session cookie, deleted when maxAge is negative.
+func sessionCookie(cfg config.Sessions, value string, maxAge int) *http.Cookie {
+	sameSite, ok := sessionSameSite[cfg.CookieSameSite]
+	if !ok {
+		sameSite = http.SameSiteDefaultMode
+	}
+
+	cookie := &http.Cookie{
+		Name:     cfg.CookieName,
+		Value:    value,
+		Path:     "/",
+		Domain:   cfg.CookieDomain,
+		MaxAge:   maxAge,
+		Secure:   cfg.CookieSecure,
+		HttpOnly: true,
+		SameSite: sameSite,
+	}
+
+	if maxAge < 0 {
+		cookie.Expires = time.Unix(0, 0)
+	}
+
+	return cookie
+}
@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/auth"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/config"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/secret"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/session"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/supabase"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/validation"
+	"github.com/go-playground/assert/v2"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sessionAuth issues a new Supabase session for each sign in.
+type sessionAuth struct {
+	fakeAuth
+	sessions  int
+	signedOut []string
+}
+
+func (a *sessionAuth) SignIn(credentials supabase.UserCredentials) (*supabase.AuthenticatedDetails, *supabase.ErrorResponse, error) {
+	details, serviceErr, err := a.fakeAuth.SignIn(credentials)
+	if details != nil {
+		a.sessions++
+		details = sessionDetails("s" + strconv.Itoa(a.sessions))
+	}
+
+	return details, serviceErr, err
+}
+
+func (a *sessionAuth) RefreshToken(refreshToken string) (*supabase.AuthenticatedDetails, *supabase.ErrorResponse, error) {
+	return sessionDetails(refreshToken), nil, nil
+}
+
+func (a *sessionAuth) SignOut(token string, scope string) (*supabase.ErrorResponse, error) {
+	a.signedOut = append(a.signedOut, scope)
+
+	return nil, nil
+}
+
+// sessionDetails returns tokens of the Supabase session, its refresh token
+// being the session ID.
+func sessionDetails(sessionID string) *supabase.AuthenticatedDetails {
+	claims := supabase.AccessTokenClaims{
+		Subject:   "123",
+		Role:      supabase.AuthenticatedRole,
+		Audience:  supabase.Audience{supabase.AuthenticatedRole},
+		Email:     "jane@example.com",
+		SessionID: sessionID,
+		ExpiresAt: time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC).Unix(),
+	}
+	token, _ := supabase.SignAccessToken(claims, "secret")
+
+	return &supabase.AuthenticatedDetails{
+		AccessToken:  token,
+		RefreshToken: sessionID,
+		ExpiresIn:    3600,
+		User:         supabase.User{ID: "123", Email: "jane@example.com"},
+	}
+}
+
+func sessionCipher() *secret.Cipher {
+	key, _ := secret.GenerateKey()
+	cipher, _ := secret.NewCipher(key)
+
+	return cipher
+}
+
+func newSessionHandlers() (*Handler, *SessionHandler, *session.Manager, *sessionAuth) {
+	cfg := config.Default()
+	cfg.Supabase.JwtSecret = "secret"
+
+	client := &sessionAuth{fakeAuth: fakeAuth{password: "correct-horse"}}
+	manager := session.NewManager(session.NewMemoryStore(), client, "secret", sessionCipher(), time.Hour, time.Minute)
+
+	h := NewHandler(cfg, client, validation.NewValidator(), nil, discardLogger, nil, nil, manager, nil)
+
+	return h, NewSessionHandler(cfg, client, manager, discardLogger), manager, client
+}
+
+func asUser(h echo.HandlerFunc, method string, sessionID string, id string) (*httptest.ResponseRecorder, error) {
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(httptest.NewRequest(method, "/", nil), rec)
+	c.SetParamNames("id")
+	c.SetParamValues(id)
+
+	auth.SetPrincipal(c, &auth.Principal{Type: auth.PrincipalUser, ID: "123", SessionID: sessionID, Token: "token"})
+
+	return rec, h(c)
+}
+
+func TestSessionLoginAndLogout(t *testing.T) {
+	h, _, manager, client := newSessionHandlers()
+
+	rec, err := post(h.LoginHandler, `{"email": "jane@example.com", "password": "correct-horse", "session": true}`, nil)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, strings.Contains(rec.Body.String(), "token"), false)
+
+	cookies := rec.Result().Cookies()
+	assert.Equal(t, len(cookies), 1)
+	assert.Equal(t, cookies[0].Name, "session")
+	assert.Equal(t, cookies[0].HttpOnly, true)
+	assert.Equal(t, cookies[0].Secure, true)
+	assert.Equal(t, cookies[0].SameSite, http.SameSiteLaxMode)
+	assert.Equal(t, cookies[0].MaxAge, 3600)
+
+	header := http.Header{"Cookie": {cookies[0].Name + "=" + cookies[0].Value}}
+
+	rec, err = post(h.LogoutHandler, "", header)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, rec.Code, http.StatusNoContent)
+	assert.Equal(t, client.signedOut, []string{supabase.SignOutLocal})
+	assert.Equal(t, rec.Result().Cookies()[0].MaxAge, -1)
+
+	_, err = manager.Resolve(context.Background(), cookies[0].Value)
+	assert.Equal(t, err, session.ErrInvalidSession)
+}
+
+func TestLoginWithoutSession(t *testing.T) {
+	h, _, manager, _ := newSessionHandlers()
+
+	rec, err := post(h.LoginHandler, `{"email": "jane@example.com", "password": "correct-horse"}`, nil)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, strings.Contains(rec.Body.String(), "access_token"), true)
+	assert.Equal(t, len(rec.Result().Cookies()), 0)
+
+	// The session is tracked all the same.
+	sessions, err := manager.List(context.Background(), "123")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(sessions), 1)
+
+	// Logging out without a cookie needs a bearer token.
+	_, err = post(h.LogoutHandler, "", nil)
+	assert.Equal(t, err.(*echo.HTTPError).Code, http.StatusUnauthorized)
+
+	// Logging out revokes the session of the bearer token.
+	_, err = post(h.LogoutHandler, "", http.Header{"Authorization": {"Bearer " + sessionDetails("s1").AccessToken}})
+	assert.Equal(t, err, nil)
+
+	revoked, err := manager.Revoked(context.Background(), "s1")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, revoked, true)
+}
+
+func TestSessionManagement(t *testing.T) {
+	h, sessions, manager, client := newSessionHandlers()
+
+	for range 2 {
+		_, err := post(h.LoginHandler, `{"email": "jane@example.com", "password": "correct-horse"}`, nil)
+		assert.Equal(t, err, nil)
+	}
+
+	rec, err := asUser(sessions.ListHandler, http.MethodGet, "s1", "")
+	assert.Equal(t, err, nil)
+
+	var listed []map[string]any
+	assert.Equal(t, json.Unmarshal(rec.Body.Bytes(), &listed), nil)
+	assert.Equal(t, len(listed), 2)
+	assert.Equal(t, listed[0]["current"], true)
+	assert.Equal(t, listed[1]["current"], false)
+	assert.Equal(t, listed[0]["ip"], "192.0.2.1")
+
+	_, err = asUser(sessions.RevokeHandler, http.MethodDelete, "s1", "3")
+	assert.Equal(t, err.(*echo.HTTPError).Code, http.StatusNotFound)
+
+	rec, err = asUser(sessions.RevokeHandler, http.MethodDelete, "s1", "2")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, rec.Code, http.StatusNoContent)
+
+	// The revoked session can no longer refresh its tokens.
+	_, err = post(h.RefreshTokenHandler, `{"refreshToken": "s2"}`, nil)
+	assert.Equal(t, err.(*echo.HTTPError).Code, http.StatusUnauthorized)
+
+	rec, err = post(h.RefreshTokenHandler, `{"refreshToken": "s1"}`, nil)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, rec.Code, http.StatusOK)
+
+	rec, err = asUser(sessions.RevokeAllHandler, http.MethodDelete, "s1", "")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, rec.Code, http.StatusNoContent)
+	assert.Equal(t, client.signedOut, []string{supabase.SignOutLocal, supabase.SignOutGlobal})
+
+	revoked, err := manager.Revoked(context.Background(), "s1")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, revoked, true)
+}
@@ -0,0 +1,458 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/auth"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/config"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/http/request"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/http/response"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/supabase"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/validation"
+	"github.com/labstack/echo/v4"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+const sniffLength = 512
+
+var (
+	errInvalidPath         = errors.New("invalid file path")
+	errInvalidUser         = errors.New("invalid user")
+	errNoFile              = errors.New("no file provided")
+	errFileTooLarge        = errors.New("file exceeds the maximum upload size")
+	errUnsupportedType     = errors.New("file type is not allowed")
+	errContentTypeMismatch = errors.New("file content does not match its declared type")
+	errNoUser              = errors.New("not allowed for credentials acting for no user")
+)
+
+// apiKeyTokenLifetime is how long the access tokens minted for API keys last.
+// They are only used for the storage calls of a single request.
+const apiKeyTokenLifetime = time.Minute
+
+type StorageHandler struct {
+	cfg       config.Storage
+	secret    string
+	storage   *supabase.StorageClient
+	validator validation.ValidatorInterface
+}
+
+func NewStorageHandler(cfg *config.Config, storage *supabase.StorageClient, validator validation.ValidatorInterface) *StorageHandler {
+	return &StorageHandler{
+		cfg:       cfg.Storage,
+		secret:    cfg.Supabase.JwtSecret,
+		storage:   storage,
+		validator: validator,
+	}
+}
+
+// userStorage returns the storage client acting as the user of the principal,
+// so the bucket policies apply. API keys act for their owner with a
+// short-lived access token signed like those of Supabase Auth. Principals
+// acting for no user are refused rather than given the project key.
+func (h *StorageHandler) userStorage(principal *auth.Principal) (*supabase.StorageClient, error) {
+	if principal.Token != "" {
+		return h.storage.WithToken(principal.Token), nil
+	}
+
+	if principal.Type != auth.PrincipalAPIKey {
+		return nil, response.ErrorResponse(http.StatusForbidden, response.Error{
+			Message: errNoUser.Error(),
+		})
+	}
+
+	now := time.Now()
+	token, err := supabase.SignAccessToken(supabase.AccessTokenClaims{
+		Subject:   principal.ID,
+		Role:      supabase.AuthenticatedRole,
+		Audience:  supabase.Audience{supabase.AuthenticatedRole},
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(apiKeyTokenLifetime).Unix(),
+	}, h.secret)
+	if err != nil {
+		return nil, response.ServerErrorResponse(err)
+	}
+
+	return h.storage.WithToken(token), nil
+}
+
+// UploadHandler streams every file part of a multipart request straight to
+// storage under the caller's folder, without buffering whole files.
+func (h *StorageHandler) UploadHandler(c echo.Context) error {
+	principal := auth.FromContext(c)
+
+	folder := c.QueryParam("folder")
+	if _, err := userObjectPath(principal.ID, folder); err != nil {
+		return response.BadRequestResponse(err)
+	}
+
+	reader, err := c.Request().MultipartReader()
+	if err != nil {
+		return response.BadRequestResponse(err)
+	}
+
+	var uploaded []supabase.UploadResult
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return response.BadRequestResponse(err)
+		}
+
+		if part.FileName() == "" {
+			continue
+		}
+
+		result, err := h.uploadPart(c, principal, folder, part)
+		if err != nil {
+			return err
+		}
+
+		uploaded = append(uploaded, *result)
+	}
+
+	if len(uploaded) == 0 {
+		return response.BadRequestResponse(errNoFile)
+	}
+
+	return response.CreatedResponse(c, uploaded)
+}
+
+func (h *StorageHandler) ListHandler(c echo.Context) error {
+	principal := auth.FromContext(c)
+
+	prefix, err := userObjectPath(principal.ID, c.QueryParam("prefix"))
+	if err != nil {
+		return response.BadRequestResponse(err)
+	}
+
+	storage, err := h.userStorage(principal)
+	if err != nil {
+		return err
+	}
+
+	files, storageErr, err := storage.List(c.Request().Context(), h.cfg.Bucket, prefix, supabase.ListOptions{
+		SortBy: &supabase.SortBy{Column: "name", Order: "asc"},
+	})
+	if err != nil {
+		return response.ServerErrorResponse(err)
+	}
+
+	if storageErr != nil {
+		return storageErrorResponse(storageErr)
+	}
+
+	return response.SuccessResponse(c, files)
+}
+
+func (h *StorageHandler) DownloadHandler(c echo.Context) error {
+	principal := auth.FromContext(c)
+
+	objectPath, err := userFilePath(principal.ID, c.QueryParam("path"))
+	if err != nil {
+		return response.BadRequestResponse(err)
+	}
+
+	storage, err := h.userStorage(principal)
+	if err != nil {
+		return err
+	}
+
+	file, storageErr, err := storage.Download(c.Request().Context(), h.cfg.Bucket, objectPath)
+	if err != nil {
+		return response.ServerErrorResponse(err)
+	}
+
+	if storageErr != nil {
+		return storageErrorResponse(storageErr)
+	}
+
+	defer file.Body.Close()
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", path.Base(objectPath)))
+
+	return c.Stream(http.StatusOK, file.ContentType, file.Body)
+}
+
+func (h *StorageHandler) SignedURLHandler(c echo.Context) error {
+	principal := auth.FromContext(c)
+
+	objectPath, err := userFilePath(principal.ID, c.QueryParam("path"))
+	if err != nil {
+		return response.BadRequestResponse(err)
+	}
+
+	storage, err := h.userStorage(principal)
+	if err != nil {
+		return err
+	}
+
+	signedURL, storageErr, err := storage.CreateSignedURL(c.Request().Context(), h.cfg.Bucket, objectPath, h.cfg.SignedURLExpiry)
+	if err != nil {
+		return response.ServerErrorResponse(err)
+	}
+
+	if storageErr != nil {
+		return storageErrorResponse(storageErr)
+	}
+
+	return response.SuccessResponse(c, map[string]any{
+		"url":       signedURL,
+		"expiresIn": h.cfg.SignedURLExpiry,
+	})
+}
+
+// SignedUploadURLHandler lets clients upload directly to storage. Size and
+// type limits for those uploads are enforced by the bucket configuration.
+func (h *StorageHandler) SignedUploadURLHandler(c echo.Context) error {
+	var r request.SignedUploadURLRequest
+
+	err := decode(c.Request().Body, &r)
+	if err != nil {
+		return response.ServerErrorResponse(err)
+	}
+
+	validationErrors := h.validator.Validate(r)
+
+	if len(validationErrors.ValidationErrors) > 0 {
+		return response.ValidationErrorResponse(validationErrors)
+	}
+
+	principal := auth.FromContext(c)
+
+	objectPath, err := userFilePath(principal.ID, r.Path)
+	if err != nil {
+		return response.BadRequestResponse(err)
+	}
+
+	storage, err := h.userStorage(principal)
+	if err != nil {
+		return err
+	}
+
+	signed, storageErr, err := storage.CreateSignedUploadURL(c.Request().Context(), h.cfg.Bucket, objectPath)
+	if err != nil {
+		return response.ServerErrorResponse(err)
+	}
+
+	if storageErr != nil {
+		return storageErrorResponse(storageErr)
+	}
+
+	return response.CreatedResponse(c, signed)
+}
+
+func (h *StorageHandler) MoveHandler(c echo.Context) error {
+	return h.transfer(c, (*supabase.StorageClient).Move)
+}
+
+func (h *StorageHandler) CopyHandler(c echo.Context) error {
+	return h.transfer(c, (*supabase.StorageClient).Copy)
+}
+
+func (h *StorageHandler) DeleteHandler(c echo.Context) error {
+	principal := auth.FromContext(c)
+
+	objectPath, err := userFilePath(principal.ID, c.QueryParam("path"))
+	if err != nil {
+		return response.BadRequestResponse(err)
+	}
+
+	storage, err := h.userStorage(principal)
+	if err != nil {
+		return err
+	}
+
+	_, storageErr, err := storage.Remove(c.Request().Context(), h.cfg.Bucket, []string{objectPath})
+	if err != nil {
+		return response.ServerErrorResponse(err)
+	}
+
+	if storageErr != nil {
+		return storageErrorResponse(storageErr)
+	}
+
+	return response.NoContentResponse(c)
+}
+
+type transferFunc func(s *supabase.StorageClient, ctx context.Context, bucket string, from string, to string) (*supabase.StorageError, error)
+
+func (h *StorageHandler) transfer(c echo.Context, fn transferFunc) error {
+	var r request.MoveFileRequest
+
+	err := decode(c.Request().Body, &r)
+	if err != nil {
+		return response.ServerErrorResponse(err)
+	}
+
+	validationErrors := h.validator.Validate(r)
+
+	if len(validationErrors.ValidationErrors) > 0 {
+		return response.ValidationErrorResponse(validationErrors)
+	}
+
+	principal := auth.FromContext(c)
+
+	from, err := userFilePath(principal.ID, r.From)
+	if err != nil {
+		return response.BadRequestResponse(err)
+	}
+
+	to, err := userFilePath(principal.ID, r.To)
+	if err != nil {
+		return response.BadRequestResponse(err)
+	}
+
+	storage, err := h.userStorage(principal)
+	if err != nil {
+		return err
+	}
+
+	storageErr, err := fn(storage, c.Request().Context(), h.cfg.Bucket, from, to)
+	if err != nil {
+		return response.ServerErrorResponse(err)
+	}
+
+	if storageErr != nil {
+		return storageErrorResponse(storageErr)
+	}
+
+	return response.NoContentResponse(c)
+}
+
+func (h *StorageHandler) uploadPart(c echo.Context, principal *auth.Principal, folder string, part *multipart.Part) (*supabase.UploadResult, error) {
+	objectPath, err := userObjectPath(principal.ID, folder, path.Base(part.FileName()))
+	if err != nil {
+		return nil, response.BadRequestResponse(err)
+	}
+
+	buffered := bufio.NewReaderSize(part, sniffLength)
+	head, _ := buffered.Peek(sniffLength)
+
+	contentType, err := detectContentType(part.Header.Get(echo.HeaderContentType), head)
+	if err != nil {
+		return nil, response.ErrorResponse(http.StatusUnsupportedMediaType, response.Error{
+			Message: fmt.Sprintf("%s: %s", err.Error(), contentType),
+		})
+	}
+
+	if !slices.Contains(h.cfg.AllowedContentTypes, contentType) {
+		return nil, response.ErrorResponse(http.StatusUnsupportedMediaType, response.Error{
+			Message: fmt.Sprintf("%s: %s", errUnsupportedType.Error(), contentType),
+		})
+	}
+
+	body := &sizeLimitedReader{reader: buffered, remaining: h.cfg.MaxUploadSize}
+
+	storage, err := h.userStorage(principal)
+	if err != nil {
+		return nil, err
+	}
+
+	result, storageErr, err := storage.Upload(c.Request().Context(), h.cfg.Bucket, objectPath, body, supabase.UploadOptions{
+		ContentType: contentType,
+	})
+	if body.exceeded {
+		return nil, response.ErrorResponse(http.StatusRequestEntityTooLarge, response.Error{
+			Message: errFileTooLarge.Error(),
+		})
+	}
+
+	if err != nil {
+		return nil, response.ServerErrorResponse(err)
+	}
+
+	if storageErr != nil {
+		return nil, storageErrorResponse(storageErr)
+	}
+
+	return result, nil
+}
+
+// detectContentType sniffs the type from the first bytes of the file, so the
+// client cannot choose which allowed type a file passes as. A declared type
+// other than the generic one must match what was sniffed.
+func detectContentType(declared string, head []byte) (string, error) {
+	sniffed := mediaType(http.DetectContentType(head))
+
+	if declared != "" && mediaType(declared) != "application/octet-stream" && mediaType(declared) != sniffed {
+		return sniffed, errContentTypeMismatch
+	}
+
+	return sniffed, nil
+}
+
+func mediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return contentType
+	}
+
+	return mediaType
+}
+
+func userFilePath(userID string, filePath string) (string, error) {
+	if strings.Trim(filePath, "/") == "" {
+		return "", errInvalidPath
+	}
+
+	return userObjectPath(userID, filePath)
+}
+
+// userObjectPath confines a client supplied path to the user's own folder.
+// Without a user ID there is no folder to confine it to.
+func userObjectPath(userID string, elements ...string) (string, error) {
+	if userID == "" || strings.Contains(userID, "/") || userID == ".." {
+		return "", errInvalidUser
+	}
+
+	for _, element := range elements {
+		for _, segment := range strings.Split(element, "/") {
+			if segment == ".." {
+				return "", errInvalidPath
+			}
+		}
+	}
+
+	cleaned := strings.Trim(path.Join(append([]string{"/"}, elements...)...), "/")
+	if cleaned == "" {
+		return userID, nil
+	}
+
+	return userID + "/" + cleaned, nil
+}
+
+func storageErrorResponse(err *supabase.StorageError) error {
+	return response.ErrorResponse(err.Status(), response.Error{
+		Message: err.Message,
+	})
+}
+
+type sizeLimitedReader struct {
+	reader    io.Reader
+	remaining int64
+	exceeded  bool
+}
+
+func (r *sizeLimitedReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.remaining -= int64(n)
+
+	if r.remaining < 0 {
+		r.exceeded = true
+		return n, errFileTooLarge
+	}
+
+	return n, err
+}
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/auth"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/config"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/supabase"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/validation"
+	"github.com/go-playground/assert/v2"
+	"github.com/labstack/echo/v4"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestUserObjectPath(t *testing.T) {
+	tests := []struct {
+		name        string
+		elements    []string
+		expected    string
+		expectedErr error
+	}{
+		{"Root folder", []string{""}, "123", nil},
+		{"Nested file", []string{"docs", "report.pdf"}, "123/docs/report.pdf", nil},
+		{"Leading slash is ignored", []string{"/docs/"}, "123/docs", nil},
+		{"Parent segments are rejected", []string{"../456/secret.pdf"}, "", errInvalidPath},
+		{"Hidden parent segments are rejected", []string{"docs/../../456"}, "", errInvalidPath},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := userObjectPath("123", tt.elements...)
+
+			assert.Equal(t, result, tt.expected)
+			assert.Equal(t, err, tt.expectedErr)
+		})
+	}
+}
+
+func TestUserObjectPathRequiresUser(t *testing.T) {
+	for _, userID := range []string{"", "..", "123/456"} {
+		result, err := userObjectPath(userID, "docs")
+
+		assert.Equal(t, result, "")
+		assert.Equal(t, err, errInvalidUser)
+	}
+}
+
+func TestUserFilePathRequiresPath(t *testing.T) {
+	result, err := userFilePath("123", "/")
+
+	assert.Equal(t, result, "")
+	assert.Equal(t, err, errInvalidPath)
+}
+
+func TestDetectContentType(t *testing.T) {
+	png := []byte("\x89PNG\x0D\x0A\x1A\x0A")
+
+	tests := []struct {
+		name         string
+		declared     string
+		head         []byte
+		expectedType string
+		expectedErr  error
+	}{
+		{"Matching type", "image/png", png, "image/png", nil},
+		{"Missing type", "", png, "image/png", nil},
+		{"Generic type", "application/octet-stream", png, "image/png", nil},
+		{"Parameters are ignored", "text/plain; charset=utf-8", []byte("hello"), "text/plain", nil},
+		{"Declared type is not trusted", "image/jpeg", png, "image/png", errContentTypeMismatch},
+		{"Script declared as image", "image/png", []byte("<html><script>alert(1)</script>"), "text/html", errContentTypeMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contentType, err := detectContentType(tt.declared, tt.head)
+
+			assert.Equal(t, contentType, tt.expectedType)
+			assert.Equal(t, err, tt.expectedErr)
+		})
+	}
+}
+
+func TestSizeLimitedReader(t *testing.T) {
+	within := &sizeLimitedReader{reader: strings.NewReader("12345"), remaining: 5}
+	data, err := io.ReadAll(within)
+
+	assert.Equal(t, string(data), "12345")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, within.exceeded, false)
+
+	over := &sizeLimitedReader{reader: strings.NewReader("123456"), remaining: 5}
+	_, err = io.ReadAll(over)
+
+	assert.Equal(t, err, errFileTooLarge)
+	assert.Equal(t, over.exceeded, true)
+}
+
+func TestStorageActsAsTheUser(t *testing.T) {
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get(echo.HeaderAuthorization)
+		w.Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		_, _ = w.Write([]byte("[]"))
+	}))
+	defer server.Close()
+
+	cfg := config.Default()
+	cfg.Supabase.JwtSecret = "secret"
+	h := NewStorageHandler(cfg, supabase.NewStorageClient(server.URL, "project-key"), validation.NewValidator())
+
+	list := func(principal *auth.Principal) error {
+		authorization = ""
+		c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/files", nil), httptest.NewRecorder())
+		auth.SetPrincipal(c, principal)
+
+		return h.ListHandler(c)
+	}
+
+	// Users forward their own access token.
+	assert.Equal(t, list(&auth.Principal{Type: auth.PrincipalUser, ID: "123", Token: "user-token"}), nil)
+	assert.Equal(t, authorization, "Bearer user-token")
+
+	// API keys act for their owner.
+	assert.Equal(t, list(&auth.Principal{Type: auth.PrincipalAPIKey, ID: "123"}), nil)
+	claims, err := supabase.ParseAccessToken(strings.TrimPrefix(authorization, "Bearer "), "secret")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, claims.Subject, "123")
+
+	// Services act for no user and never get the project key.
+	err = list(&auth.Principal{Type: auth.PrincipalService, ID: "payments"})
+	assert.Equal(t, err.(*echo.HTTPError).Code, http.StatusForbidden)
+	assert.Equal(t, authorization, "")
+}
@@ -0,0 +1,71 @@
+package hook
+
+import (
+	"context"
+	"errors"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/model"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/supabase"
+	"gorm.io/gorm"
+)
+
+const (
+	TenantClaim = "tenant_id"
+	RoleClaim   = "user_role"
+)
+
+type ClaimsStoreInterface interface {
+	FindByUserID(ctx context.Context, userID string) (*model.UserClaim, error)
+}
+
+type DatabaseClaimsStore struct {
+	db *gorm.DB
+}
+
+func NewDatabaseClaimsStore(db *gorm.DB) *DatabaseClaimsStore {
+	return &DatabaseClaimsStore{db: db}
+}
+
+func (s *DatabaseClaimsStore) FindByUserID(ctx context.Context, userID string) (*model.UserClaim, error) {
+	var claim model.UserClaim
+
+	err := s.db.WithContext(ctx).Where("user_id = ?", userID).First(&claim).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &claim, nil
+}
+
+// ClaimsHook injects the tenant and application role stored for a user into
+// the access token Supabase is about to issue. The Postgres "role" claim is
+// left untouched so row-level security keeps working.
+type ClaimsHook struct {
+	store ClaimsStoreInterface
+}
+
+func NewClaimsHook(store ClaimsStoreInterface) *ClaimsHook {
+	return &ClaimsHook{store: store}
+}
+
+func (h *ClaimsHook) CustomAccessToken(ctx context.Context, input supabase.CustomAccessTokenInput) (*supabase.CustomAccessTokenOutput, error) {
+	claims := input.Claims
+
+	claim, err := h.store.FindByUserID(ctx, input.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if claim != nil {
+		if claim.TenantID != "" {
+			claims.Set(TenantClaim, claim.TenantID)
+		}
+
+		claims.Set(RoleClaim, claim.Role)
+	}
+
+	return &supabase.CustomAccessTokenOutput{Claims: claims}, nil
+}
@@ -0,0 +1,75 @@
+package hook
+
+import (
+	"context"
+	"errors"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/model"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/supabase"
+	"github.com/go-playground/assert/v2"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+type ClaimsStoreMock struct {
+	mock.Mock
+}
+
+func (m *ClaimsStoreMock) FindByUserID(ctx context.Context, userID string) (*model.UserClaim, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(*model.UserClaim), args.Error(1)
+}
+
+func TestClaimsHookCustomAccessToken(t *testing.T) {
+	tests := []struct {
+		name           string
+		claim          *model.UserClaim
+		storeErr       error
+		expectedCustom map[string]interface{}
+		expectedErr    error
+	}{
+		{
+			name:           "Should inject tenant and role",
+			claim:          &model.UserClaim{UserID: "123", TenantID: "tenant", Role: "admin"},
+			expectedCustom: map[string]interface{}{TenantClaim: "tenant", RoleClaim: "admin"},
+		},
+		{
+			name:           "Should inject role without tenant",
+			claim:          &model.UserClaim{UserID: "123", Role: "member"},
+			expectedCustom: map[string]interface{}{RoleClaim: "member"},
+		},
+		{
+			name:           "Should leave claims untouched for unknown user",
+			claim:          nil,
+			expectedCustom: nil,
+		},
+		{
+			name:        "Should return store error",
+			claim:       nil,
+			storeErr:    errors.New("db error"),
+			expectedErr: errors.New("db error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := new(ClaimsStoreMock)
+			store.On("FindByUserID", ctx, "123").Return(tt.claim, tt.storeErr)
+
+			input := supabase.CustomAccessTokenInput{
+				UserID: "123",
+				Claims: supabase.AccessTokenClaims{Subject: "123", Role: "authenticated"},
+			}
+
+			output, err := NewClaimsHook(store).CustomAccessToken(ctx, input)
+
+			assert.Equal(t, err, tt.expectedErr)
+			if tt.expectedErr != nil {
+				return
+			}
+
+			assert.Equal(t, output.Claims.Role, "authenticated")
+			assert.Equal(t, output.Claims.Custom, tt.expectedCustom)
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package hook
+
+import (
+	"context"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/config"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/invite"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/supabase"
+	"net/http"
+	"time"
+)
+
+// RegistrationHook applies the registration mode to every user Supabase Auth
+// creates, so signing up with the public key directly does not bypass it. In
+// invite mode only users carrying an admission of the API are created.
+type RegistrationHook struct {
+	cfg    config.Registration
+	secret string
+	now    func() time.Time
+}
+
+func NewRegistrationHook(cfg config.Registration, secret string) *RegistrationHook {
+	return &RegistrationHook{cfg: cfg, secret: secret, now: time.Now}
+}
+
+func (h *RegistrationHook) BeforeUserCreated(_ context.Context, input supabase.BeforeUserCreatedInput) error {
+	user := input.User
+
+	switch h.cfg.Mode {
+	case "disabled":
+		return supabase.NewHookError(http.StatusForbidden, "registration is disabled")
+	case "domains":
+		if !h.cfg.AllowsDomain(user.Email) {
+			return supabase.NewHookError(http.StatusForbidden, "registration is restricted to approved email domains")
+		}
+	case "invite":
+		admission, _ := user.UserMetadata[invite.AdmissionKey].(string)
+		if !invite.Admitted(h.secret, user.Email, admission, h.now()) {
+			return supabase.NewHookError(http.StatusForbidden, "an invitation code is required")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package hook
+
+import (
+	"context"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/config"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/invite"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/supabase"
+	"github.com/go-playground/assert/v2"
+	"testing"
+	"time"
+)
+
+func TestRegistrationHookBeforeUserCreated(t *testing.T) {
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	admission := invite.Admit("secret", "test@example.com", now.Add(invite.AdmissionLifetime))
+
+	tests := []struct {
+		name     string
+		mode     string
+		email    string
+		metadata map[string]interface{}
+		allowed  bool
+	}{
+		{"Open", "open", "test@example.com", nil, true},
+		{"Disabled", "disabled", "test@example.com", nil, false},
+		{"Allowed domain", "domains", "test@Example.com", nil, true},
+		{"Other domain", "domains", "test@example.org", nil, false},
+		{"Admitted", "invite", "test@example.com", map[string]interface{}{invite.AdmissionKey: admission}, true},
+		{"Admitted for another email", "invite", "other@example.com", map[string]interface{}{invite.AdmissionKey: admission}, false},
+		{"Without admission", "invite", "test@example.com", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewRegistrationHook(config.Registration{Mode: tt.mode, AllowedDomains: []string{"example.com"}}, "secret")
+			h.now = func() time.Time { return now }
+
+			err := h.BeforeUserCreated(context.Background(), supabase.BeforeUserCreatedInput{
+				User: supabase.User{Email: tt.email, UserMetadata: tt.metadata},
+			})
+
+			assert.Equal(t, err == nil, tt.allowed)
+		})
+	}
+}
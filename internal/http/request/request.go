@@ -1,23 +1,66 @@
 package request
 
+import "time"
+
+// RegisterRequest carries an InvitationCode when registration is invite
+// only.
 type RegisterRequest struct {
-	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required"`
+	Email          string `json:"email" validate:"required,email"`
+	Password       string `json:"password" validate:"required,password"`
+	InvitationCode string `json:"invitationCode"`
 }
 
+// LoginRequest sets Session to keep the tokens server side behind a session
+// cookie, when sessions are enabled.
 type LoginRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required"`
+	Session  bool   `json:"session"`
 }
 
 type ForgottenPasswordRequest struct {
 	Email string `json:"email" validate:"required,email"`
 }
 
+type ResendConfirmationRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ResetPasswordRequest takes the email from the recovery token, for the
+// password policy.
 type ResetPasswordRequest struct {
-	Password string `json:"password" validate:"required"`
+	Email    string `json:"-"`
+	Password string `json:"password" validate:"required,password"`
 }
 
 type RefreshTokenRequest struct {
 	RefreshToken string `json:"refreshToken" validate:"required"`
 }
+
+type MoveFileRequest struct {
+	From string `json:"from" validate:"required"`
+	To   string `json:"to" validate:"required"`
+}
+
+type SignedUploadURLRequest struct {
+	Path string `json:"path" validate:"required"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" validate:"required,max=255"`
+	Scopes    []string   `json:"scopes" validate:"dive,required"`
+	ExpiresAt *time.Time `json:"expiresAt" validate:"omitempty,gt"`
+}
+
+// CreateInvitationRequest defaults to the configured usage limit and
+// lifetime. An empty Email lets anyone use the invitation.
+type CreateInvitationRequest struct {
+	Email     string     `json:"email" validate:"omitempty,email"`
+	MaxUses   int        `json:"maxUses" validate:"min=0"`
+	ExpiresAt *time.Time `json:"expiresAt" validate:"omitempty,gt"`
+}
+
+type UpdateAPIKeyRequest struct {
+	Name   string   `json:"name" validate:"required,max=255"`
+	Scopes []string `json:"scopes" validate:"dive,required"`
+}
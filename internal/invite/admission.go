@@ -0,0 +1,49 @@
+package invite
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AdmissionKey is the user metadata key of the admission the API passes on
+// to Supabase Auth with signups it admitted, for the before-user-created hook
+// to tell them from signups made with the public key directly.
+const AdmissionKey = "invitation_admission"
+
+// AdmissionLifetime only needs to cover the signup request itself.
+const AdmissionLifetime = 5 * time.Minute
+
+// Admit returns an admission of the email until expires, signed with the
+// secret.
+func Admit(secret string, email string, expires time.Time) string {
+	expiresAt := strconv.FormatInt(expires.Unix(), 10)
+
+	return expiresAt + "." + admissionSignature(secret, email, expiresAt)
+}
+
+// Admitted reports whether the admission was issued for the email with the
+// secret, and has not expired.
+func Admitted(secret string, email string, admission string, now time.Time) bool {
+	expiresAt, signature, ok := strings.Cut(admission, ".")
+	if !ok {
+		return false
+	}
+
+	expires, err := strconv.ParseInt(expiresAt, 10, 64)
+	if err != nil || !now.Before(time.Unix(expires, 0)) {
+		return false
+	}
+
+	return hmac.Equal([]byte(signature), []byte(admissionSignature(secret, email, expiresAt)))
+}
+
+func admissionSignature(secret string, email string, expiresAt string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("invitation_admission\n" + strings.ToLower(strings.TrimSpace(email)) + "\n" + expiresAt))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,34 @@
+package invite
+
+import (
+	"github.com/go-playground/assert/v2"
+	"testing"
+	"time"
+)
+
+func TestAdmitted(t *testing.T) {
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	admission := Admit("secret", "Test@Example.com", now.Add(AdmissionLifetime))
+
+	tests := []struct {
+		name      string
+		secret    string
+		email     string
+		admission string
+		now       time.Time
+		expected  bool
+	}{
+		{"Valid", "secret", "test@example.com", admission, now, true},
+		{"Other email", "secret", "other@example.com", admission, now, false},
+		{"Other secret", "other", "test@example.com", admission, now, false},
+		{"Expired", "secret", "test@example.com", admission, now.Add(AdmissionLifetime), false},
+		{"Forged expiry", "secret", "test@example.com", "9999999999" + admission[len("1893456300"):], now, false},
+		{"Malformed", "secret", "test@example.com", "admitted", now, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, Admitted(tt.secret, tt.email, tt.admission, tt.now), tt.expected)
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package invite
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/model"
+	"strings"
+	"time"
+)
+
+const codeLength = 10
+
+var ErrInvalidInvitation = errors.New("invitation code is invalid or has expired")
+
+// Generate returns a new code, grouped as XXXX-XXXX-XXXX-XXXX for reading it
+// out, and its hash to store. The code itself is only shown once.
+func Generate() (code string, hash string, err error) {
+	raw := make([]byte, codeLength)
+	if _, err = rand.Read(raw); err != nil {
+		return "", "", err
+	}
+
+	encoded := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(raw)
+
+	groups := make([]string, 0, len(encoded)/4)
+	for i := 0; i < len(encoded); i += 4 {
+		groups = append(groups, encoded[i:i+4])
+	}
+
+	code = strings.Join(groups, "-")
+
+	return code, Hash(code), nil
+}
+
+// Hash returns the hex encoded SHA-256 of the code, ignoring case, dashes and
+// spaces.
+func Hash(code string) string {
+	normalized := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// Redeem uses up one use of the invitation of the code, for the email. It
+// returns ErrInvalidInvitation when the code is unknown, revoked, expired,
+// used up or issued to another address.
+func Redeem(ctx context.Context, store Store, code string, email string, now time.Time) (*model.Invitation, error) {
+	invitation, err := store.FindByCodeHash(ctx, Hash(code))
+	if err != nil {
+		return nil, err
+	}
+
+	if invitation == nil || (invitation.Email != "" && !strings.EqualFold(invitation.Email, strings.TrimSpace(email))) {
+		return nil, ErrInvalidInvitation
+	}
+
+	consumed, err := store.Consume(ctx, invitation.ID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if !consumed {
+		return nil, ErrInvalidInvitation
+	}
+
+	return invitation, nil
+}
@@ -0,0 +1,129 @@
+package invite
+
+import (
+	"context"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/model"
+	"github.com/go-playground/assert/v2"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestGenerate(t *testing.T) {
+	code, hash, err := Generate()
+
+	assert.Equal(t, err, nil)
+	assert.MatchRegex(t, code, regexp.MustCompile(`^[A-Z2-7]{4}(-[A-Z2-7]{4}){3}$`))
+	assert.Equal(t, hash, Hash(code))
+
+	other, _, _ := Generate()
+	assert.NotEqual(t, code, other)
+}
+
+func TestHashNormalizes(t *testing.T) {
+	assert.Equal(t, Hash("abcd-efgh ijkl-mnop"), Hash("ABCDEFGHIJKLMNOP"))
+	assert.NotEqual(t, Hash("ABCD-EFGH-IJKL-MNOP"), Hash("ABCD-EFGH-IJKL-MNOQ"))
+}
+
+func TestRedeem(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	revokedAt := now.Add(-time.Minute)
+
+	tests := []struct {
+		name        string
+		invitation  model.Invitation
+		code        string
+		email       string
+		expectedErr error
+	}{
+		{
+			name:       "Valid code",
+			invitation: model.Invitation{MaxUses: 1, ExpiresAt: now.Add(time.Hour)},
+			code:       "AAAA-BBBB",
+			email:      "test@example.com",
+		},
+		{
+			name:       "Normalized code and matching email",
+			invitation: model.Invitation{Email: "test@example.com", MaxUses: 1, ExpiresAt: now.Add(time.Hour)},
+			code:       "aaaa bbbb",
+			email:      "Test@Example.com",
+		},
+		{
+			name:        "Unknown code",
+			invitation:  model.Invitation{MaxUses: 1, ExpiresAt: now.Add(time.Hour)},
+			code:        "CCCC-DDDD",
+			email:       "test@example.com",
+			expectedErr: ErrInvalidInvitation,
+		},
+		{
+			name:        "Other email",
+			invitation:  model.Invitation{Email: "other@example.com", MaxUses: 1, ExpiresAt: now.Add(time.Hour)},
+			code:        "AAAA-BBBB",
+			email:       "test@example.com",
+			expectedErr: ErrInvalidInvitation,
+		},
+		{
+			name:        "Expired",
+			invitation:  model.Invitation{MaxUses: 1, ExpiresAt: now},
+			code:        "AAAA-BBBB",
+			email:       "test@example.com",
+			expectedErr: ErrInvalidInvitation,
+		},
+		{
+			name:        "Used up",
+			invitation:  model.Invitation{MaxUses: 2, Uses: 2, ExpiresAt: now.Add(time.Hour)},
+			code:        "AAAA-BBBB",
+			email:       "test@example.com",
+			expectedErr: ErrInvalidInvitation,
+		},
+		{
+			name:        "Revoked",
+			invitation:  model.Invitation{MaxUses: 1, ExpiresAt: now.Add(time.Hour), RevokedAt: &revokedAt},
+			code:        "AAAA-BBBB",
+			email:       "test@example.com",
+			expectedErr: ErrInvalidInvitation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryStore()
+			invitation := tt.invitation
+			invitation.CodeHash = Hash("AAAA-BBBB")
+			_ = store.Create(ctx, &invitation)
+
+			redeemed, err := Redeem(ctx, store, tt.code, tt.email, now)
+			assert.Equal(t, err, tt.expectedErr)
+
+			stored, _ := store.Find(ctx, invitation.ID)
+			if tt.expectedErr == nil {
+				assert.Equal(t, redeemed.ID, invitation.ID)
+				assert.Equal(t, stored.Uses, invitation.Uses+1)
+			} else {
+				assert.Equal(t, stored.Uses, invitation.Uses)
+			}
+		})
+	}
+}
+
+func TestRedeemUsageLimit(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store := NewMemoryStore()
+	invitation := &model.Invitation{CodeHash: Hash("AAAA-BBBB"), MaxUses: 2, ExpiresAt: now.Add(time.Hour)}
+	_ = store.Create(ctx, invitation)
+
+	for range 2 {
+		_, err := Redeem(ctx, store, "AAAA-BBBB", "test@example.com", now)
+		assert.Equal(t, err, nil)
+	}
+
+	_, err := Redeem(ctx, store, "AAAA-BBBB", "test@example.com", now)
+	assert.Equal(t, err, ErrInvalidInvitation)
+
+	_ = store.Release(ctx, invitation.ID)
+
+	_, err = Redeem(ctx, store, "AAAA-BBBB", "test@example.com", now)
+	assert.Equal(t, err, nil)
+}
@@ -0,0 +1,191 @@
+package invite
+
+import (
+	"context"
+	"errors"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/model"
+	"gorm.io/gorm"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Store persists invitations. Lookups return nil without an error when no
+// invitation matches.
+type Store interface {
+	Create(ctx context.Context, invitation *model.Invitation) error
+	Update(ctx context.Context, invitation *model.Invitation) error
+	Find(ctx context.Context, id uint) (*model.Invitation, error)
+	FindByCodeHash(ctx context.Context, hash string) (*model.Invitation, error)
+	List(ctx context.Context) ([]model.Invitation, error)
+	// Consume records a use of the invitation, unless it is revoked, expired
+	// at the given time or used up, and reports whether it did.
+	Consume(ctx context.Context, id uint, at time.Time) (bool, error)
+	// Release gives back a use, when the registration it was consumed for
+	// failed.
+	Release(ctx context.Context, id uint) error
+}
+
+type DatabaseStore struct {
+	db *gorm.DB
+}
+
+func NewDatabaseStore(db *gorm.DB) *DatabaseStore {
+	return &DatabaseStore{db: db}
+}
+
+func (s *DatabaseStore) Create(ctx context.Context, invitation *model.Invitation) error {
+	return s.db.WithContext(ctx).Create(invitation).Error
+}
+
+func (s *DatabaseStore) Update(ctx context.Context, invitation *model.Invitation) error {
+	return s.db.WithContext(ctx).Save(invitation).Error
+}
+
+func (s *DatabaseStore) Find(ctx context.Context, id uint) (*model.Invitation, error) {
+	return s.first(s.db.WithContext(ctx).Where("id = ?", id))
+}
+
+func (s *DatabaseStore) FindByCodeHash(ctx context.Context, hash string) (*model.Invitation, error) {
+	return s.first(s.db.WithContext(ctx).Where("code_hash = ?", hash))
+}
+
+func (s *DatabaseStore) List(ctx context.Context) ([]model.Invitation, error) {
+	var invitations []model.Invitation
+
+	err := s.db.WithContext(ctx).Order("id").Find(&invitations).Error
+
+	return invitations, err
+}
+
+// Consume checks and counts the use in a single statement, so concurrent
+// registrations never exceed the limit.
+func (s *DatabaseStore) Consume(ctx context.Context, id uint, at time.Time) (bool, error) {
+	result := s.db.WithContext(ctx).Model(&model.Invitation{}).
+		Where("id = ? AND uses < max_uses AND revoked_at IS NULL AND expires_at > ?", id, at).
+		UpdateColumn("uses", gorm.Expr("uses + 1"))
+
+	return result.RowsAffected == 1, result.Error
+}
+
+func (s *DatabaseStore) Release(ctx context.Context, id uint) error {
+	return s.db.WithContext(ctx).Model(&model.Invitation{}).
+		Where("id = ? AND uses > 0", id).
+		UpdateColumn("uses", gorm.Expr("uses - 1")).Error
+}
+
+func (s *DatabaseStore) first(query *gorm.DB) (*model.Invitation, error) {
+	var invitation model.Invitation
+
+	err := query.First(&invitation).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &invitation, nil
+}
+
+// MemoryStore keeps invitations in the current process. It is meant for
+// tests.
+type MemoryStore struct {
+	mu          sync.Mutex
+	nextID      uint
+	invitations map[uint]model.Invitation
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{invitations: map[uint]model.Invitation{}}
+}
+
+func (s *MemoryStore) Create(_ context.Context, invitation *model.Invitation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	invitation.ID = s.nextID
+	invitation.CreatedAt = time.Now()
+	invitation.UpdatedAt = invitation.CreatedAt
+	s.invitations[invitation.ID] = *invitation
+
+	return nil
+}
+
+func (s *MemoryStore) Update(_ context.Context, invitation *model.Invitation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	invitation.UpdatedAt = time.Now()
+	s.invitations[invitation.ID] = *invitation
+
+	return nil
+}
+
+func (s *MemoryStore) Find(_ context.Context, id uint) (*model.Invitation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	invitation, ok := s.invitations[id]
+	if !ok {
+		return nil, nil
+	}
+
+	return &invitation, nil
+}
+
+func (s *MemoryStore) FindByCodeHash(_ context.Context, hash string) (*model.Invitation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, invitation := range s.invitations {
+		if invitation.CodeHash == hash {
+			return &invitation, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (s *MemoryStore) List(_ context.Context) ([]model.Invitation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	invitations := make([]model.Invitation, 0, len(s.invitations))
+	for _, invitation := range s.invitations {
+		invitations = append(invitations, invitation)
+	}
+
+	sort.Slice(invitations, func(i, j int) bool { return invitations[i].ID < invitations[j].ID })
+
+	return invitations, nil
+}
+
+func (s *MemoryStore) Consume(_ context.Context, id uint, at time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	invitation, ok := s.invitations[id]
+	if !ok || invitation.Uses >= invitation.MaxUses || invitation.RevokedAt != nil || !at.Before(invitation.ExpiresAt) {
+		return false, nil
+	}
+
+	invitation.Uses++
+	s.invitations[id] = invitation
+
+	return true, nil
+}
+
+func (s *MemoryStore) Release(_ context.Context, id uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if invitation, ok := s.invitations[id]; ok && invitation.Uses > 0 {
+		invitation.Uses--
+		s.invitations[id] = invitation
+	}
+
+	return nil
+}
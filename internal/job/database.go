@@ -0,0 +1,119 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// DatabaseStore keeps jobs in the jobs table so they survive restarts and are
+// shared between replicas.
+type DatabaseStore struct {
+	db         *gorm.DB
+	skipLocked bool
+}
+
+func NewDatabaseStore(db *gorm.DB) *DatabaseStore {
+	return &DatabaseStore{
+		db:         db,
+		skipLocked: supportsSkipLocked(db.Dialector.Name()),
+	}
+}
+
+func (s *DatabaseStore) Push(ctx context.Context, job *model.Job) error {
+	return s.db.WithContext(ctx).Create(job).Error
+}
+
+// Reserve claims the oldest available job. Where the database supports it,
+// rows locked by other workers are skipped instead of waited on; the
+// conditional update guards against double reservation everywhere else.
+func (s *DatabaseStore) Reserve(ctx context.Context, now time.Time, reserveTimeout time.Duration) (*model.Job, error) {
+	var reserved *model.Job
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		query := tx.Where("status = ? AND available_at <= ?", model.JobPending, now)
+		if reserveTimeout > 0 {
+			query = query.Or("status = ? AND reserved_at <= ?", model.JobRunning, now.Add(-reserveTimeout))
+		}
+
+		query = query.Order("available_at, id")
+		if s.skipLocked {
+			query = query.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+		}
+
+		var job model.Job
+		err := query.First(&job).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		result := tx.Model(&model.Job{}).
+			Where("id = ? AND status = ? AND attempts = ?", job.ID, job.Status, job.Attempts).
+			Updates(map[string]any{
+				"status":      model.JobRunning,
+				"attempts":    job.Attempts + 1,
+				"reserved_at": now,
+			})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		job.Status = model.JobRunning
+		job.Attempts++
+		job.ReservedAt = &now
+		reserved = &job
+
+		return nil
+	})
+
+	return reserved, err
+}
+
+func (s *DatabaseStore) Complete(ctx context.Context, job *model.Job) error {
+	return s.update(ctx, job, map[string]any{
+		"status":      model.JobCompleted,
+		"reserved_at": nil,
+	})
+}
+
+func (s *DatabaseStore) Retry(ctx context.Context, job *model.Job, availableAt time.Time, cause error) error {
+	return s.update(ctx, job, map[string]any{
+		"status":       model.JobPending,
+		"available_at": availableAt,
+		"reserved_at":  nil,
+		"last_error":   cause.Error(),
+	})
+}
+
+func (s *DatabaseStore) Bury(ctx context.Context, job *model.Job, cause error) error {
+	return s.update(ctx, job, map[string]any{
+		"status":      model.JobDead,
+		"reserved_at": nil,
+		"last_error":  cause.Error(),
+	})
+}
+
+// Purge deletes completed jobs last updated before the given time. Dead jobs
+// are kept for inspection.
+func (s *DatabaseStore) Purge(ctx context.Context, before time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).
+		Where("status = ? AND updated_at < ?", model.JobCompleted, before).
+		Delete(&model.Job{})
+
+	return result.RowsAffected, result.Error
+}
+
+func (s *DatabaseStore) update(ctx context.Context, job *model.Job, values map[string]any) error {
+	return s.db.WithContext(ctx).Model(&model.Job{}).Where("id = ?", job.ID).Updates(values).Error
+}
+
+func supportsSkipLocked(dialect string) bool {
+	return dialect == "mysql" || dialect == "postgres"
+}
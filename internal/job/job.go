@@ -0,0 +1,122 @@
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/model"
+	"sync"
+	"time"
+)
+
+// HandlerFunc processes the raw JSON payload of a job.
+type HandlerFunc func(ctx context.Context, payload []byte) error
+
+// Registry maps job names to their handlers.
+type Registry struct {
+	mu       sync.RWMutex
+	handlers map[string]HandlerFunc
+}
+
+func NewRegistry() *Registry {
+	return &Registry{handlers: map[string]HandlerFunc{}}
+}
+
+func (r *Registry) Register(name string, handler HandlerFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.handlers[name] = handler
+}
+
+func (r *Registry) Handler(name string) (HandlerFunc, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	handler, ok := r.handlers[name]
+
+	return handler, ok
+}
+
+// Definition ties a job name to its payload type, so registration and
+// enqueueing are checked by the compiler.
+type Definition[T any] struct {
+	Name string
+}
+
+func Define[T any](name string) Definition[T] {
+	return Definition[T]{Name: name}
+}
+
+func (d Definition[T]) Register(registry *Registry, handler func(ctx context.Context, payload T) error) {
+	registry.Register(d.Name, func(ctx context.Context, data []byte) error {
+		var payload T
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return fmt.Errorf("job %s: invalid payload: %w", d.Name, err)
+		}
+
+		return handler(ctx, payload)
+	})
+}
+
+func (d Definition[T]) Enqueue(ctx context.Context, queue QueueInterface, payload T, options ...EnqueueOption) error {
+	return queue.Push(ctx, d.Name, payload, options...)
+}
+
+type enqueueOptions struct {
+	delay       time.Duration
+	maxAttempts int
+}
+
+type EnqueueOption func(*enqueueOptions)
+
+// WithDelay postpones the first attempt of the job.
+func WithDelay(delay time.Duration) EnqueueOption {
+	return func(o *enqueueOptions) {
+		o.delay = delay
+	}
+}
+
+// WithMaxAttempts overrides the configured number of attempts for the job.
+func WithMaxAttempts(attempts int) EnqueueOption {
+	return func(o *enqueueOptions) {
+		o.maxAttempts = attempts
+	}
+}
+
+type QueueInterface interface {
+	Push(ctx context.Context, name string, payload any, options ...EnqueueOption) error
+}
+
+type Queue struct {
+	store       Store
+	maxAttempts int
+}
+
+func NewQueue(store Store, maxAttempts int) *Queue {
+	if maxAttempts <= 0 {
+		maxAttempts = 1
+	}
+
+	return &Queue{store: store, maxAttempts: maxAttempts}
+}
+
+func (q *Queue) Push(ctx context.Context, name string, payload any, options ...EnqueueOption) error {
+	opts := enqueueOptions{maxAttempts: q.maxAttempts}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	return q.store.Push(ctx, &model.Job{
+		Name:        name,
+		Payload:     string(data),
+		Status:      model.JobPending,
+		MaxAttempts: opts.maxAttempts,
+		AvailableAt: time.Now().Add(opts.delay),
+	})
+}
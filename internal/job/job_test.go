@@ -0,0 +1,66 @@
+package job
+
+import (
+	"context"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/model"
+	"github.com/go-playground/assert/v2"
+	"testing"
+	"time"
+)
+
+type greeting struct {
+	Name string `json:"name"`
+}
+
+var greetJob = Define[greeting]("greet")
+
+func TestDefinitionRoundTrip(t *testing.T) {
+	store := NewMemoryStore()
+	queue := NewQueue(store, 3)
+	registry := NewRegistry()
+
+	var received greeting
+	greetJob.Register(registry, func(_ context.Context, payload greeting) error {
+		received = payload
+		return nil
+	})
+
+	err := greetJob.Enqueue(context.Background(), queue, greeting{Name: "Ada"})
+	assert.Equal(t, err, nil)
+
+	jobs := store.Jobs()
+	assert.Equal(t, len(jobs), 1)
+	assert.Equal(t, jobs[0].Name, "greet")
+	assert.Equal(t, jobs[0].Payload, `{"name":"Ada"}`)
+	assert.Equal(t, jobs[0].Status, model.JobPending)
+	assert.Equal(t, jobs[0].MaxAttempts, 3)
+
+	handler, ok := registry.Handler("greet")
+	assert.Equal(t, ok, true)
+
+	err = handler(context.Background(), []byte(jobs[0].Payload))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, received.Name, "Ada")
+}
+
+func TestDefinitionRejectsInvalidPayload(t *testing.T) {
+	registry := NewRegistry()
+	greetJob.Register(registry, func(context.Context, greeting) error { return nil })
+
+	handler, _ := registry.Handler("greet")
+	err := handler(context.Background(), []byte(`"not an object"`))
+	assert.NotEqual(t, err, nil)
+}
+
+func TestQueueOptions(t *testing.T) {
+	store := NewMemoryStore()
+	queue := NewQueue(store, 3)
+
+	before := time.Now()
+	err := queue.Push(context.Background(), "greet", greeting{}, WithDelay(time.Hour), WithMaxAttempts(1))
+	assert.Equal(t, err, nil)
+
+	job := store.Jobs()[0]
+	assert.Equal(t, job.MaxAttempts, 1)
+	assert.Equal(t, job.AvailableAt.After(before.Add(59*time.Minute)), true)
+}
@@ -0,0 +1,163 @@
+package job
+
+import (
+	"context"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/model"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	DriverDatabase = "database"
+	DriverMemory   = "memory"
+)
+
+// Store persists jobs. Reserve must hand a job to at most one worker, and
+// returns nil when no job is available.
+type Store interface {
+	Push(ctx context.Context, job *model.Job) error
+	Reserve(ctx context.Context, now time.Time, reserveTimeout time.Duration) (*model.Job, error)
+	Complete(ctx context.Context, job *model.Job) error
+	Retry(ctx context.Context, job *model.Job, availableAt time.Time, cause error) error
+	Bury(ctx context.Context, job *model.Job, cause error) error
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
+
+// MemoryStore keeps jobs in process. Jobs are lost on restart, so it is meant
+// for tests and local development.
+type MemoryStore struct {
+	mu     sync.Mutex
+	nextID uint
+	jobs   map[uint]*model.Job
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{jobs: map[uint]*model.Job{}}
+}
+
+func (s *MemoryStore) Push(_ context.Context, job *model.Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	job.ID = s.nextID
+	job.CreatedAt = time.Now()
+	job.UpdatedAt = job.CreatedAt
+
+	stored := *job
+	s.jobs[job.ID] = &stored
+
+	return nil
+}
+
+func (s *MemoryStore) Reserve(_ context.Context, now time.Time, reserveTimeout time.Duration) (*model.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var candidates []*model.Job
+	for _, job := range s.jobs {
+		if isReservable(job, now, reserveTimeout) {
+			candidates = append(candidates, job)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].AvailableAt.Equal(candidates[j].AvailableAt) {
+			return candidates[i].ID < candidates[j].ID
+		}
+
+		return candidates[i].AvailableAt.Before(candidates[j].AvailableAt)
+	})
+
+	job := candidates[0]
+	job.Status = model.JobRunning
+	job.Attempts++
+	job.ReservedAt = &now
+	job.UpdatedAt = now
+
+	reserved := *job
+
+	return &reserved, nil
+}
+
+func (s *MemoryStore) Complete(_ context.Context, job *model.Job) error {
+	return s.update(job.ID, func(stored *model.Job) {
+		stored.Status = model.JobCompleted
+		stored.ReservedAt = nil
+	})
+}
+
+func (s *MemoryStore) Retry(_ context.Context, job *model.Job, availableAt time.Time, cause error) error {
+	return s.update(job.ID, func(stored *model.Job) {
+		stored.Status = model.JobPending
+		stored.AvailableAt = availableAt
+		stored.ReservedAt = nil
+		stored.LastError = cause.Error()
+	})
+}
+
+func (s *MemoryStore) Bury(_ context.Context, job *model.Job, cause error) error {
+	return s.update(job.ID, func(stored *model.Job) {
+		stored.Status = model.JobDead
+		stored.ReservedAt = nil
+		stored.LastError = cause.Error()
+	})
+}
+
+func (s *MemoryStore) Purge(_ context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var purged int64
+	for id, job := range s.jobs {
+		if job.Status == model.JobCompleted && job.UpdatedAt.Before(before) {
+			delete(s.jobs, id)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+// Jobs returns a snapshot of every job, ordered by ID.
+func (s *MemoryStore) Jobs() []model.Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs := make([]model.Job, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, *job)
+	}
+
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].ID < jobs[j].ID })
+
+	return jobs
+}
+
+func (s *MemoryStore) update(id uint, fn func(job *model.Job)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if job, ok := s.jobs[id]; ok {
+		fn(job)
+		job.UpdatedAt = time.Now()
+	}
+
+	return nil
+}
+
+func isReservable(job *model.Job, now time.Time, reserveTimeout time.Duration) bool {
+	switch job.Status {
+	case model.JobPending:
+		return !job.AvailableAt.After(now)
+	case model.JobRunning:
+		return reserveTimeout > 0 && job.ReservedAt != nil && !job.ReservedAt.After(now.Add(-reserveTimeout))
+	default:
+		return false
+	}
+}
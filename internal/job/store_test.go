@@ -0,0 +1,87 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/model"
+	"github.com/go-playground/assert/v2"
+	"testing"
+	"time"
+)
+
+func pushJob(t *testing.T, store Store, name string, availableAt time.Time) {
+	t.Helper()
+
+	err := store.Push(context.Background(), &model.Job{
+		Name:        name,
+		Payload:     "{}",
+		Status:      model.JobPending,
+		MaxAttempts: 3,
+		AvailableAt: availableAt,
+	})
+	assert.Equal(t, err, nil)
+}
+
+func TestMemoryStoreReservesOldestAvailableJob(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Now()
+
+	pushJob(t, store, "later", now.Add(time.Minute))
+	pushJob(t, store, "second", now.Add(-time.Second))
+	pushJob(t, store, "first", now.Add(-time.Minute))
+
+	job, err := store.Reserve(context.Background(), now, 0)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, job.Name, "first")
+	assert.Equal(t, job.Status, model.JobRunning)
+	assert.Equal(t, job.Attempts, 1)
+
+	job, _ = store.Reserve(context.Background(), now, 0)
+	assert.Equal(t, job.Name, "second")
+
+	job, err = store.Reserve(context.Background(), now, 0)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, job, (*model.Job)(nil))
+}
+
+func TestMemoryStoreReclaimsStaleReservations(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Now()
+
+	pushJob(t, store, "stuck", now)
+
+	_, _ = store.Reserve(context.Background(), now, time.Minute)
+
+	job, _ := store.Reserve(context.Background(), now.Add(30*time.Second), time.Minute)
+	assert.Equal(t, job, (*model.Job)(nil))
+
+	job, _ = store.Reserve(context.Background(), now.Add(2*time.Minute), time.Minute)
+	assert.Equal(t, job.Name, "stuck")
+	assert.Equal(t, job.Attempts, 2)
+}
+
+func TestMemoryStoreRetryAndBury(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Now()
+
+	pushJob(t, store, "flaky", now)
+
+	job, _ := store.Reserve(context.Background(), now, 0)
+	err := store.Retry(context.Background(), job, now.Add(time.Minute), errors.New("boom"))
+	assert.Equal(t, err, nil)
+
+	stored := store.Jobs()[0]
+	assert.Equal(t, stored.Status, model.JobPending)
+	assert.Equal(t, stored.LastError, "boom")
+
+	job, _ = store.Reserve(context.Background(), now, 0)
+	assert.Equal(t, job, (*model.Job)(nil))
+
+	job, _ = store.Reserve(context.Background(), now.Add(time.Minute), 0)
+	err = store.Bury(context.Background(), job, errors.New("gave up"))
+	assert.Equal(t, err, nil)
+
+	stored = store.Jobs()[0]
+	assert.Equal(t, stored.Status, model.JobDead)
+	assert.Equal(t, stored.LastError, "gave up")
+}
@@ -0,0 +1,171 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/log"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/model"
+	"sync"
+	"time"
+)
+
+var ErrUnknownJob = errors.New("no handler registered for job")
+
+type WorkerOptions struct {
+	Concurrency    int
+	PollInterval   time.Duration
+	RetryBackoff   time.Duration
+	MaxBackoff     time.Duration
+	ReserveTimeout time.Duration
+}
+
+// Worker polls the store and runs jobs concurrently. Failed jobs are retried
+// with exponential backoff until they run out of attempts, at which point
+// they are moved to the dead state.
+type Worker struct {
+	store    Store
+	registry *Registry
+	log      log.LoggerInterface
+	options  WorkerOptions
+	now      func() time.Time
+
+	stop    chan struct{}
+	cancel  context.CancelFunc
+	ctx     context.Context
+	wg      sync.WaitGroup
+	started sync.Once
+	stopped sync.Once
+}
+
+func NewWorker(store Store, registry *Registry, log log.LoggerInterface, options WorkerOptions) *Worker {
+	if options.Concurrency <= 0 {
+		options.Concurrency = 1
+	}
+
+	if options.PollInterval <= 0 {
+		options.PollInterval = time.Second
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Worker{
+		store:    store,
+		registry: registry,
+		log:      log,
+		options:  options,
+		now:      time.Now,
+		stop:     make(chan struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+func (w *Worker) Start() {
+	w.started.Do(func() {
+		for i := 0; i < w.options.Concurrency; i++ {
+			w.wg.Add(1)
+			go w.run()
+		}
+	})
+}
+
+// Shutdown stops reserving new jobs and waits for running ones to finish.
+// Jobs still running when ctx expires are cancelled and will be retried.
+func (w *Worker) Shutdown(ctx context.Context) error {
+	w.stopped.Do(func() { close(w.stop) })
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		w.cancel()
+		return nil
+	case <-ctx.Done():
+		w.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (w *Worker) run() {
+	defer w.wg.Done()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		default:
+		}
+
+		processed, err := w.processNext()
+		if err != nil {
+			w.log.Error("Job reservation error", "error", err)
+		}
+
+		if processed {
+			continue
+		}
+
+		select {
+		case <-w.stop:
+			return
+		case <-time.After(w.options.PollInterval):
+		}
+	}
+}
+
+// processNext runs a single job and reports whether one was available.
+func (w *Worker) processNext() (bool, error) {
+	job, err := w.store.Reserve(w.ctx, w.now(), w.options.ReserveTimeout)
+	if err != nil || job == nil {
+		return false, err
+	}
+
+	err = w.execute(job)
+
+	switch {
+	case err == nil:
+		err = w.store.Complete(context.Background(), job)
+	case errors.Is(err, ErrUnknownJob) || job.Attempts >= job.MaxAttempts:
+		w.log.Error("Job failed permanently", "job", job.Name, "id", job.ID, "attempts", job.Attempts, "error", err)
+		err = w.store.Bury(context.Background(), job, err)
+	default:
+		retryAt := w.now().Add(w.backoff(job.Attempts))
+		w.log.Warn("Job failed, retrying", "job", job.Name, "id", job.ID, "attempts", job.Attempts, "retryAt", retryAt, "error", err)
+		err = w.store.Retry(context.Background(), job, retryAt, err)
+	}
+
+	return true, err
+}
+
+func (w *Worker) execute(job *model.Job) (err error) {
+	handler, ok := w.registry.Handler(job.Name)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownJob, job.Name)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+
+	return handler(w.ctx, []byte(job.Payload))
+}
+
+func (w *Worker) backoff(attempts int) time.Duration {
+	delay := w.options.RetryBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if w.options.MaxBackoff > 0 && delay >= w.options.MaxBackoff {
+			return w.options.MaxBackoff
+		}
+	}
+
+	return delay
+}
@@ -0,0 +1,153 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/model"
+	"github.com/go-playground/assert/v2"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError + 1}))
+}
+
+func newTestWorker(store Store, registry *Registry) *Worker {
+	return NewWorker(store, registry, testLogger(), WorkerOptions{
+		PollInterval: time.Millisecond,
+		RetryBackoff: time.Second,
+		MaxBackoff:   5 * time.Second,
+	})
+}
+
+func TestWorkerCompletesJob(t *testing.T) {
+	store := NewMemoryStore()
+	registry := NewRegistry()
+	registry.Register("ok", func(context.Context, []byte) error { return nil })
+	pushJob(t, store, "ok", time.Now())
+
+	worker := newTestWorker(store, registry)
+
+	processed, err := worker.processNext()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, processed, true)
+	assert.Equal(t, store.Jobs()[0].Status, model.JobCompleted)
+
+	processed, _ = worker.processNext()
+	assert.Equal(t, processed, false)
+}
+
+func TestWorkerRetriesWithBackoffThenBuries(t *testing.T) {
+	store := NewMemoryStore()
+	registry := NewRegistry()
+	registry.Register("fail", func(context.Context, []byte) error { return errors.New("boom") })
+	pushJob(t, store, "fail", time.Now())
+
+	worker := newTestWorker(store, registry)
+	now := time.Now()
+	worker.now = func() time.Time { return now }
+
+	_, _ = worker.processNext()
+	job := store.Jobs()[0]
+	assert.Equal(t, job.Status, model.JobPending)
+	assert.Equal(t, job.AvailableAt, now.Add(time.Second))
+
+	now = now.Add(time.Second)
+	_, _ = worker.processNext()
+	job = store.Jobs()[0]
+	assert.Equal(t, job.Status, model.JobPending)
+	assert.Equal(t, job.AvailableAt, now.Add(2*time.Second))
+
+	now = now.Add(2 * time.Second)
+	_, _ = worker.processNext()
+	job = store.Jobs()[0]
+	assert.Equal(t, job.Status, model.JobDead)
+	assert.Equal(t, job.Attempts, 3)
+	assert.Equal(t, job.LastError, "boom")
+}
+
+func TestWorkerBuriesUnknownJobs(t *testing.T) {
+	store := NewMemoryStore()
+	pushJob(t, store, "missing", time.Now())
+
+	worker := newTestWorker(store, NewRegistry())
+	_, _ = worker.processNext()
+
+	job := store.Jobs()[0]
+	assert.Equal(t, job.Status, model.JobDead)
+	assert.Equal(t, job.Attempts, 1)
+}
+
+func TestWorkerRecoversFromPanics(t *testing.T) {
+	store := NewMemoryStore()
+	registry := NewRegistry()
+	registry.Register("panic", func(context.Context, []byte) error { panic("oops") })
+	pushJob(t, store, "panic", time.Now())
+
+	worker := newTestWorker(store, registry)
+	_, _ = worker.processNext()
+
+	job := store.Jobs()[0]
+	assert.Equal(t, job.Status, model.JobPending)
+	assert.Equal(t, job.LastError, "job panicked: oops")
+}
+
+func TestWorkerBackoffIsCapped(t *testing.T) {
+	worker := newTestWorker(NewMemoryStore(), NewRegistry())
+
+	assert.Equal(t, worker.backoff(1), time.Second)
+	assert.Equal(t, worker.backoff(3), 4*time.Second)
+	assert.Equal(t, worker.backoff(10), 5*time.Second)
+}
+
+func TestWorkerShutdownDrainsRunningJobs(t *testing.T) {
+	store := NewMemoryStore()
+	registry := NewRegistry()
+
+	started := make(chan struct{})
+	var finished atomic.Bool
+	registry.Register("slow", func(context.Context, []byte) error {
+		close(started)
+		time.Sleep(50 * time.Millisecond)
+		finished.Store(true)
+		return nil
+	})
+	pushJob(t, store, "slow", time.Now())
+
+	worker := newTestWorker(store, registry)
+	worker.Start()
+	<-started
+
+	err := worker.Shutdown(context.Background())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, finished.Load(), true)
+	assert.Equal(t, store.Jobs()[0].Status, model.JobCompleted)
+}
+
+func TestWorkerShutdownCancelsOnTimeout(t *testing.T) {
+	store := NewMemoryStore()
+	registry := NewRegistry()
+
+	started := make(chan struct{})
+	registry.Register("blocking", func(ctx context.Context, _ []byte) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	pushJob(t, store, "blocking", time.Now())
+
+	worker := newTestWorker(store, registry)
+	worker.Start()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := worker.Shutdown(ctx)
+	assert.Equal(t, errors.Is(err, context.DeadlineExceeded), true)
+	assert.Equal(t, store.Jobs()[0].Status, model.JobPending)
+}
@@ -0,0 +1,276 @@
+// Package lockout slows down and then locks out clients repeatedly failing to
+// sign in, counted per email address and per IP.
+package lockout
+
+import (
+	"context"
+	"fmt"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/config"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/ratelimit"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	KindEmail = "email"
+	KindIP    = "ip"
+)
+
+// Subject is what failures are counted against.
+type Subject struct {
+	Kind  string
+	Value string
+}
+
+// Email returns the subject for an email address, ignoring case.
+func Email(email string) Subject {
+	return Subject{Kind: KindEmail, Value: strings.ToLower(strings.TrimSpace(email))}
+}
+
+func IP(ip string) Subject {
+	return Subject{Kind: KindIP, Value: ip}
+}
+
+// Policy locks a subject out for Duration after MaxAttempts failures within
+// Window, zero never locking it. Past FreeAttempts failures, the next attempt
+// must wait Delay, doubled for every further failure up to MaxDelay.
+type Policy struct {
+	MaxAttempts  int
+	Window       time.Duration
+	Duration     time.Duration
+	FreeAttempts int
+	Delay        time.Duration
+	MaxDelay     time.Duration
+}
+
+// NewPolicy converts the configured policy, given in seconds.
+func NewPolicy(cfg config.LockoutPolicy) Policy {
+	return Policy{
+		MaxAttempts:  cfg.MaxAttempts,
+		Window:       time.Duration(cfg.Window) * time.Second,
+		Duration:     time.Duration(cfg.Duration) * time.Second,
+		FreeAttempts: cfg.FreeAttempts,
+		Delay:        time.Duration(cfg.Delay) * time.Second,
+		MaxDelay:     time.Duration(cfg.MaxDelay) * time.Second,
+	}
+}
+
+// Status is the state of a subject. RetryAt is nil when an attempt is
+// allowed right away.
+type Status struct {
+	Kind     string     `json:"kind"`
+	Value    string     `json:"value"`
+	Failures int        `json:"failures"`
+	Locked   bool       `json:"locked"`
+	RetryAt  *time.Time `json:"retryAt"`
+}
+
+// RetryAfter returns how long the subject must wait before its next attempt.
+func (s Status) RetryAfter(now time.Time) time.Duration {
+	if s.RetryAt == nil {
+		return 0
+	}
+
+	return max(s.RetryAt.Sub(now), 0)
+}
+
+// Tracker counts failures in a rate limit store, so they are shared between
+// instances whenever the store is.
+type Tracker struct {
+	store    ratelimit.Store
+	policies map[string]Policy
+	now      func() time.Time
+}
+
+func NewTracker(store ratelimit.Store, policies map[string]Policy) *Tracker {
+	return &Tracker{store: store, policies: policies, now: time.Now}
+}
+
+// Check returns the status of the subject that has to wait the longest, and
+// false when every subject may attempt to sign in.
+func (t *Tracker) Check(ctx context.Context, subjects ...Subject) (Status, bool, error) {
+	var blocked Status
+	var found bool
+
+	for _, subject := range subjects {
+		status, err := t.Status(ctx, subject)
+		if err != nil {
+			return Status{}, false, err
+		}
+
+		if status.RetryAt != nil && (!found || status.RetryAt.After(*blocked.RetryAt)) {
+			blocked, found = status, true
+		}
+	}
+
+	return blocked, found, nil
+}
+
+// Status returns the current state of a subject.
+func (t *Tracker) Status(ctx context.Context, subject Subject) (Status, error) {
+	return t.update(ctx, subject, func(s state, _ Policy, _ time.Time) state { return s })
+}
+
+// Fail records a failed attempt against every subject. A Locked status means
+// this failure started the lockout.
+func (t *Tracker) Fail(ctx context.Context, subjects ...Subject) ([]Status, error) {
+	statuses := make([]Status, 0, len(subjects))
+
+	for _, subject := range subjects {
+		status, err := t.update(ctx, subject, fail)
+		if err != nil {
+			return statuses, err
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// Reset forgets the failures of every subject, e.g. after a successful login
+// or password reset.
+func (t *Tracker) Reset(ctx context.Context, subjects ...Subject) error {
+	for _, subject := range subjects {
+		_, err := t.update(ctx, subject, func(state, Policy, time.Time) state { return state{} })
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *Tracker) update(ctx context.Context, subject Subject, fn func(s state, p Policy, now time.Time) state) (Status, error) {
+	status := Status{Kind: subject.Kind, Value: subject.Value}
+
+	policy, ok := t.policies[subject.Kind]
+	if !ok || subject.Value == "" {
+		return status, nil
+	}
+
+	now := t.now()
+
+	var s state
+	err := t.store.Update(ctx, "lockout:"+subject.Kind+":"+subject.Value, func(value string) (string, time.Duration) {
+		s = fn(parse(value, policy, now), policy, now)
+
+		return s.encode(), max(s.expiresAt(policy).Sub(now), 0)
+	})
+	if err != nil {
+		return status, fmt.Errorf("lockout: %w", err)
+	}
+
+	status.Failures = s.failures
+	status.Locked = s.lockedUntil.After(now)
+
+	if s.retryAt.After(now) {
+		retryAt := s.retryAt
+		status.RetryAt = &retryAt
+	}
+
+	return status, nil
+}
+
+func fail(s state, p Policy, now time.Time) state {
+	if s.failures == 0 {
+		s.start = now
+	}
+
+	s.failures++
+
+	if p.MaxAttempts > 0 && s.failures >= p.MaxAttempts {
+		s.lockedUntil = now.Add(p.Duration)
+		s.retryAt = s.lockedUntil
+
+		return s
+	}
+
+	if n := s.failures - p.FreeAttempts; n > 0 && p.Delay > 0 {
+		delay := p.Delay
+		for i := 1; i < n && (p.MaxDelay <= 0 || delay < p.MaxDelay); i++ {
+			delay *= 2
+		}
+
+		if p.MaxDelay > 0 {
+			delay = min(delay, p.MaxDelay)
+		}
+
+		s.retryAt = now.Add(delay)
+	}
+
+	return s
+}
+
+// state is stored as "<failures>:<window start>:<retry at>:<locked until>",
+// times in Unix nanoseconds.
+type state struct {
+	failures    int
+	start       time.Time
+	retryAt     time.Time
+	lockedUntil time.Time
+}
+
+// parse decodes a stored state, starting over once its window or lockout is
+// over.
+func parse(value string, p Policy, now time.Time) state {
+	parts := strings.Split(value, ":")
+	if len(parts) != 4 {
+		return state{}
+	}
+
+	var s state
+	s.failures, _ = strconv.Atoi(parts[0])
+
+	times := []*time.Time{&s.start, &s.retryAt, &s.lockedUntil}
+	for i, t := range times {
+		nanos, err := strconv.ParseInt(parts[i+1], 10, 64)
+		if err != nil {
+			return state{}
+		}
+
+		if nanos != 0 {
+			*t = time.Unix(0, nanos)
+		}
+	}
+
+	if !s.lockedUntil.IsZero() && !now.Before(s.lockedUntil) {
+		return state{}
+	}
+
+	if s.lockedUntil.IsZero() && !now.Before(s.start.Add(p.Window)) {
+		return state{}
+	}
+
+	return s
+}
+
+func (s state) encode() string {
+	if s.failures == 0 {
+		return ""
+	}
+
+	nanos := func(t time.Time) string {
+		if t.IsZero() {
+			return "0"
+		}
+
+		return strconv.FormatInt(t.UnixNano(), 10)
+	}
+
+	return fmt.Sprintf("%d:%s:%s:%s", s.failures, nanos(s.start), nanos(s.retryAt), nanos(s.lockedUntil))
+}
+
+// expiresAt is when the state can be forgotten.
+func (s state) expiresAt(p Policy) time.Time {
+	expires := s.start.Add(p.Window)
+
+	for _, t := range []time.Time{s.retryAt, s.lockedUntil} {
+		if t.After(expires) {
+			expires = t
+		}
+	}
+
+	return expires
+}
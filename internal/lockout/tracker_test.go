@@ -0,0 +1,117 @@
+package lockout
+
+import (
+	"context"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/ratelimit"
+	"github.com/go-playground/assert/v2"
+	"testing"
+	"time"
+)
+
+func newTestTracker(now *time.Time) *Tracker {
+	tracker := NewTracker(ratelimit.NewMemoryStore(), map[string]Policy{
+		KindEmail: {MaxAttempts: 5, Window: 15 * time.Minute, Duration: 15 * time.Minute, FreeAttempts: 2, Delay: time.Second, MaxDelay: 3 * time.Second},
+		KindIP:    {MaxAttempts: 20, Window: 15 * time.Minute, Duration: time.Hour},
+	})
+	tracker.now = func() time.Time { return *now }
+
+	return tracker
+}
+
+func TestTrackerDelaysThenLocksOut(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+	tracker := newTestTracker(&now)
+	email := Email(" Jane@Example.com ")
+
+	assert.Equal(t, email.Value, "jane@example.com")
+
+	delays := []time.Duration{0, 0, time.Second, 2 * time.Second}
+	for _, delay := range delays {
+		statuses, err := tracker.Fail(ctx, email)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, statuses[0].Locked, false)
+		assert.Equal(t, statuses[0].RetryAfter(now), delay)
+
+		now = now.Add(delay)
+	}
+
+	statuses, err := tracker.Fail(ctx, email)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, statuses[0].Failures, 5)
+	assert.Equal(t, statuses[0].Locked, true)
+	assert.Equal(t, statuses[0].RetryAfter(now), 15*time.Minute)
+
+	status, blocked, err := tracker.Check(ctx, email, IP("192.0.2.1"))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, blocked, true)
+	assert.Equal(t, status.Kind, KindEmail)
+
+	// The lockout ends with a clean slate.
+	now = now.Add(15 * time.Minute)
+
+	status, err = tracker.Status(ctx, email)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, status, Status{Kind: KindEmail, Value: "jane@example.com"})
+}
+
+func TestTrackerCapsDelay(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tracker := newTestTracker(&now)
+	tracker.policies[KindEmail] = Policy{Window: time.Hour, FreeAttempts: 1, Delay: time.Second, MaxDelay: 3 * time.Second}
+
+	var statuses []Status
+	for range 10 {
+		statuses, _ = tracker.Fail(context.Background(), Email("jane@example.com"))
+		now = now.Add(time.Minute)
+	}
+
+	assert.Equal(t, statuses[0].Failures, 10)
+	assert.Equal(t, statuses[0].Locked, false)
+	assert.Equal(t, statuses[0].RetryAfter(now.Add(-time.Minute)), 3*time.Second)
+}
+
+func TestTrackerForgetsFailuresAfterWindow(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+	tracker := newTestTracker(&now)
+	ip := IP("192.0.2.1")
+
+	_, _ = tracker.Fail(ctx, ip)
+	_, _ = tracker.Fail(ctx, ip)
+
+	now = now.Add(15 * time.Minute)
+
+	statuses, err := tracker.Fail(ctx, ip)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, statuses[0].Failures, 1)
+}
+
+func TestTrackerReset(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+	tracker := newTestTracker(&now)
+	email := Email("jane@example.com")
+
+	for range 5 {
+		_, _ = tracker.Fail(ctx, email)
+	}
+
+	_, blocked, _ := tracker.Check(ctx, email)
+	assert.Equal(t, blocked, true)
+
+	assert.Equal(t, tracker.Reset(ctx, email), nil)
+
+	_, blocked, _ = tracker.Check(ctx, email)
+	assert.Equal(t, blocked, false)
+}
+
+func TestTrackerIgnoresSubjectsWithoutPolicy(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tracker := newTestTracker(&now)
+
+	statuses, err := tracker.Fail(context.Background(), Subject{Kind: "device", Value: "abc"}, Email(""))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, statuses[0].Failures, 0)
+	assert.Equal(t, statuses[1].Failures, 0)
+}
@@ -1,8 +1,10 @@
 package log
 
 import (
+	"fmt"
 	"log/slog"
 	"os"
+	"strings"
 )
 
 type Level int
@@ -15,6 +17,17 @@ type LoggerInterface interface {
 	Handler() slog.Handler
 }
 
-func NewLogger() LoggerInterface {
-	return slog.New(slog.NewJSONHandler(os.Stdout, nil))
+func NewLogger(level slog.Leveler) LoggerInterface {
+	return slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level}))
+}
+
+// ParseLevel converts a level name such as "debug" or "warn" to a slog level.
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(name))); err != nil {
+		return 0, fmt.Errorf("invalid log level %q: expected debug, info, warn or error", name)
+	}
+
+	return level, nil
 }
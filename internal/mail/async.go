@@ -0,0 +1,130 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/log"
+	"sync"
+	"time"
+)
+
+const (
+	queueSize       = 100
+	deliveryTimeout = 30 * time.Second
+)
+
+var ErrMailerClosed = errors.New("mail: mailer is closed")
+
+// AsyncMailer queues messages and delivers them from background workers,
+// retrying failed deliveries with exponential backoff.
+type AsyncMailer struct {
+	mailer     Mailer
+	log        log.LoggerInterface
+	maxRetries int
+	backoff    time.Duration
+	queue      chan *Message
+	abort      chan struct{}
+	abortOnce  sync.Once
+	mu         sync.RWMutex
+	closed     bool
+	wg         sync.WaitGroup
+}
+
+func NewAsyncMailer(mailer Mailer, log log.LoggerInterface, workers int, maxRetries int, backoff time.Duration) *AsyncMailer {
+	m := &AsyncMailer{
+		mailer:     mailer,
+		log:        log,
+		maxRetries: maxRetries,
+		backoff:    backoff,
+		queue:      make(chan *Message, queueSize),
+		abort:      make(chan struct{}),
+	}
+
+	for i := 0; i < workers; i++ {
+		m.wg.Add(1)
+		go m.work()
+	}
+
+	return m
+}
+
+// Send validates and queues the message. Delivery errors are logged by the
+// workers once all retries are exhausted.
+func (m *AsyncMailer) Send(ctx context.Context, message *Message) error {
+	if _, err := message.Bytes(); err != nil {
+		return err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.closed {
+		return ErrMailerClosed
+	}
+
+	select {
+	case m.queue <- message:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops accepting messages and waits for queued ones to be delivered.
+// Pending retries are abandoned when ctx expires.
+func (m *AsyncMailer) Close(ctx context.Context) error {
+	m.mu.Lock()
+	if !m.closed {
+		m.closed = true
+		close(m.queue)
+	}
+	m.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		m.abortOnce.Do(func() { close(m.abort) })
+		return ctx.Err()
+	}
+}
+
+func (m *AsyncMailer) work() {
+	defer m.wg.Done()
+
+	for message := range m.queue {
+		m.deliver(message)
+	}
+}
+
+func (m *AsyncMailer) deliver(message *Message) {
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), deliveryTimeout)
+		err := m.mailer.Send(ctx, message)
+		cancel()
+
+		if err == nil {
+			return
+		}
+
+		if attempt >= m.maxRetries {
+			m.log.Error("Mail delivery failed", "subject", message.Subject, "attempts", attempt+1, "error", err)
+			return
+		}
+
+		m.log.Warn("Mail delivery attempt failed", "subject", message.Subject, "attempt", attempt+1, "error", err)
+
+		select {
+		case <-time.After(m.backoff << attempt):
+		case <-m.abort:
+			m.log.Error("Mail delivery abandoned", "subject", message.Subject, "error", err)
+			return
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"github.com/go-playground/assert/v2"
+	"log/slog"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+type flakyMailer struct {
+	mu       sync.Mutex
+	failures int
+	attempts int
+	sent     []string
+}
+
+func (m *flakyMailer) Send(_ context.Context, message *Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.attempts++
+	if m.attempts <= m.failures {
+		return errors.New("temporary failure")
+	}
+
+	m.sent = append(m.sent, message.Subject)
+
+	return nil
+}
+
+func testMessage(subject string) *Message {
+	return &Message{
+		From:    "no-reply@example.com",
+		To:      []string{"user@example.com"},
+		Subject: subject,
+		Text:    "body",
+	}
+}
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError + 1}))
+}
+
+func TestAsyncMailerRetries(t *testing.T) {
+	transport := &flakyMailer{failures: 2}
+	mailer := NewAsyncMailer(transport, testLogger(), 1, 3, time.Millisecond)
+
+	err := mailer.Send(context.Background(), testMessage("hello"))
+	assert.Equal(t, err, nil)
+
+	err = mailer.Close(context.Background())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, transport.attempts, 3)
+	assert.Equal(t, transport.sent, []string{"hello"})
+}
+
+func TestAsyncMailerGivesUp(t *testing.T) {
+	transport := &flakyMailer{failures: 10}
+	mailer := NewAsyncMailer(transport, testLogger(), 1, 2, time.Millisecond)
+
+	_ = mailer.Send(context.Background(), testMessage("hello"))
+	_ = mailer.Close(context.Background())
+
+	assert.Equal(t, transport.attempts, 3)
+	assert.Equal(t, len(transport.sent), 0)
+}
+
+func TestAsyncMailerDrainsOnClose(t *testing.T) {
+	transport := &flakyMailer{}
+	mailer := NewAsyncMailer(transport, testLogger(), 2, 0, time.Millisecond)
+
+	for _, subject := range []string{"a", "b", "c"} {
+		assert.Equal(t, mailer.Send(context.Background(), testMessage(subject)), nil)
+	}
+
+	assert.Equal(t, mailer.Close(context.Background()), nil)
+	assert.Equal(t, len(transport.sent), 3)
+	assert.Equal(t, mailer.Send(context.Background(), testMessage("late")), ErrMailerClosed)
+}
+
+func TestAsyncMailerAbandonsRetriesWhenCloseTimesOut(t *testing.T) {
+	transport := &flakyMailer{failures: 10}
+	mailer := NewAsyncMailer(transport, testLogger(), 1, 5, time.Hour)
+
+	_ = mailer.Send(context.Background(), testMessage("hello"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	assert.Equal(t, mailer.Close(ctx), context.DeadlineExceeded)
+}
+
+func TestAsyncMailerRejectsInvalidMessage(t *testing.T) {
+	mailer := NewAsyncMailer(&flakyMailer{}, testLogger(), 1, 0, time.Millisecond)
+	defer mailer.Close(context.Background())
+
+	assert.Equal(t, mailer.Send(context.Background(), &Message{From: "no-reply@example.com"}), ErrNoRecipients)
+}
@@ -0,0 +1,39 @@
+package mail
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileMailer writes every message as an .eml file, so mail can be inspected
+// offline with any mail client during development.
+type FileMailer struct {
+	directory string
+}
+
+func NewFileMailer(directory string) *FileMailer {
+	return &FileMailer{directory: directory}
+}
+
+func (m *FileMailer) Send(_ context.Context, message *Message) error {
+	data, err := message.Bytes()
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(m.directory, 0o755); err != nil {
+		return err
+	}
+
+	random := make([]byte, 4)
+	_, _ = rand.Read(random)
+
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), hex.EncodeToString(random))
+
+	return os.WriteFile(filepath.Join(m.directory, name), data, 0o644)
+}
@@ -0,0 +1,40 @@
+package mail
+
+import (
+	"context"
+	"github.com/go-playground/assert/v2"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileMailerWritesEml(t *testing.T) {
+	directory := filepath.Join(t.TempDir(), "mail")
+	mailer := NewFileMailer(directory)
+
+	err := mailer.Send(context.Background(), testMessage("hello"))
+	assert.Equal(t, err, nil)
+
+	files, _ := filepath.Glob(filepath.Join(directory, "*.eml"))
+	assert.Equal(t, len(files), 1)
+
+	data, _ := os.ReadFile(files[0])
+	parsed, err := mail.ReadMessage(strings.NewReader(string(data)))
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, parsed.Header.Get("Subject"), "hello")
+}
+
+func TestMemoryMailer(t *testing.T) {
+	mailer := NewMemoryMailer()
+
+	assert.Equal(t, mailer.Send(context.Background(), testMessage("hello")), nil)
+	assert.Equal(t, len(mailer.Messages()), 1)
+	assert.Equal(t, mailer.Messages()[0].Subject, "hello")
+
+	mailer.Reset()
+
+	assert.Equal(t, len(mailer.Messages()), 0)
+}
@@ -0,0 +1,46 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/config"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/log"
+	"time"
+)
+
+const (
+	DriverSMTP   = "smtp"
+	DriverFile   = "file"
+	DriverMemory = "memory"
+)
+
+// Mailer delivers a fully built message. Drivers differ only in where the
+// message ends up.
+type Mailer interface {
+	Send(ctx context.Context, message *Message) error
+}
+
+// NewMailer creates the driver selected in the configuration, wrapped in an
+// asynchronous queue when workers are configured.
+func NewMailer(cfg config.Mail, log log.LoggerInterface) (Mailer, error) {
+	var mailer Mailer
+
+	switch cfg.Driver {
+	case DriverSMTP:
+		mailer = NewSMTPMailer(cfg.SMTP.Host, cfg.SMTP.Port, cfg.SMTP.Username, cfg.SMTP.Password)
+	case DriverFile:
+		mailer = NewFileMailer(cfg.Directory)
+	case DriverMemory, "":
+		mailer = NewMemoryMailer()
+	default:
+		return nil, fmt.Errorf("mail: unknown driver %q", cfg.Driver)
+	}
+
+	if cfg.Workers <= 0 {
+		return mailer, nil
+	}
+
+	backoff := time.Duration(cfg.RetryBackoff) * time.Second
+
+	return NewAsyncMailer(mailer, log, cfg.Workers, cfg.MaxRetries, backoff), nil
+}
@@ -0,0 +1,150 @@
+package mail
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+)
+
+var ErrNoRecipients = errors.New("mail: message has no recipients")
+
+type Message struct {
+	From    string
+	To      []string
+	Cc      []string
+	Bcc     []string
+	ReplyTo string
+	Subject string
+	Text    string
+	HTML    string
+	Headers map[string]string
+}
+
+// Recipients returns every address the message must be delivered to,
+// including blind copies which are not written to the headers.
+func (m *Message) Recipients() []string {
+	recipients := make([]string, 0, len(m.To)+len(m.Cc)+len(m.Bcc))
+	recipients = append(recipients, m.To...)
+	recipients = append(recipients, m.Cc...)
+	recipients = append(recipients, m.Bcc...)
+
+	return recipients
+}
+
+// Bytes renders the message as an RFC 5322 document, using a
+// multipart/alternative body when both text and HTML parts are present.
+func (m *Message) Bytes() ([]byte, error) {
+	if len(m.To)+len(m.Cc)+len(m.Bcc) == 0 {
+		return nil, ErrNoRecipients
+	}
+
+	from, err := mail.ParseAddress(m.From)
+	if err != nil {
+		return nil, fmt.Errorf("mail: invalid from address: %w", err)
+	}
+
+	buf := &bytes.Buffer{}
+
+	writeHeader(buf, "From", from.String())
+	writeHeader(buf, "To", strings.Join(m.To, ", "))
+	if len(m.Cc) > 0 {
+		writeHeader(buf, "Cc", strings.Join(m.Cc, ", "))
+	}
+
+	if m.ReplyTo != "" {
+		writeHeader(buf, "Reply-To", m.ReplyTo)
+	}
+
+	writeHeader(buf, "Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	writeHeader(buf, "Date", time.Now().Format(time.RFC1123Z))
+	writeHeader(buf, "Message-ID", messageID(from.Address))
+	writeHeader(buf, "MIME-Version", "1.0")
+
+	keys := make([]string, 0, len(m.Headers))
+	for key := range m.Headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		writeHeader(buf, key, m.Headers[key])
+	}
+
+	switch {
+	case m.Text != "" && m.HTML != "":
+		writer := multipart.NewWriter(buf)
+		writeHeader(buf, "Content-Type", fmt.Sprintf("multipart/alternative; boundary=%q", writer.Boundary()))
+		buf.WriteString("\r\n")
+
+		if err = writePart(writer, "text/plain; charset=utf-8", m.Text); err != nil {
+			return nil, err
+		}
+
+		if err = writePart(writer, "text/html; charset=utf-8", m.HTML); err != nil {
+			return nil, err
+		}
+
+		if err = writer.Close(); err != nil {
+			return nil, err
+		}
+	case m.HTML != "":
+		writeBody(buf, "text/html; charset=utf-8", m.HTML)
+	default:
+		writeBody(buf, "text/plain; charset=utf-8", m.Text)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func writeHeader(buf *bytes.Buffer, key string, value string) {
+	fmt.Fprintf(buf, "%s: %s\r\n", key, value)
+}
+
+func writeBody(buf *bytes.Buffer, contentType string, body string) {
+	writeHeader(buf, "Content-Type", contentType)
+	writeHeader(buf, "Content-Transfer-Encoding", "quoted-printable")
+	buf.WriteString("\r\n")
+
+	writer := quotedprintable.NewWriter(buf)
+	_, _ = writer.Write([]byte(body))
+	_ = writer.Close()
+}
+
+func writePart(writer *multipart.Writer, contentType string, body string) error {
+	part, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+
+	encoder := quotedprintable.NewWriter(part)
+	if _, err = encoder.Write([]byte(body)); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
+
+func messageID(from string) string {
+	domain := "localhost"
+	if _, host, found := strings.Cut(from, "@"); found {
+		domain = host
+	}
+
+	random := make([]byte, 12)
+	_, _ = rand.Read(random)
+
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), hex.EncodeToString(random), domain)
+}
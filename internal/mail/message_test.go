@@ -0,0 +1,68 @@
+package mail
+
+import (
+	"github.com/go-playground/assert/v2"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+func TestMessageBytesMultipart(t *testing.T) {
+	message := &Message{
+		From:    "App <no-reply@example.com>",
+		To:      []string{"user@example.com"},
+		Bcc:     []string{"audit@example.com"},
+		Subject: "Héllo",
+		Text:    "plain body",
+		HTML:    "<p>html body</p>",
+		Headers: map[string]string{"X-Template": "welcome"},
+	}
+
+	data, err := message.Bytes()
+	assert.Equal(t, err, nil)
+
+	parsed, err := mail.ReadMessage(strings.NewReader(string(data)))
+	assert.Equal(t, err, nil)
+
+	subject, _ := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	assert.Equal(t, subject, "Héllo")
+	assert.Equal(t, parsed.Header.Get("To"), "user@example.com")
+	assert.Equal(t, parsed.Header.Get("Bcc"), "")
+	assert.Equal(t, parsed.Header.Get("X-Template"), "welcome")
+
+	mediaType, params, _ := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	assert.Equal(t, mediaType, "multipart/alternative")
+
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+
+	text, _ := reader.NextPart()
+	textBody, _ := io.ReadAll(text)
+	assert.Equal(t, text.Header.Get("Content-Type"), "text/plain; charset=utf-8")
+	assert.Equal(t, string(textBody), "plain body")
+
+	html, _ := reader.NextPart()
+	htmlBody, _ := io.ReadAll(html)
+	assert.Equal(t, html.Header.Get("Content-Type"), "text/html; charset=utf-8")
+	assert.Equal(t, string(htmlBody), "<p>html body</p>")
+}
+
+func TestMessageBytesErrors(t *testing.T) {
+	_, err := (&Message{From: "no-reply@example.com"}).Bytes()
+	assert.Equal(t, err, ErrNoRecipients)
+
+	_, err = (&Message{From: "invalid", To: []string{"user@example.com"}}).Bytes()
+	assert.NotEqual(t, err, nil)
+}
+
+func TestMessageRecipients(t *testing.T) {
+	message := &Message{
+		To:  []string{"a@example.com"},
+		Cc:  []string{"b@example.com"},
+		Bcc: []string{"c@example.com"},
+	}
+
+	assert.Equal(t, message.Recipients(), []string{"a@example.com", "b@example.com", "c@example.com"})
+}
@@ -0,0 +1,43 @@
+package mail
+
+import (
+	"context"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/job"
+)
+
+type SendTemplatePayload struct {
+	Template string   `json:"template"`
+	Locale   string   `json:"locale"`
+	To       []string `json:"to"`
+	Data     any      `json:"data"`
+}
+
+// SendTemplateJob renders and delivers a templated email from a worker.
+var SendTemplateJob = job.Define[SendTemplatePayload]("mail.send_template")
+
+// RegisterJobs lets the job worker deliver emails queued by a QueuedMailer.
+func RegisterJobs(registry *job.Registry, mailer TemplateMailerInterface) {
+	SendTemplateJob.Register(registry, func(ctx context.Context, payload SendTemplatePayload) error {
+		return mailer.SendTemplate(ctx, payload.Template, payload.Locale, payload.To, payload.Data)
+	})
+}
+
+// QueuedMailer defers templated emails to the job queue, so delivery is
+// retried and survives restarts when the database driver is used. Template
+// data must be serialisable to JSON.
+type QueuedMailer struct {
+	queue job.QueueInterface
+}
+
+func NewQueuedMailer(queue job.QueueInterface) *QueuedMailer {
+	return &QueuedMailer{queue: queue}
+}
+
+func (m *QueuedMailer) SendTemplate(ctx context.Context, name string, locale string, to []string, data any) error {
+	return SendTemplateJob.Enqueue(ctx, m.queue, SendTemplatePayload{
+		Template: name,
+		Locale:   locale,
+		To:       to,
+		Data:     data,
+	})
+}
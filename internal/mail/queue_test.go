@@ -0,0 +1,45 @@
+package mail
+
+import (
+	"context"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/job"
+	"github.com/go-playground/assert/v2"
+	"testing"
+)
+
+type recordingTemplateMailer struct {
+	name   string
+	locale string
+	to     []string
+	data   any
+}
+
+func (m *recordingTemplateMailer) SendTemplate(_ context.Context, name string, locale string, to []string, data any) error {
+	m.name, m.locale, m.to, m.data = name, locale, to, data
+	return nil
+}
+
+func TestQueuedMailerDeliversThroughJob(t *testing.T) {
+	store := job.NewMemoryStore()
+	registry := job.NewRegistry()
+	recorder := &recordingTemplateMailer{}
+	RegisterJobs(registry, recorder)
+
+	mailer := NewQueuedMailer(job.NewQueue(store, 3))
+	err := mailer.SendTemplate(context.Background(), "welcome", "fr", []string{"ada@example.com"}, map[string]string{"Email": "ada@example.com"})
+	assert.Equal(t, err, nil)
+
+	queued := store.Jobs()
+	assert.Equal(t, len(queued), 1)
+	assert.Equal(t, queued[0].Name, SendTemplateJob.Name)
+
+	handler, ok := registry.Handler(SendTemplateJob.Name)
+	assert.Equal(t, ok, true)
+
+	err = handler(context.Background(), []byte(queued[0].Payload))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, recorder.name, "welcome")
+	assert.Equal(t, recorder.locale, "fr")
+	assert.Equal(t, recorder.to, []string{"ada@example.com"})
+	assert.Equal(t, recorder.data, map[string]any{"Email": "ada@example.com"})
+}
@@ -0,0 +1,104 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"github.com/go-playground/assert/v2"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type smtpTranscript struct {
+	commands []string
+	data     string
+}
+
+// startSMTPServer runs a minimal SMTP stand-in which accepts a single
+// message without STARTTLS or authentication.
+func startSMTPServer(t *testing.T) (string, int, chan smtpTranscript) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Equal(t, err, nil)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	transcripts := make(chan smtpTranscript, 1)
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+		transcript := smtpTranscript{}
+
+		reply("220 localhost ESMTP")
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			command := strings.TrimSpace(line)
+			transcript.commands = append(transcript.commands, command)
+
+			switch {
+			case strings.HasPrefix(command, "EHLO"):
+				reply("250-localhost")
+				reply("250 8BITMIME")
+			case command == "DATA":
+				reply("354 go ahead")
+
+				var data strings.Builder
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil || line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+
+				transcript.data = data.String()
+				reply("250 queued")
+			case command == "QUIT":
+				reply("221 bye")
+				transcripts <- transcript
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+
+	return host, portNumber, transcripts
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	host, port, transcripts := startSMTPServer(t)
+	mailer := NewSMTPMailer(host, port, "", "")
+
+	message := testMessage("hello")
+	message.From = "App <no-reply@example.com>"
+	message.Bcc = []string{"audit@example.com"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := mailer.Send(ctx, message)
+	assert.Equal(t, err, nil)
+
+	transcript := <-transcripts
+
+	assert.Equal(t, transcript.commands[1], "MAIL FROM:<no-reply@example.com> BODY=8BITMIME")
+	assert.Equal(t, transcript.commands[2], "RCPT TO:<user@example.com>")
+	assert.Equal(t, transcript.commands[3], "RCPT TO:<audit@example.com>")
+	assert.Equal(t, strings.Contains(transcript.data, "Subject: hello"), true)
+	assert.Equal(t, strings.Contains(transcript.data, "audit@example.com"), false)
+}
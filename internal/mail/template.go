@@ -0,0 +1,204 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// TemplateMailerInterface sends emails built from the named templates.
+type TemplateMailerInterface interface {
+	SendTemplate(ctx context.Context, name string, locale string, to []string, data any) error
+}
+
+type templateData struct {
+	App     string
+	Locale  string
+	Subject string
+	Data    any
+}
+
+// Renderer builds messages from a template directory laid out as
+// layouts/base.{html,txt}.tmpl, <locale>/<name>.{html,txt}.tmpl and
+// locales/<locale>.json. Missing templates and translations fall back to the
+// default locale.
+type Renderer struct {
+	fsys          fs.FS
+	app           string
+	defaultLocale string
+	translations  map[string]map[string]string
+}
+
+func NewRenderer(app string, defaultLocale string) (*Renderer, error) {
+	fsys, err := fs.Sub(templateFS, "templates")
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRendererFS(fsys, app, defaultLocale)
+}
+
+func NewRendererFS(fsys fs.FS, app string, defaultLocale string) (*Renderer, error) {
+	translations := map[string]map[string]string{}
+
+	files, err := fs.Glob(fsys, "locales/*.json")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+
+		messages := map[string]string{}
+		if err = json.Unmarshal(data, &messages); err != nil {
+			return nil, fmt.Errorf("mail: invalid translations %s: %w", file, err)
+		}
+
+		translations[strings.TrimSuffix(path.Base(file), ".json")] = messages
+	}
+
+	return &Renderer{
+		fsys:          fsys,
+		app:           app,
+		defaultLocale: defaultLocale,
+		translations:  translations,
+	}, nil
+}
+
+// Render fills in the subject, text and HTML bodies of a message.
+func (r *Renderer) Render(name string, locale string, data any) (*Message, error) {
+	locale = r.resolveLocale(name, locale)
+	td := templateData{App: r.app, Locale: locale, Data: data}
+	funcs := map[string]any{"t": r.translator(locale)}
+
+	textFile := path.Join(locale, name+".txt.tmpl")
+	text, err := texttemplate.New("base.txt.tmpl").Funcs(funcs).ParseFS(r.fsys, "layouts/base.txt.tmpl", textFile)
+	if err != nil {
+		return nil, err
+	}
+
+	subject := &bytes.Buffer{}
+	if err = text.ExecuteTemplate(subject, "subject", td); err != nil {
+		return nil, err
+	}
+
+	td.Subject = strings.TrimSpace(subject.String())
+
+	textBody := &bytes.Buffer{}
+	if err = text.Execute(textBody, td); err != nil {
+		return nil, err
+	}
+
+	message := &Message{
+		Subject: td.Subject,
+		Text:    strings.TrimSpace(textBody.String()),
+	}
+
+	htmlFile := path.Join(locale, name+".html.tmpl")
+	if _, err = fs.Stat(r.fsys, htmlFile); errors.Is(err, fs.ErrNotExist) {
+		return message, nil
+	}
+
+	html, err := htmltemplate.New("base.html.tmpl").Funcs(funcs).ParseFS(r.fsys, "layouts/base.html.tmpl", htmlFile)
+	if err != nil {
+		return nil, err
+	}
+
+	htmlBody := &bytes.Buffer{}
+	if err = html.Execute(htmlBody, td); err != nil {
+		return nil, err
+	}
+
+	message.HTML = htmlBody.String()
+
+	return message, nil
+}
+
+func (r *Renderer) resolveLocale(name string, locale string) string {
+	for _, candidate := range []string{locale, baseLanguage(locale)} {
+		if candidate == "" {
+			continue
+		}
+
+		if _, err := fs.Stat(r.fsys, path.Join(candidate, name+".txt.tmpl")); err == nil {
+			return candidate
+		}
+	}
+
+	return r.defaultLocale
+}
+
+func (r *Renderer) translator(locale string) func(key string, args ...any) string {
+	return func(key string, args ...any) string {
+		message, ok := r.translations[locale][key]
+		if !ok {
+			message, ok = r.translations[r.defaultLocale][key]
+		}
+
+		if !ok {
+			return key
+		}
+
+		if len(args) == 0 {
+			return message
+		}
+
+		return fmt.Sprintf(message, args...)
+	}
+}
+
+// TemplateMailer renders templates and hands the result to a Mailer.
+type TemplateMailer struct {
+	mailer   Mailer
+	renderer *Renderer
+	from     string
+}
+
+func NewTemplateMailer(mailer Mailer, renderer *Renderer, from string) *TemplateMailer {
+	return &TemplateMailer{
+		mailer:   mailer,
+		renderer: renderer,
+		from:     from,
+	}
+}
+
+func (m *TemplateMailer) SendTemplate(ctx context.Context, name string, locale string, to []string, data any) error {
+	message, err := m.renderer.Render(name, locale, data)
+	if err != nil {
+		return err
+	}
+
+	message.From = m.from
+	message.To = to
+
+	return m.mailer.Send(ctx, message)
+}
+
+// ParseLocale returns the preferred language of an Accept-Language header,
+// e.g. "fr-CA,fr;q=0.9" becomes "fr-CA".
+func ParseLocale(acceptLanguage string) string {
+	preferred, _, _ := strings.Cut(acceptLanguage, ",")
+	preferred, _, _ = strings.Cut(preferred, ";")
+
+	return strings.TrimSpace(preferred)
+}
+
+func baseLanguage(locale string) string {
+	language, _, _ := strings.Cut(locale, "-")
+
+	return strings.ToLower(language)
+}
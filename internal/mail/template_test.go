@@ -0,0 +1,99 @@
+package mail
+
+import (
+	"context"
+	"github.com/go-playground/assert/v2"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRendererRender(t *testing.T) {
+	renderer, err := NewRenderer("Skeleton", "en")
+	assert.Equal(t, err, nil)
+
+	tests := []struct {
+		name            string
+		locale          string
+		expectedSubject string
+		expectedText    string
+		expectedFooter  string
+	}{
+		{"Default locale", "en", "Welcome to Skeleton", "Hi user@example.com,", "You are receiving this email because you have an account with Skeleton."},
+		{"Regional locale", "fr-CA", "Bienvenue sur Skeleton", "Bonjour user@example.com,", "Vous recevez cet e-mail car vous avez un compte chez Skeleton."},
+		{"Unknown locale falls back", "de", "Welcome to Skeleton", "Hi user@example.com,", "You are receiving this email because you have an account with Skeleton."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message, err := renderer.Render("welcome", tt.locale, map[string]string{"Email": "user@example.com"})
+
+			assert.Equal(t, err, nil)
+			assert.Equal(t, message.Subject, tt.expectedSubject)
+			assert.Equal(t, strings.Contains(message.Text, tt.expectedText), true)
+			assert.Equal(t, strings.Contains(message.Text, tt.expectedFooter), true)
+			assert.Equal(t, strings.Contains(message.HTML, "<title>"+tt.expectedSubject+"</title>"), true)
+			assert.Equal(t, strings.Contains(message.HTML, tt.expectedFooter), true)
+		})
+	}
+}
+
+func TestRendererEscapesHTML(t *testing.T) {
+	renderer, _ := NewRenderer("Skeleton", "en")
+
+	message, err := renderer.Render("notification", "en", map[string]string{
+		"Title": "Tom & Jerry",
+		"Body":  "<script>alert(1)</script>",
+	})
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, message.Subject, "Tom & Jerry")
+	assert.Equal(t, strings.Contains(message.Text, "<script>alert(1)</script>"), true)
+	assert.Equal(t, strings.Contains(message.HTML, "&lt;script&gt;"), true)
+}
+
+func TestRendererTextOnly(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layouts/base.txt.tmpl":  {Data: []byte(`{{template "content" .}}`)},
+		"layouts/base.html.tmpl": {Data: []byte(`{{template "content" .}}`)},
+		"en/plain.txt.tmpl":      {Data: []byte(`{{define "subject"}}Plain{{end}}{{define "content"}}{{t "missing"}}{{end}}`)},
+	}
+
+	renderer, err := NewRendererFS(fsys, "Skeleton", "en")
+	assert.Equal(t, err, nil)
+
+	message, err := renderer.Render("plain", "en", nil)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, message.Subject, "Plain")
+	assert.Equal(t, message.Text, "missing")
+	assert.Equal(t, message.HTML, "")
+}
+
+func TestTemplateMailerSendTemplate(t *testing.T) {
+	renderer, _ := NewRenderer("Skeleton", "en")
+	memory := NewMemoryMailer()
+	mailer := NewTemplateMailer(memory, renderer, "Skeleton <no-reply@example.com>")
+
+	err := mailer.SendTemplate(context.Background(), "receipt", "en", []string{"user@example.com"}, map[string]any{
+		"Number": "R-1",
+		"Date":   "2024-01-01",
+		"Items":  []map[string]any{{"Description": "Plan", "Quantity": 1, "Amount": "$10.00"}},
+		"Total":  "$10.00",
+	})
+
+	assert.Equal(t, err, nil)
+
+	messages := memory.Messages()
+	assert.Equal(t, len(messages), 1)
+	assert.Equal(t, messages[0].Subject, "Your receipt R-1")
+	assert.Equal(t, messages[0].From, "Skeleton <no-reply@example.com>")
+	assert.Equal(t, messages[0].To, []string{"user@example.com"})
+	assert.Equal(t, strings.Contains(messages[0].Text, "Plan x 1: $10.00"), true)
+}
+
+func TestParseLocale(t *testing.T) {
+	assert.Equal(t, ParseLocale("fr-CA,fr;q=0.9,en;q=0.8"), "fr-CA")
+	assert.Equal(t, ParseLocale("en;q=0.8"), "en")
+	assert.Equal(t, ParseLocale(""), "")
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/auth"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/http/response"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+// AuthMiddleware tries each authenticator in turn and rejects the request
+// when none of them recognises its credentials. A principal already set by an
+// earlier middleware, such as a client certificate, is kept. The CSRF check
+// is only waived for principals authenticated by a bearer token or API key.
+func AuthMiddleware(authenticators ...auth.Authenticator) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		authenticated := func(c echo.Context, principal *auth.Principal) error {
+			if !principal.HeaderCredentials() {
+				if err := VerifyCSRF(c); err != nil {
+					return err
+				}
+			}
+
+			return next(c)
+		}
+
+		return func(c echo.Context) error {
+			if principal := auth.FromContext(c); principal != nil {
+				return authenticated(c, principal)
+			}
+
+			for _, authenticator := range authenticators {
+				principal, err := authenticator.Authenticate(c)
+				if err != nil {
+					return unauthorized(err)
+				}
+
+				if principal != nil {
+					auth.SetPrincipal(c, principal)
+					return authenticated(c, principal)
+				}
+			}
+
+			return unauthorized(auth.ErrUnauthenticated)
+		}
+	}
+}
+
+func unauthorized(err error) error {
+	return response.ErrorResponse(http.StatusUnauthorized, response.Error{
+		Message: err.Error(),
+	})
+}
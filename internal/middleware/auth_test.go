@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"errors"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/auth"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/http/response"
+	"github.com/go-playground/assert/v2"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type authenticatorFunc func(c echo.Context) (*auth.Principal, error)
+
+func (f authenticatorFunc) Authenticate(c echo.Context) (*auth.Principal, error) {
+	return f(c)
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	principal := &auth.Principal{Type: auth.PrincipalUser, ID: "123"}
+	skip := authenticatorFunc(func(c echo.Context) (*auth.Principal, error) { return nil, nil })
+	accept := authenticatorFunc(func(c echo.Context) (*auth.Principal, error) { return principal, nil })
+	reject := authenticatorFunc(func(c echo.Context) (*auth.Principal, error) { return nil, errors.New("bad token") })
+
+	tests := []struct {
+		name              string
+		authenticators    []auth.Authenticator
+		expectedPrincipal *auth.Principal
+		expectedErr       error
+	}{
+		{
+			"First matching authenticator wins",
+			[]auth.Authenticator{skip, accept, reject},
+			principal,
+			nil,
+		},
+		{
+			"Invalid credentials are rejected",
+			[]auth.Authenticator{reject, accept},
+			nil,
+			response.ErrorResponse(http.StatusUnauthorized, response.Error{Message: "bad token"}),
+		},
+		{
+			"Missing credentials are rejected",
+			[]auth.Authenticator{skip},
+			nil,
+			response.ErrorResponse(http.StatusUnauthorized, response.Error{Message: auth.ErrUnauthenticated.Error()}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			c := echo.New().NewContext(req, httptest.NewRecorder())
+
+			var received *auth.Principal
+			h := AuthMiddleware(tt.authenticators...)(func(c echo.Context) error {
+				received = auth.FromContext(c)
+				return nil
+			})
+
+			err := h(c)
+
+			assert.Equal(t, err, tt.expectedErr)
+			assert.Equal(t, received, tt.expectedPrincipal)
+		})
+	}
+}
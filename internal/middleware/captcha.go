@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"errors"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/captcha"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/config"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/http/response"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/log"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+const (
+	captchaHeader     = "X-Captcha-Token"
+	captchaContextKey = "captcha"
+)
+
+// CaptchaMiddleware requires a captcha token in the X-Captcha-Token header.
+// Forwarded tokens are only required, and left for the handler to pass on to
+// Supabase Auth, other tokens are checked with the verifier.
+func CaptchaMiddleware(cfg config.Captcha, verifier captcha.Verifier, log log.LoggerInterface) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			token := c.Request().Header.Get(captchaHeader)
+
+			var err error
+			if cfg.Forward {
+				if token == "" {
+					err = captcha.ErrMissingToken
+				}
+			} else {
+				err = verifier.Verify(c.Request().Context(), token, c.RealIP())
+			}
+
+			if errors.Is(err, captcha.ErrMissingToken) || errors.Is(err, captcha.ErrInvalidToken) {
+				return response.ErrorResponse(http.StatusBadRequest, response.Error{Message: err.Error()})
+			}
+
+			// Without a working provider, bots cannot be told apart.
+			if err != nil {
+				log.Error("Captcha verification unavailable", "provider", cfg.Provider, "error", err)
+
+				return response.ErrorResponse(http.StatusServiceUnavailable, response.Error{
+					Message: "captcha verification is unavailable, please retry later",
+				})
+			}
+
+			if cfg.Forward {
+				c.Set(captchaContextKey, token)
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// CaptchaToken returns the captcha token to forward to Supabase Auth, if any.
+func CaptchaToken(c echo.Context) string {
+	token, _ := c.Get(captchaContextKey).(string)
+
+	return token
+}
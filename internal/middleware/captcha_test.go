@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/captcha"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/config"
+	"github.com/go-playground/assert/v2"
+	"github.com/labstack/echo/v4"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type unavailableVerifier struct{}
+
+func (unavailableVerifier) Verify(context.Context, string, string) error {
+	return errors.New("connection refused")
+}
+
+func runCaptcha(m echo.MiddlewareFunc, token string) (string, error) {
+	req := httptest.NewRequest(http.MethodPost, "/login", nil)
+	if token != "" {
+		req.Header.Set("X-Captcha-Token", token)
+	}
+
+	forwarded := ""
+	err := m(func(c echo.Context) error {
+		forwarded = CaptchaToken(c)
+		return c.NoContent(http.StatusOK)
+	})(echo.New().NewContext(req, httptest.NewRecorder()))
+
+	return forwarded, err
+}
+
+func TestCaptchaMiddleware(t *testing.T) {
+	fake := captcha.NewFake("pass")
+	var logged bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logged, nil))
+	m := CaptchaMiddleware(config.Captcha{Provider: "turnstile"}, fake, logger)
+
+	forwarded, err := runCaptcha(m, "pass")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, forwarded, "")
+
+	_, err = runCaptcha(m, "fail")
+	assert.Equal(t, err.(*echo.HTTPError).Code, http.StatusBadRequest)
+
+	_, err = runCaptcha(m, "")
+	assert.Equal(t, err.(*echo.HTTPError).Code, http.StatusBadRequest)
+	assert.Equal(t, fake.Tokens(), []string{"pass", "fail", ""})
+
+	assert.Equal(t, logged.Len(), 0)
+
+	_, err = runCaptcha(CaptchaMiddleware(config.Captcha{Provider: "turnstile"}, unavailableVerifier{}, logger), "pass")
+	assert.Equal(t, err.(*echo.HTTPError).Code, http.StatusServiceUnavailable)
+	assert.Equal(t, strings.Contains(logged.String(), `msg="Captcha verification unavailable" provider=turnstile error="connection refused"`), true)
+}
+
+func TestCaptchaMiddlewareForwardsTokens(t *testing.T) {
+	m := CaptchaMiddleware(config.Captcha{Forward: true}, nil, nil)
+
+	forwarded, err := runCaptcha(m, "token")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, forwarded, "token")
+
+	_, err = runCaptcha(m, "")
+	assert.Equal(t, err.(*echo.HTTPError).Code, http.StatusBadRequest)
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"errors"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/auth"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/config"
+	"github.com/labstack/echo/v4"
+)
+
+var ErrClientCertificateRequired = errors.New("a client certificate is required")
+
+// ClientCertificateMiddleware authenticates a service by its client
+// certificate. In config.ClientAuthRequire mode a request without a verified
+// certificate is rejected, in config.ClientAuthVerifyIfGiven mode it is left
+// to the other authenticators.
+func ClientCertificateMiddleware(mode string, authenticator auth.Authenticator) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			principal, err := authenticator.Authenticate(c)
+			if err != nil {
+				return unauthorized(err)
+			}
+
+			if principal == nil {
+				if mode == config.ClientAuthRequire {
+					return unauthorized(ErrClientCertificateRequired)
+				}
+
+				return next(c)
+			}
+
+			auth.SetPrincipal(c, principal)
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/auth"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/config"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/http/response"
+	"github.com/go-playground/assert/v2"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientCertificateMiddleware(t *testing.T) {
+	service := &auth.Principal{Type: auth.PrincipalService, ID: "payments"}
+	withCertificate := authenticatorFunc(func(c echo.Context) (*auth.Principal, error) { return service, nil })
+	withoutCertificate := authenticatorFunc(func(c echo.Context) (*auth.Principal, error) { return nil, nil })
+	unknownService := authenticatorFunc(func(c echo.Context) (*auth.Principal, error) { return nil, auth.ErrUnknownService })
+
+	tests := []struct {
+		name              string
+		mode              string
+		authenticator     auth.Authenticator
+		expectedPrincipal *auth.Principal
+		expectedErr       error
+	}{
+		{"Required certificate given", config.ClientAuthRequire, withCertificate, service, nil},
+		{
+			"Required certificate missing",
+			config.ClientAuthRequire,
+			withoutCertificate,
+			nil,
+			response.ErrorResponse(http.StatusUnauthorized, response.Error{Message: ErrClientCertificateRequired.Error()}),
+		},
+		{"Optional certificate given", config.ClientAuthVerifyIfGiven, withCertificate, service, nil},
+		{"Optional certificate missing", config.ClientAuthVerifyIfGiven, withoutCertificate, nil, nil},
+		{
+			"Unknown service",
+			config.ClientAuthVerifyIfGiven,
+			unknownService,
+			nil,
+			response.ErrorResponse(http.StatusUnauthorized, response.Error{Message: auth.ErrUnknownService.Error()}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			c := echo.New().NewContext(req, httptest.NewRecorder())
+
+			var received *auth.Principal
+			h := ClientCertificateMiddleware(tt.mode, tt.authenticator)(func(c echo.Context) error {
+				received = auth.FromContext(c)
+				return nil
+			})
+
+			err := h(c)
+
+			assert.Equal(t, err, tt.expectedErr)
+			assert.Equal(t, received, tt.expectedPrincipal)
+		})
+	}
+}
+
+func TestAuthMiddlewareKeepsExistingPrincipal(t *testing.T) {
+	service := &auth.Principal{Type: auth.PrincipalService, ID: "payments"}
+	reject := authenticatorFunc(func(c echo.Context) (*auth.Principal, error) { return nil, auth.ErrUnauthenticated })
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	c := echo.New().NewContext(req, httptest.NewRecorder())
+	auth.SetPrincipal(c, service)
+
+	var received *auth.Principal
+	err := AuthMiddleware(reject)(func(c echo.Context) error {
+		received = auth.FromContext(c)
+		return nil
+	})(c)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, received, service)
+}
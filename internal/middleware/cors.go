@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/config"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"net/url"
+	"strings"
+)
+
+// CORSMiddleware applies a CORS policy. Preflight requests are answered here,
+// without reaching the route.
+func CORSMiddleware(cfg config.CORS) echo.MiddlewareFunc {
+	return middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOriginFunc:  AllowOrigin(cfg.AllowOrigins, cfg.AllowCredentials),
+		AllowMethods:     cfg.AllowMethods,
+		AllowHeaders:     cfg.AllowHeaders,
+		AllowCredentials: cfg.AllowCredentials,
+		ExposeHeaders:    cfg.ExposeHeaders,
+		MaxAge:           cfg.MaxAge,
+	})
+}
+
+// AllowOrigin returns a matcher for the origin patterns. Unlike plain glob
+// matching, "https://*.example.com" only matches subdomains of example.com
+// over HTTPS on the default port. "*" matches any origin unless credentials
+// are allowed, as browsers would then send cookies to any site. Invalid
+// patterns never match.
+func AllowOrigin(patterns []string, credentials bool) func(origin string) (bool, error) {
+	var parsed []originPattern
+	var anyOrigin bool
+
+	for _, pattern := range patterns {
+		if pattern == "*" {
+			anyOrigin = !credentials
+			continue
+		}
+
+		if p, ok := parseOrigin(pattern); ok {
+			parsed = append(parsed, p)
+		}
+	}
+
+	return func(origin string) (bool, error) {
+		if anyOrigin {
+			return true, nil
+		}
+
+		o, ok := parseOrigin(origin)
+		if !ok || o.wildcard {
+			return false, nil
+		}
+
+		for _, p := range parsed {
+			if p.matches(o) {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	}
+}
+
+type originPattern struct {
+	scheme   string
+	host     string
+	port     string
+	wildcard bool
+}
+
+func parseOrigin(origin string) (originPattern, bool) {
+	u, err := url.Parse(origin)
+	if err != nil || u.Scheme == "" || u.Host == "" || u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+		return originPattern{}, false
+	}
+
+	if u.Path != "" && u.Path != "/" {
+		return originPattern{}, false
+	}
+
+	p := originPattern{
+		scheme: strings.ToLower(u.Scheme),
+		host:   strings.ToLower(u.Hostname()),
+		port:   u.Port(),
+	}
+
+	if p.port == "" {
+		p.port = map[string]string{"http": "80", "https": "443"}[p.scheme]
+	}
+
+	if rest, ok := strings.CutPrefix(p.host, "*."); ok {
+		p.host, p.wildcard = rest, true
+	}
+
+	if p.host == "" || strings.Contains(p.host, "*") {
+		return originPattern{}, false
+	}
+
+	return p, true
+}
+
+func (p originPattern) matches(o originPattern) bool {
+	if p.scheme != o.scheme || p.port != o.port {
+		return false
+	}
+
+	if p.wildcard {
+		return strings.HasSuffix(o.host, "."+p.host)
+	}
+
+	return p.host == o.host
+}
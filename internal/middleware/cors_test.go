@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"github.com/go-playground/assert/v2"
+	"testing"
+)
+
+func TestAllowOrigin(t *testing.T) {
+	allow := AllowOrigin([]string{
+		"https://app.example.com",
+		"https://*.example.org",
+		"http://localhost:3000",
+		"not an origin",
+	}, true)
+
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"https://app.example.com", true},
+		{"https://APP.example.com:443", true},
+		{"http://app.example.com", false},
+		{"https://app.example.com:8443", false},
+		{"https://www.example.org", true},
+		{"https://a.b.example.org", true},
+		{"https://example.org", false},
+		{"https://evilexample.org", false},
+		{"https://example.org.evil.com", false},
+		{"https://*.example.org", false},
+		{"http://localhost:3000", true},
+		{"http://localhost:3001", false},
+		{"null", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			allowed, err := allow(tt.origin)
+
+			assert.Equal(t, err, nil)
+			assert.Equal(t, allowed, tt.allowed)
+		})
+	}
+}
+
+func TestAllowOriginWildcard(t *testing.T) {
+	allowed, _ := AllowOrigin([]string{"*"}, false)("https://anywhere.test")
+	assert.Equal(t, allowed, true)
+
+	// Any origin with credentials would hand cookies to every site.
+	allowed, _ = AllowOrigin([]string{"*"}, true)("https://anywhere.test")
+	assert.Equal(t, allowed, false)
+}
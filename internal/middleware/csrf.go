@@ -1,15 +1,135 @@
 package middleware
 
 import (
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/auth"
 	"github.com/Fortress-Digital/go-rest-skeleton/internal/config"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"mime"
+	"net/http"
+	"strings"
 )
 
+const (
+	csrfContextKey = "csrf"
+	csrfPendingKey = "csrf.pending"
+)
+
+// CSRFFormField holds the token of HTML forms, which cannot set the
+// X-CSRF-Token header.
+const CSRFFormField = "_csrf"
+
+// csrfExemptPrefixes lists paths called server-to-server, which carry their
+// own authentication and never hold the CSRF cookie, and the CSP report
+// endpoint, which browsers post to on their own.
+var csrfExemptPrefixes = []string{
+	"/hooks/",
+	CSPReportPath,
+}
+
+var csrfSameSite = map[string]http.SameSite{
+	"lax":    http.SameSiteLaxMode,
+	"strict": http.SameSiteStrictMode,
+	"none":   http.SameSiteNoneMode,
+}
+
 func CSRFMiddleware(cfg *config.Config) echo.MiddlewareFunc {
-	return middleware.CSRFWithConfig(middleware.CSRFConfig{
+	sameSite, ok := csrfSameSite[cfg.CSRF.CookieSameSite]
+	if !ok {
+		sameSite = http.SameSiteDefaultMode
+	}
+
+	csrf := middleware.CSRFWithConfig(middleware.CSRFConfig{
+		Skipper:        csrfSkipper,
+		ContextKey:     csrfContextKey,
+		CookieName:     cfg.CSRF.CookieName,
+		CookieDomain:   cfg.CSRF.CookieDomain,
 		CookiePath:     "/",
+		CookieMaxAge:   cfg.CSRF.CookieMaxAge,
+		CookieSameSite: sameSite,
 		CookieSecure:   cfg.Application.Env == "production",
 		CookieHTTPOnly: cfg.Application.Env == "production",
 	})
+
+	check := csrf(func(c echo.Context) error { return nil })
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		protected := csrf(next)
+
+		return func(c echo.Context) error {
+			formToken(c.Request())
+
+			// A bearer token or API key only exempts the request once it has
+			// authenticated it, see VerifyCSRF.
+			if tokenCredentials(c.Request()) {
+				c.Set(csrfPendingKey, check)
+				return next(c)
+			}
+
+			return protected(c)
+		}
+	}
+}
+
+// VerifyCSRF checks the token of a request whose check was deferred because
+// it carried a bearer token or API key. It is called by everything that
+// authenticates a request with a cookie or client certificate instead, which
+// browsers send on their own.
+func VerifyCSRF(c echo.Context) error {
+	check, ok := c.Get(csrfPendingKey).(echo.HandlerFunc)
+	if !ok {
+		return nil
+	}
+
+	c.Set(csrfPendingKey, nil)
+
+	return check(c)
+}
+
+// formToken copies the token of a URL-encoded form to the header the CSRF
+// middleware checks. Other bodies are left unread, so uploads are not parsed
+// before the token is checked.
+func formToken(r *http.Request) {
+	if r.Header.Get(echo.HeaderXCSRFToken) != "" {
+		return
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get(echo.HeaderContentType))
+	if mediaType != echo.MIMEApplicationForm {
+		return
+	}
+
+	if token := r.PostFormValue(CSRFFormField); token != "" {
+		r.Header.Set(echo.HeaderXCSRFToken, token)
+	}
+}
+
+// CSRFToken returns the token of the request, as set by CSRFMiddleware.
+func CSRFToken(c echo.Context) string {
+	token, _ := c.Get(csrfContextKey).(string)
+
+	return token
+}
+
+// csrfSkipper exempts server-to-server paths.
+func csrfSkipper(c echo.Context) bool {
+	for _, prefix := range csrfExemptPrefixes {
+		if strings.HasPrefix(c.Request().URL.Path, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// tokenCredentials reports whether the request carries a bearer token or API
+// key, which browsers never send on their own.
+func tokenCredentials(r *http.Request) bool {
+	if _, ok := auth.BearerToken(r); ok {
+		return true
+	}
+
+	_, ok := auth.APIKey(r)
+
+	return ok
 }
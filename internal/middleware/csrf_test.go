@@ -2,12 +2,16 @@ package middleware
 
 import (
 	"fmt"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/auth"
 	"github.com/Fortress-Digital/go-rest-skeleton/internal/config"
 	"github.com/go-playground/assert/v2"
 	"github.com/labstack/echo/v4"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestCSRFMiddleware(t *testing.T) {
@@ -78,3 +82,157 @@ func TestCSRFMiddleware(t *testing.T) {
 		}
 	}
 }
+
+func csrfConfig() *config.Config {
+	cfg := config.Default()
+	cfg.Application.Env = "production"
+	cfg.CSRF = config.CSRF{
+		CookieName:     "app_csrf",
+		CookieDomain:   "example.com",
+		CookieSameSite: "strict",
+		CookieMaxAge:   3600,
+	}
+
+	return cfg
+}
+
+func TestCSRFDoubleSubmit(t *testing.T) {
+	e := echo.New()
+	e.Use(CSRFMiddleware(csrfConfig()))
+	e.GET("/csrf", func(c echo.Context) error { return c.String(http.StatusOK, CSRFToken(c)) })
+	e.POST("/login", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/csrf", nil))
+
+	token := rec.Body.String()
+	assert.NotEqual(t, token, "")
+
+	cookies := rec.Result().Cookies()
+	assert.Equal(t, len(cookies), 1)
+	assert.Equal(t, cookies[0].Name, "app_csrf")
+	assert.Equal(t, cookies[0].Value, token)
+	assert.Equal(t, cookies[0].Domain, "example.com")
+	assert.Equal(t, cookies[0].SameSite, http.SameSiteStrictMode)
+	assert.Equal(t, cookies[0].Expires.After(time.Now().Add(59*time.Minute)), true)
+	assert.Equal(t, cookies[0].Secure, true)
+	assert.Equal(t, cookies[0].HttpOnly, true)
+
+	post := func(header string) int {
+		req := httptest.NewRequest(http.MethodPost, "/login", nil)
+		req.AddCookie(&http.Cookie{Name: "app_csrf", Value: token})
+		if header != "" {
+			req.Header.Set(echo.HeaderXCSRFToken, header)
+		}
+
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		return rec.Code
+	}
+
+	assert.Equal(t, post(token), http.StatusOK)
+	assert.Equal(t, post(""), http.StatusBadRequest)
+	assert.Equal(t, post("forged"), http.StatusForbidden)
+}
+
+func TestCSRFFormField(t *testing.T) {
+	e := echo.New()
+	e.Use(CSRFMiddleware(csrfConfig()))
+	e.POST("/verify", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+
+	post := func(contentType string, token string) int {
+		form := url.Values{CSRFFormField: {token}}
+		req := httptest.NewRequest(http.MethodPost, "/verify", strings.NewReader(form.Encode()))
+		req.Header.Set(echo.HeaderContentType, contentType)
+		req.AddCookie(&http.Cookie{Name: "app_csrf", Value: "token"})
+
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		return rec.Code
+	}
+
+	assert.Equal(t, post(echo.MIMEApplicationForm, "token"), http.StatusOK)
+	assert.Equal(t, post(echo.MIMEApplicationForm, "forged"), http.StatusForbidden)
+	assert.Equal(t, post(echo.MIMETextPlain, "token"), http.StatusBadRequest)
+}
+
+func TestCSRFSkipper(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		skipped bool
+	}{
+		{"Hook", "/hooks/send-email", true},
+		{"CSP report", "/csp-report", true},
+		{"Other path", "/login", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := echo.New().NewContext(httptest.NewRequest(http.MethodPost, tt.path, nil), httptest.NewRecorder())
+
+			assert.Equal(t, csrfSkipper(c), tt.skipped)
+		})
+	}
+}
+
+func TestCSRFExemptsTokenAuthenticatedRequests(t *testing.T) {
+	bearer := authenticatorFunc(func(c echo.Context) (*auth.Principal, error) {
+		if token, ok := auth.BearerToken(c.Request()); ok && token == "valid" {
+			return &auth.Principal{Type: auth.PrincipalUser, Method: auth.MethodBearer}, nil
+		}
+
+		return nil, nil
+	})
+	session := authenticatorFunc(func(c echo.Context) (*auth.Principal, error) {
+		if _, err := c.Cookie("session"); err == nil {
+			return &auth.Principal{Type: auth.PrincipalUser, Method: auth.MethodSession}, nil
+		}
+
+		return nil, nil
+	})
+
+	e := echo.New()
+	e.Use(CSRFMiddleware(csrfConfig()))
+	e.POST("/files", func(c echo.Context) error { return c.NoContent(http.StatusOK) }, AuthMiddleware(bearer, session))
+
+	tests := []struct {
+		name     string
+		header   string
+		value    string
+		session  bool
+		token    string
+		expected int
+	}{
+		{"Bearer token", echo.HeaderAuthorization, "Bearer valid", false, "", http.StatusOK},
+		{"Bearer token with session cookie", echo.HeaderAuthorization, "Bearer valid", true, "", http.StatusOK},
+		{"Session cookie with token", "", "", true, "token", http.StatusOK},
+		{"Session cookie with forged token", "", "", true, "forged", http.StatusForbidden},
+		{"Bogus API key with session cookie", "X-API-Key", "x", true, "forged", http.StatusForbidden},
+		{"Bogus API key with session cookie and no token", "X-API-Key", "x", true, "", http.StatusBadRequest},
+		{"Bogus bearer token with session cookie", echo.HeaderAuthorization, "Bearer bogus", true, "forged", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/files", nil)
+			req.AddCookie(&http.Cookie{Name: "app_csrf", Value: "token"})
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			if tt.session {
+				req.AddCookie(&http.Cookie{Name: "session", Value: "s1"})
+			}
+			if tt.token != "" {
+				req.Header.Set(echo.HeaderXCSRFToken, tt.token)
+			}
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, rec.Code, tt.expected)
+		})
+	}
+}
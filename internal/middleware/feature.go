@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/config"
+	"github.com/labstack/echo/v4"
+)
+
+// FeatureMiddleware hides routes behind a feature toggle, answering 404 while
+// the toggle is off.
+func FeatureMiddleware(live *config.Live, feature string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !live.Feature(feature) {
+				return echo.ErrNotFound
+			}
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"fmt"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/auth"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/http/response"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"slices"
+)
+
+// RequireScope rejects API key and service principals without scope. Users
+// signed in with a JWT act with their full rights and always pass.
+func RequireScope(scope string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			principal := auth.FromContext(c)
+			if principal == nil {
+				return unauthorized(auth.ErrUnauthenticated)
+			}
+
+			if principal.Type != auth.PrincipalUser && !principal.HasScope(scope) {
+				return forbidden(fmt.Sprintf("the %q scope is required", scope))
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// RequirePrincipal only lets the given principal types through, e.g. to stop
+// API keys from managing API keys.
+func RequirePrincipal(types ...auth.PrincipalType) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			principal := auth.FromContext(c)
+			if principal == nil {
+				return unauthorized(auth.ErrUnauthenticated)
+			}
+
+			if !slices.Contains(types, principal.Type) {
+				return forbidden(fmt.Sprintf("not allowed for %s credentials", principal.Type))
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// RequireRole only lets principals with one of the application roles through.
+func RequireRole(roles ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			principal := auth.FromContext(c)
+			if principal == nil {
+				return unauthorized(auth.ErrUnauthenticated)
+			}
+
+			if !slices.Contains(roles, principal.Role) {
+				return forbidden("not allowed for your role")
+			}
+
+			return next(c)
+		}
+	}
+}
+
+func forbidden(message string) error {
+	return response.ErrorResponse(http.StatusForbidden, response.Error{
+		Message: message,
+	})
+}
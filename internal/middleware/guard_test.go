@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/auth"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/http/response"
+	"github.com/go-playground/assert/v2"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func runGuard(guard echo.MiddlewareFunc, principal *auth.Principal) error {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	c := echo.New().NewContext(req, httptest.NewRecorder())
+
+	if principal != nil {
+		auth.SetPrincipal(c, principal)
+	}
+
+	return guard(func(c echo.Context) error { return nil })(c)
+}
+
+func TestRequireScope(t *testing.T) {
+	forbiddenErr := response.ErrorResponse(http.StatusForbidden, response.Error{Message: `the "files:write" scope is required`})
+
+	tests := []struct {
+		name        string
+		principal   *auth.Principal
+		expectedErr error
+	}{
+		{"User", &auth.Principal{Type: auth.PrincipalUser}, nil},
+		{"API key with scope", &auth.Principal{Type: auth.PrincipalAPIKey, Scopes: []string{"files:write"}}, nil},
+		{"API key without scope", &auth.Principal{Type: auth.PrincipalAPIKey, Scopes: []string{"files:read"}}, forbiddenErr},
+		{"Service without scope", &auth.Principal{Type: auth.PrincipalService}, forbiddenErr},
+		{
+			"Anonymous",
+			nil,
+			response.ErrorResponse(http.StatusUnauthorized, response.Error{Message: auth.ErrUnauthenticated.Error()}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, runGuard(RequireScope("files:write"), tt.principal), tt.expectedErr)
+		})
+	}
+}
+
+func TestRequirePrincipal(t *testing.T) {
+	guard := RequirePrincipal(auth.PrincipalUser)
+
+	assert.Equal(t, runGuard(guard, &auth.Principal{Type: auth.PrincipalUser}), nil)
+	assert.Equal(t, runGuard(guard, &auth.Principal{Type: auth.PrincipalAPIKey}), response.ErrorResponse(http.StatusForbidden, response.Error{
+		Message: "not allowed for api_key credentials",
+	}))
+}
+
+func TestRequireRole(t *testing.T) {
+	guard := RequireRole(auth.RoleAdmin)
+
+	assert.Equal(t, runGuard(guard, &auth.Principal{Type: auth.PrincipalUser, Role: auth.RoleAdmin}), nil)
+	assert.Equal(t, runGuard(guard, &auth.Principal{Type: auth.PrincipalUser, Role: "member"}), response.ErrorResponse(http.StatusForbidden, response.Error{
+		Message: "not allowed for your role",
+	}))
+}
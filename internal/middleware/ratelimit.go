@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/apikey"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/auth"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/config"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/http/response"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/log"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/ratelimit"
+	"github.com/labstack/echo/v4"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RateLimitMiddleware applies the named policy, counting requests per client
+// as selected by its key. It sets the RateLimit-Limit, RateLimit-Remaining,
+// RateLimit-Reset and RateLimit-Policy headers, plus Retry-After when the
+// request is denied. Store failures are logged and let the request through.
+func RateLimitMiddleware(limiter *ratelimit.Limiter, name string, cfg config.RateLimitPolicy, log log.LoggerInterface) echo.MiddlewareFunc {
+	policy := ratelimit.Policy{
+		Algorithm: cfg.Algorithm,
+		Limit:     cfg.Limit,
+		Window:    time.Duration(cfg.Window) * time.Second,
+		Burst:     cfg.Burst,
+	}
+
+	policyHeader := strconv.Itoa(cfg.Limit) + ";w=" + strconv.Itoa(cfg.Window)
+	if cfg.Algorithm == ratelimit.TokenBucket && cfg.Burst > 0 {
+		policyHeader += ";burst=" + strconv.Itoa(cfg.Burst)
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			kind, identity := rateLimitIdentity(c, cfg.Key)
+
+			result, err := limiter.Allow(c.Request().Context(), name+":"+kind+":"+identity, policy)
+			if err != nil {
+				log.Warn("Rate limit unavailable", "policy", name, "error", err)
+				return next(c)
+			}
+
+			header := c.Response().Header()
+			header.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			header.Set("RateLimit-Reset", seconds(result.Reset))
+			header.Set("RateLimit-Policy", policyHeader)
+
+			if !result.Allowed {
+				header.Set("Retry-After", seconds(result.RetryAfter))
+
+				return response.ErrorResponse(http.StatusTooManyRequests, response.Error{
+					Message: "too many requests, please retry later",
+				})
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// rateLimitIdentity returns the kind and value of the client identity. API
+// key policies count other principals per user, and both fall back to the IP
+// for anonymous requests.
+func rateLimitIdentity(c echo.Context, key string) (string, string) {
+	principal := auth.FromContext(c)
+	if principal == nil || key == "ip" {
+		return "ip", c.RealIP()
+	}
+
+	if key == "api_key" && principal.Type == auth.PrincipalAPIKey {
+		if token, ok := auth.APIKey(c.Request()); ok {
+			if prefix, _, ok := apikey.Parse(token); ok {
+				return "api_key", prefix
+			}
+		}
+	}
+
+	return "user", principal.ID
+}
+
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/auth"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/config"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/http/response"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/ratelimit"
+	"github.com/go-playground/assert/v2"
+	"github.com/labstack/echo/v4"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func runRateLimit(m echo.MiddlewareFunc, req *http.Request, principal *auth.Principal) (*httptest.ResponseRecorder, error) {
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+
+	if principal != nil {
+		auth.SetPrincipal(c, principal)
+	}
+
+	return rec, m(func(c echo.Context) error { return c.NoContent(http.StatusOK) })(c)
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	m := RateLimitMiddleware(ratelimit.NewLimiter(ratelimit.NewMemoryStore()), "login", config.RateLimitPolicy{
+		Algorithm: ratelimit.SlidingWindow,
+		Limit:     2,
+		Window:    60,
+		Key:       "ip",
+	}, nil)
+
+	for remaining := 1; remaining >= 0; remaining-- {
+		rec, err := runRateLimit(m, httptest.NewRequest(http.MethodPost, "/login", nil), nil)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, rec.Code, http.StatusOK)
+		assert.Equal(t, rec.Header().Get("RateLimit-Limit"), "2")
+		assert.Equal(t, rec.Header().Get("RateLimit-Remaining"), strconv.Itoa(remaining))
+		assert.Equal(t, rec.Header().Get("RateLimit-Policy"), "2;w=60")
+	}
+
+	rec, err := runRateLimit(m, httptest.NewRequest(http.MethodPost, "/login", nil), nil)
+
+	assert.Equal(t, err, response.ErrorResponse(http.StatusTooManyRequests, response.Error{
+		Message: "too many requests, please retry later",
+	}))
+	assert.Equal(t, rec.Header().Get("RateLimit-Remaining"), "0")
+	assert.NotEqual(t, rec.Header().Get("Retry-After"), "")
+
+	// Another client has its own bucket.
+	req := httptest.NewRequest(http.MethodPost, "/login", nil)
+	req.RemoteAddr = "192.0.2.10:1234"
+
+	_, err = runRateLimit(m, req, nil)
+	assert.Equal(t, err, nil)
+}
+
+type unavailableStore struct{}
+
+func (unavailableStore) Update(context.Context, string, func(value string) (string, time.Duration)) error {
+	return errors.New("connection refused")
+}
+
+func TestRateLimitMiddlewareLetsRequestsThroughWhenUnavailable(t *testing.T) {
+	var logged bytes.Buffer
+	m := RateLimitMiddleware(ratelimit.NewLimiter(unavailableStore{}), "login", config.RateLimitPolicy{
+		Algorithm: ratelimit.SlidingWindow,
+		Limit:     2,
+		Window:    60,
+		Key:       "ip",
+	}, slog.New(slog.NewTextHandler(&logged, nil)))
+
+	rec, err := runRateLimit(m, httptest.NewRequest(http.MethodPost, "/login", nil), nil)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, rec.Header().Get("RateLimit-Limit"), "")
+	assert.Equal(t, strings.Contains(logged.String(), `level=WARN msg="Rate limit unavailable" policy=login error="connection refused"`), true)
+}
+
+func TestRateLimitIdentity(t *testing.T) {
+	user := &auth.Principal{Type: auth.PrincipalUser, ID: "user-1"}
+	key := &auth.Principal{Type: auth.PrincipalAPIKey, ID: "user-1"}
+
+	tests := []struct {
+		name      string
+		key       string
+		principal *auth.Principal
+		kind      string
+		identity  string
+	}{
+		{"IP", "ip", user, "ip", "192.0.2.1"},
+		{"Anonymous user", "user", nil, "ip", "192.0.2.1"},
+		{"User", "user", user, "user", "user-1"},
+		{"API key", "api_key", key, "api_key", "sk_abc123"},
+		{"API key policy for user", "api_key", user, "user", "user-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = "192.0.2.1:1234"
+			req.Header.Set("X-API-Key", "sk_abc123_0123456789abcdef0123456789abcdef")
+
+			c := echo.New().NewContext(req, httptest.NewRecorder())
+			if tt.principal != nil {
+				auth.SetPrincipal(c, tt.principal)
+			}
+
+			kind, identity := rateLimitIdentity(c, tt.key)
+
+			assert.Equal(t, kind, tt.kind)
+			assert.Equal(t, identity, tt.identity)
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/config"
+	"github.com/labstack/echo/v4"
+	"reflect"
+	"sync/atomic"
+)
+
+// Reloadable rebuilds a middleware whenever the configuration section picked
+// by section changes, so settings such as CORS origins apply without a
+// restart. State kept by the previous middleware, e.g. rate limit counters,
+// starts afresh.
+func Reloadable(live *config.Live, section func(cfg *config.Config) any, build func(cfg *config.Config) echo.MiddlewareFunc) echo.MiddlewareFunc {
+	var current atomic.Pointer[echo.MiddlewareFunc]
+
+	mw := build(live.Current())
+	current.Store(&mw)
+
+	live.Subscribe(func(previous *config.Config, next *config.Config) {
+		if reflect.DeepEqual(section(previous), section(next)) {
+			return
+		}
+
+		mw := build(next)
+		current.Store(&mw)
+	})
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			return (*current.Load())(next)(c)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/config"
+	"github.com/go-playground/assert/v2"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func headerMiddleware(cfg *config.Config) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Response().Header().Set("X-Origins", cfg.CORS.AllowOrigins[0])
+			return next(c)
+		}
+	}
+}
+
+func TestReloadableRebuildsOnSectionChange(t *testing.T) {
+	cfg := config.Default()
+	cfg.CORS.AllowOrigins = []string{"*"}
+	live := config.NewLive(cfg)
+
+	builds := 0
+	mw := Reloadable(live, func(cfg *config.Config) any { return cfg.CORS }, func(cfg *config.Config) echo.MiddlewareFunc {
+		builds++
+		return headerMiddleware(cfg)
+	})
+
+	e := echo.New()
+	e.GET("/", func(c echo.Context) error { return c.NoContent(http.StatusOK) }, mw)
+
+	serve := func() string {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		return rec.Header().Get("X-Origins")
+	}
+
+	assert.Equal(t, serve(), "*")
+
+	unrelated := config.Default()
+	unrelated.CORS.AllowOrigins = []string{"*"}
+	unrelated.Log.Level = "debug"
+	live.Apply(unrelated)
+	assert.Equal(t, builds, 1)
+
+	changed := config.Default()
+	changed.CORS.AllowOrigins = []string{"https://example.com"}
+	live.Apply(changed)
+
+	assert.Equal(t, builds, 2)
+	assert.Equal(t, serve(), "https://example.com")
+}
+
+func TestFeatureMiddleware(t *testing.T) {
+	cfg := config.Default()
+	cfg.Features = map[string]bool{"storage": false}
+	live := config.NewLive(cfg)
+
+	e := echo.New()
+	e.GET("/", func(c echo.Context) error { return c.NoContent(http.StatusOK) }, FeatureMiddleware(live, "storage"))
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, rec.Code, http.StatusNotFound)
+
+	enabled := config.Default()
+	enabled.Features = map[string]bool{"storage": true}
+	live.Apply(enabled)
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, rec.Code, http.StatusOK)
+}
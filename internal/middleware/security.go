@@ -0,0 +1,189 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/config"
+	"github.com/labstack/echo/v4"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	cspNonceContextKey = "csp.nonce"
+
+	// CSPReportPath receives the violation reports of browsers.
+	CSPReportPath = "/csp-report"
+)
+
+// ContentSecurityPolicy builds a Content-Security-Policy header value. The
+// 'nonce' source stands for the nonce of each request.
+type ContentSecurityPolicy struct {
+	directives map[string][]string
+}
+
+func NewContentSecurityPolicy() *ContentSecurityPolicy {
+	return &ContentSecurityPolicy{directives: map[string][]string{}}
+}
+
+// Directive adds sources to a directive. A directive without sources, such
+// as upgrade-insecure-requests, is written on its own.
+func (p *ContentSecurityPolicy) Directive(name string, sources ...string) *ContentSecurityPolicy {
+	p.directives[name] = append(p.directives[name], sources...)
+
+	return p
+}
+
+// UsesNonce reports whether the policy needs a nonce per request.
+func (p *ContentSecurityPolicy) UsesNonce() bool {
+	for _, sources := range p.directives {
+		if slices.Contains(sources, "'nonce'") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Build renders the directives in alphabetical order, so the header is
+// stable.
+func (p *ContentSecurityPolicy) Build(nonce string) string {
+	names := make([]string, 0, len(p.directives))
+	for name := range p.directives {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		directive := []string{name}
+
+		for _, source := range p.directives[name] {
+			if source == "'nonce'" {
+				source = "'nonce-" + nonce + "'"
+			}
+
+			directive = append(directive, source)
+		}
+
+		parts = append(parts, strings.Join(directive, " "))
+	}
+
+	return strings.Join(parts, "; ")
+}
+
+// SecurityHeadersMiddleware sets the configured security headers on every
+// response.
+func SecurityHeadersMiddleware(cfg config.SecurityHeaders) echo.MiddlewareFunc {
+	if !cfg.Enabled {
+		return func(next echo.HandlerFunc) echo.HandlerFunc { return next }
+	}
+
+	static := map[string]string{}
+
+	if cfg.ContentTypeOptions {
+		static["X-Content-Type-Options"] = "nosniff"
+	}
+
+	if cfg.FrameOptions != "" {
+		static["X-Frame-Options"] = cfg.FrameOptions
+	}
+
+	if cfg.ReferrerPolicy != "" {
+		static["Referrer-Policy"] = cfg.ReferrerPolicy
+	}
+
+	if cfg.PermissionsPolicy != "" {
+		static["Permissions-Policy"] = cfg.PermissionsPolicy
+	}
+
+	hsts := hstsHeader(cfg.HSTS)
+
+	var csp *ContentSecurityPolicy
+	cspHeader := "Content-Security-Policy"
+
+	if cfg.CSP.Enabled {
+		csp = NewContentSecurityPolicy()
+		for name, sources := range cfg.CSP.Directives {
+			csp.Directive(name, sources...)
+		}
+
+		if cfg.CSP.Report {
+			csp.Directive("report-uri", CSPReportPath)
+		}
+
+		if cfg.CSP.ReportOnly {
+			cspHeader = "Content-Security-Policy-Report-Only"
+		}
+	}
+
+	usesNonce := csp != nil && csp.UsesNonce()
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			header := c.Response().Header()
+
+			for name, value := range static {
+				header.Set(name, value)
+			}
+
+			if hsts != "" && c.Scheme() == "https" {
+				header.Set("Strict-Transport-Security", hsts)
+			}
+
+			if csp != nil {
+				var nonce string
+
+				if usesNonce {
+					var err error
+					if nonce, err = newNonce(); err != nil {
+						return err
+					}
+
+					c.Set(cspNonceContextKey, nonce)
+				}
+
+				header.Set(cspHeader, csp.Build(nonce))
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// CSPNonce returns the nonce of the request, for the script and style tags of
+// rendered pages.
+func CSPNonce(c echo.Context) string {
+	nonce, _ := c.Get(cspNonceContextKey).(string)
+
+	return nonce
+}
+
+func hstsHeader(cfg config.HSTS) string {
+	if cfg.MaxAge <= 0 {
+		return ""
+	}
+
+	value := "max-age=" + strconv.Itoa(cfg.MaxAge)
+
+	if cfg.IncludeSubdomains {
+		value += "; includeSubDomains"
+	}
+
+	if cfg.Preload {
+		value += "; preload"
+	}
+
+	return value
+}
+
+func newNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(b), nil
+}
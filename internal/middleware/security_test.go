@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/config"
+	"github.com/go-playground/assert/v2"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestContentSecurityPolicyBuild(t *testing.T) {
+	csp := NewContentSecurityPolicy().
+		Directive("script-src", "'self'", "'nonce'").
+		Directive("default-src", "'none'").
+		Directive("upgrade-insecure-requests")
+
+	assert.Equal(t, csp.UsesNonce(), true)
+	assert.Equal(t, csp.Build("abc"), "default-src 'none'; script-src 'self' 'nonce-abc'; upgrade-insecure-requests")
+	assert.Equal(t, NewContentSecurityPolicy().Directive("default-src", "'self'").UsesNonce(), false)
+}
+
+func serveSecurityHeaders(cfg config.SecurityHeaders, req *http.Request) (*httptest.ResponseRecorder, string) {
+	var nonce string
+
+	e := echo.New()
+	e.Use(SecurityHeadersMiddleware(cfg))
+	e.GET("/", func(c echo.Context) error {
+		nonce = CSPNonce(c)
+		return c.NoContent(http.StatusOK)
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	return rec, nonce
+}
+
+func TestSecurityHeadersMiddleware(t *testing.T) {
+	cfg := config.Default().SecurityHeaders
+	cfg.HSTS = config.HSTS{MaxAge: 31536000, IncludeSubdomains: true, Preload: true}
+	cfg.CSP.Report = true
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(echo.HeaderXForwardedProto, "https")
+
+	rec, _ := serveSecurityHeaders(cfg, req)
+	header := rec.Header()
+
+	assert.Equal(t, header.Get("Strict-Transport-Security"), "max-age=31536000; includeSubDomains; preload")
+	assert.Equal(t, header.Get("X-Content-Type-Options"), "nosniff")
+	assert.Equal(t, header.Get("X-Frame-Options"), "DENY")
+	assert.Equal(t, header.Get("Referrer-Policy"), "no-referrer")
+	assert.Equal(t, header.Get("Permissions-Policy"), "camera=(), geolocation=(), microphone=()")
+	assert.Equal(t, header.Get("Content-Security-Policy"), "default-src 'none'; frame-ancestors 'none'; report-uri /csp-report")
+}
+
+func TestSecurityHeadersSkipsHSTSOverHTTP(t *testing.T) {
+	cfg := config.Default().SecurityHeaders
+	cfg.HSTS.MaxAge = 300
+
+	rec, _ := serveSecurityHeaders(cfg, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, rec.Header().Get("Strict-Transport-Security"), "")
+}
+
+func TestSecurityHeadersNonce(t *testing.T) {
+	cfg := config.Default().SecurityHeaders
+	cfg.CSP.ReportOnly = true
+	cfg.CSP.Directives = map[string][]string{"script-src": {"'nonce'", "'strict-dynamic'"}}
+
+	first, nonce := serveSecurityHeaders(cfg, httptest.NewRequest(http.MethodGet, "/", nil))
+	second, other := serveSecurityHeaders(cfg, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.NotEqual(t, nonce, "")
+	assert.NotEqual(t, nonce, other)
+	assert.Equal(t, first.Header().Get("Content-Security-Policy"), "")
+	assert.Equal(t, first.Header().Get("Content-Security-Policy-Report-Only"), "script-src 'nonce-"+nonce+"' 'strict-dynamic'")
+	assert.Equal(t, strings.Contains(second.Header().Get("Content-Security-Policy-Report-Only"), other), true)
+}
+
+func TestSecurityHeadersDisabled(t *testing.T) {
+	cfg := config.Default().SecurityHeaders
+	cfg.Enabled = false
+
+	rec, _ := serveSecurityHeaders(cfg, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, rec.Header().Get("X-Frame-Options"), "")
+	assert.Equal(t, rec.Header().Get("Content-Security-Policy"), "")
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/http/response"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/supabase"
+	"github.com/labstack/echo/v4"
+	"io"
+	"net/http"
+)
+
+const maxWebhookBodySize = 1 << 20
+
+func WebhookMiddleware(verifier *supabase.WebhookVerifier) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+
+			body, err := io.ReadAll(http.MaxBytesReader(c.Response(), req.Body, maxWebhookBodySize))
+
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				return response.ErrorResponse(http.StatusRequestEntityTooLarge, response.Error{
+					Message: "webhook body is too large",
+				})
+			}
+
+			if err != nil {
+				return response.BadRequestResponse(err)
+			}
+
+			if err = verifier.Verify(req.Header, body); err != nil {
+				return response.ErrorResponse(http.StatusUnauthorized, response.Error{
+					Message: err.Error(),
+				})
+			}
+
+			req.Body = io.NopCloser(bytes.NewReader(body))
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"bytes"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/http/response"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/supabase"
+	"github.com/go-playground/assert/v2"
+	"github.com/labstack/echo/v4"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWebhookMiddleware(t *testing.T) {
+	verifier, _ := supabase.NewWebhookVerifier("v1,whsec_c2VjcmV0LWtleS1mb3ItdGVzdHM=", time.Minute)
+	body := `{"user_id":"123"}`
+
+	tests := []struct {
+		name      string
+		signature string
+		expected  error
+	}{
+		{
+			"Valid signature",
+			verifier.Sign("msg_1", time.Now(), []byte(body)),
+			nil,
+		},
+		{
+			"Invalid signature",
+			"v1,invalid",
+			response.ErrorResponse(http.StatusUnauthorized, response.Error{
+				Message: supabase.ErrWebhookInvalidSignature.Error(),
+			}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/hooks/custom-access-token", strings.NewReader(body))
+			req.Header.Set(supabase.WebhookIDHeader, "msg_1")
+			req.Header.Set(supabase.WebhookTimestampHeader, strconv.FormatInt(time.Now().Unix(), 10))
+			req.Header.Set(supabase.WebhookSignatureHeader, tt.signature)
+
+			c := echo.New().NewContext(req, rec)
+
+			var received string
+			h := WebhookMiddleware(verifier)(func(c echo.Context) error {
+				data, _ := io.ReadAll(c.Request().Body)
+				received = string(data)
+				return c.NoContent(http.StatusOK)
+			})
+
+			err := h(c)
+
+			assert.Equal(t, err, tt.expected)
+			if tt.expected == nil {
+				assert.Equal(t, received, body)
+			}
+		})
+	}
+}
+
+func TestWebhookMiddlewareRejectsOversizeBody(t *testing.T) {
+	verifier, _ := supabase.NewWebhookVerifier("v1,whsec_c2VjcmV0LWtleS1mb3ItdGVzdHM=", time.Minute)
+	body := []byte(`{"user_id":"` + strings.Repeat("a", maxWebhookBodySize) + `"}`)
+
+	req := httptest.NewRequest(http.MethodPost, "/hooks/custom-access-token", bytes.NewReader(body))
+	req.Header.Set(supabase.WebhookIDHeader, "msg_1")
+	req.Header.Set(supabase.WebhookTimestampHeader, strconv.FormatInt(time.Now().Unix(), 10))
+	req.Header.Set(supabase.WebhookSignatureHeader, verifier.Sign("msg_1", time.Now(), body))
+
+	c := echo.New().NewContext(req, httptest.NewRecorder())
+
+	err := WebhookMiddleware(verifier)(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})(c)
+
+	assert.Equal(t, err.(*echo.HTTPError).Code, http.StatusRequestEntityTooLarge)
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// APIKey is a long-lived credential for integrations acting on behalf of a
+// user. Only the visible prefix and a hash of the secret are stored.
+type APIKey struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     string     `json:"userId" gorm:"type:varchar(36);not null;index"`
+	Name       string     `json:"name" gorm:"type:varchar(255);not null"`
+	Prefix     string     `json:"prefix" gorm:"type:varchar(64);not null;uniqueIndex"`
+	SecretHash string     `json:"-" gorm:"type:char(64);not null"`
+	Scopes     []string   `json:"scopes" gorm:"type:text;serializer:json"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	RevokedAt  *time.Time `json:"revokedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+}
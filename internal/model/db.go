@@ -26,7 +26,22 @@ func NewDB(cfg *config.Config, log log.LoggerInterface) (*gorm.DB, error) {
 		return nil, err
 	}
 
-	// db.AutoMigrate(&Test{})
-
 	return db, nil
 }
+
+// models lists every table managed by Migrate.
+var models = []any{
+	&UserClaim{},
+	&Job{},
+	&ScheduledTask{},
+	&ScheduledTaskLock{},
+	&APIKey{},
+	&RateLimitCounter{},
+	&Session{},
+	&Invitation{},
+}
+
+// Migrate creates missing tables, columns and indexes for every model.
+func Migrate(db *gorm.DB) error {
+	return db.AutoMigrate(models...)
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// Invitation lets its holders register while registration is invite only.
+// Only a hash of the code is stored. An Email restricts the invitation to
+// that address.
+type Invitation struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	CodeHash  string     `json:"-" gorm:"type:char(64);not null;uniqueIndex"`
+	Email     string     `json:"email" gorm:"type:varchar(255);not null"`
+	MaxUses   int        `json:"maxUses" gorm:"not null"`
+	Uses      int        `json:"uses" gorm:"not null;default:0"`
+	CreatedBy string     `json:"createdBy" gorm:"type:varchar(36);not null"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	RevokedAt *time.Time `json:"revokedAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+const (
+	JobPending   = "pending"
+	JobRunning   = "running"
+	JobCompleted = "completed"
+	JobDead      = "dead"
+)
+
+type Job struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	Name        string     `json:"name" gorm:"type:varchar(255);not null;index"`
+	Payload     string     `json:"payload" gorm:"type:text;not null"`
+	Status      string     `json:"status" gorm:"type:varchar(16);not null;index:idx_jobs_status_available"`
+	Attempts    int        `json:"attempts" gorm:"not null;default:0"`
+	MaxAttempts int        `json:"maxAttempts" gorm:"not null"`
+	AvailableAt time.Time  `json:"availableAt" gorm:"not null;index:idx_jobs_status_available"`
+	ReservedAt  *time.Time `json:"reservedAt"`
+	LastError   string     `json:"lastError" gorm:"type:text"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// RateLimitCounter holds the state of one rate limit bucket, encoded by the
+// algorithm that owns it.
+type RateLimitCounter struct {
+	Bucket    string    `json:"bucket" gorm:"primaryKey;type:varchar(255)"`
+	Value     string    `json:"value" gorm:"type:varchar(255);not null"`
+	ExpiresAt time.Time `json:"expiresAt" gorm:"not null;index"`
+}
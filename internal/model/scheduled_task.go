@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+const (
+	TaskSucceeded = "succeeded"
+	TaskFailed    = "failed"
+)
+
+// ScheduledTask records the outcome of the most recent run of a task.
+type ScheduledTask struct {
+	Name         string    `json:"name" gorm:"primaryKey;type:varchar(255)"`
+	LastRunAt    time.Time `json:"lastRunAt"`
+	LastDuration int64     `json:"lastDuration"`
+	LastStatus   string    `json:"lastStatus" gorm:"type:varchar(16)"`
+	LastError    string    `json:"lastError" gorm:"type:text"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// ScheduledTaskLock is claimed by the first replica to insert it, so each
+// tick of a task runs once across the cluster.
+type ScheduledTaskLock struct {
+	Name      string    `json:"name" gorm:"primaryKey;type:varchar(255)"`
+	Tick      time.Time `json:"tick" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"createdAt" gorm:"index"`
+}
@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// Session tracks a Supabase login session, so users can list and revoke
+// them. Sessions started with a session cookie also keep the Supabase tokens,
+// encrypted, only the SHA-256 of the cookie value being stored.
+type Session struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	SessionID      string     `json:"-" gorm:"type:varchar(36);not null;uniqueIndex"`
+	TokenHash      *string    `json:"-" gorm:"type:char(64);uniqueIndex"`
+	UserID         string     `json:"-" gorm:"type:varchar(36);not null;index"`
+	Email          string     `json:"-" gorm:"type:varchar(255);not null"`
+	UserAgent      string     `json:"userAgent" gorm:"type:varchar(512);not null"`
+	IP             string     `json:"ip" gorm:"type:varchar(45);not null"`
+	AccessToken    string     `json:"-" gorm:"type:text;not null"`
+	RefreshToken   string     `json:"-" gorm:"type:text;not null"`
+	TokenExpiresAt time.Time  `json:"-"`
+	LastSeenAt     time.Time  `json:"lastSeenAt"`
+	RevokedAt      *time.Time `json:"-"`
+	ExpiresAt      time.Time  `json:"expiresAt" gorm:"index"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+}
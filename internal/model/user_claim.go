@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+type UserClaim struct {
+	UserID    string    `json:"userId" gorm:"primaryKey;type:varchar(36)"`
+	TenantID  string    `json:"tenantId" gorm:"type:varchar(36);index"`
+	Role      string    `json:"role" gorm:"type:varchar(64);not null"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
@@ -0,0 +1,168 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	TokenBucket   = "token_bucket"
+	SlidingWindow = "sliding_window"
+)
+
+// Policy allows Limit requests per Window. The token bucket lets up to Burst
+// requests through at once, Limit when unset, then refills at a steady rate.
+// The sliding window weighs the previous window by how much of it overlaps
+// the last Window, which smooths the bursts fixed windows allow at their
+// boundaries.
+type Policy struct {
+	Algorithm string
+	Limit     int
+	Window    time.Duration
+	Burst     int
+}
+
+// Result describes the decision for one request, in the terms of the
+// RateLimit header fields.
+type Result struct {
+	Allowed bool
+	Limit   int
+	// Remaining is the number of requests still allowed right now.
+	Remaining int
+	// Reset is the time until the quota is fully available again.
+	Reset time.Duration
+	// RetryAfter is the time until a denied request would be allowed.
+	RetryAfter time.Duration
+}
+
+// Store keeps the state of every bucket. Update must apply fn atomically: it
+// receives the current value of key, empty when missing or expired, and
+// returns the value to store and how long to keep it.
+type Store interface {
+	Update(ctx context.Context, key string, fn func(value string) (string, time.Duration)) error
+}
+
+type Limiter struct {
+	store Store
+	now   func() time.Time
+}
+
+func NewLimiter(store Store) *Limiter {
+	return &Limiter{store: store, now: time.Now}
+}
+
+// Allow counts a request against the bucket key and reports whether it may
+// proceed.
+func (l *Limiter) Allow(ctx context.Context, key string, policy Policy) (Result, error) {
+	if policy.Limit <= 0 || policy.Window <= 0 {
+		return Result{}, fmt.Errorf("ratelimit: invalid policy %+v", policy)
+	}
+
+	now := l.now()
+
+	var result Result
+	err := l.store.Update(ctx, key, func(value string) (string, time.Duration) {
+		var next string
+		var ttl time.Duration
+
+		if policy.Algorithm == SlidingWindow {
+			result, next, ttl = slidingWindow(value, policy, now)
+		} else {
+			result, next, ttl = tokenBucket(value, policy, now)
+		}
+
+		return next, ttl
+	})
+
+	return result, err
+}
+
+// tokenBucket implements the bucket as the generic cell rate algorithm, which
+// only needs to store the time at which the bucket will be full again.
+func tokenBucket(value string, p Policy, now time.Time) (Result, string, time.Duration) {
+	burst := p.Burst
+	if burst <= 0 {
+		burst = p.Limit
+	}
+
+	interval := p.Window / time.Duration(p.Limit)
+	capacity := interval * time.Duration(burst)
+
+	full := now
+	if nanos, err := strconv.ParseInt(value, 10, 64); err == nil && time.Unix(0, nanos).After(now) {
+		full = time.Unix(0, nanos)
+	}
+
+	next := full.Add(interval)
+	allowAt := next.Add(-capacity)
+
+	if now.Before(allowAt) {
+		return Result{
+			Limit:      burst,
+			Reset:      full.Sub(now),
+			RetryAfter: allowAt.Sub(now),
+		}, value, full.Sub(now)
+	}
+
+	return Result{
+		Allowed:   true,
+		Limit:     burst,
+		Remaining: int(now.Sub(allowAt) / interval),
+		Reset:     next.Sub(now),
+	}, strconv.FormatInt(next.UnixNano(), 10), next.Sub(now)
+}
+
+// slidingWindow stores "<window start>:<current count>:<previous count>".
+func slidingWindow(value string, p Policy, now time.Time) (Result, string, time.Duration) {
+	start := now.Truncate(p.Window)
+
+	var current, previous int
+	if parts := strings.Split(value, ":"); len(parts) == 3 {
+		stored, _ := strconv.ParseInt(parts[0], 10, 64)
+		count, _ := strconv.Atoi(parts[1])
+		last, _ := strconv.Atoi(parts[2])
+
+		switch stored {
+		case start.UnixNano():
+			current, previous = count, last
+		case start.Add(-p.Window).UnixNano():
+			previous = count
+		}
+	}
+
+	elapsed := now.Sub(start)
+	weight := 1 - float64(elapsed)/float64(p.Window)
+	estimate := float64(previous)*weight + float64(current)
+	reset := p.Window - elapsed
+	ttl := 2*p.Window - elapsed
+
+	encode := func(count int) string {
+		return fmt.Sprintf("%d:%d:%d", start.UnixNano(), count, previous)
+	}
+
+	if estimate+1 > float64(p.Limit) {
+		// The estimate drops as the previous window slides out. When that is
+		// not enough, the next window has to start.
+		retry := reset
+		if excess := estimate + 1 - float64(p.Limit); previous > 0 && float64(previous)*weight >= excess {
+			retry = time.Duration(excess / float64(previous) * float64(p.Window))
+		}
+
+		return Result{
+			Limit:      p.Limit,
+			Reset:      reset,
+			RetryAfter: retry,
+		}, encode(current), ttl
+	}
+
+	return Result{
+		Allowed:   true,
+		Limit:     p.Limit,
+		Remaining: max(0, p.Limit-int(math.Ceil(estimate+1))),
+		Reset:     reset,
+	}, encode(current + 1), ttl
+}
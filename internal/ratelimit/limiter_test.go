@@ -0,0 +1,107 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/go-playground/assert/v2"
+	"testing"
+	"time"
+)
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func newTestLimiter(store Store) (*Limiter, *clock) {
+	c := &clock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+
+	limiter := NewLimiter(store)
+	limiter.now = c.Now
+
+	return limiter, c
+}
+
+func allow(t *testing.T, limiter *Limiter, policy Policy) Result {
+	t.Helper()
+
+	result, err := limiter.Allow(context.Background(), "client", policy)
+	assert.Equal(t, err, nil)
+
+	return result
+}
+
+func TestTokenBucket(t *testing.T) {
+	limiter, clock := newTestLimiter(NewMemoryStore())
+	policy := Policy{Algorithm: TokenBucket, Limit: 1, Window: time.Second, Burst: 3}
+
+	for remaining := 2; remaining >= 0; remaining-- {
+		result := allow(t, limiter, policy)
+
+		assert.Equal(t, result.Allowed, true)
+		assert.Equal(t, result.Limit, 3)
+		assert.Equal(t, result.Remaining, remaining)
+	}
+
+	denied := allow(t, limiter, policy)
+	assert.Equal(t, denied.Allowed, false)
+	assert.Equal(t, denied.RetryAfter, time.Second)
+	assert.Equal(t, denied.Reset, 3*time.Second)
+
+	clock.now = clock.now.Add(time.Second)
+	assert.Equal(t, allow(t, limiter, policy).Allowed, true)
+	assert.Equal(t, allow(t, limiter, policy).Allowed, false)
+
+	clock.now = clock.now.Add(time.Minute)
+	assert.Equal(t, allow(t, limiter, policy).Remaining, 2)
+}
+
+func TestSlidingWindow(t *testing.T) {
+	limiter, clock := newTestLimiter(NewMemoryStore())
+	policy := Policy{Algorithm: SlidingWindow, Limit: 4, Window: time.Minute}
+
+	for remaining := 3; remaining >= 0; remaining-- {
+		result := allow(t, limiter, policy)
+
+		assert.Equal(t, result.Allowed, true)
+		assert.Equal(t, result.Remaining, remaining)
+	}
+
+	denied := allow(t, limiter, policy)
+	assert.Equal(t, denied.Allowed, false)
+	assert.Equal(t, denied.Reset, time.Minute)
+	assert.Equal(t, denied.RetryAfter, time.Minute)
+
+	// A quarter into the next window, 3 of the previous 4 requests still
+	// count, leaving room for exactly one more.
+	clock.now = clock.now.Add(75 * time.Second)
+	assert.Equal(t, allow(t, limiter, policy).Allowed, true)
+
+	denied = allow(t, limiter, policy)
+	assert.Equal(t, denied.Allowed, false)
+	assert.Equal(t, denied.RetryAfter, 15*time.Second)
+
+	clock.now = clock.now.Add(15 * time.Second)
+	assert.Equal(t, allow(t, limiter, policy).Allowed, true)
+}
+
+func TestLimiterKeepsBucketsApart(t *testing.T) {
+	limiter, _ := newTestLimiter(NewMemoryStore())
+	policy := Policy{Algorithm: SlidingWindow, Limit: 1, Window: time.Minute}
+
+	first, _ := limiter.Allow(context.Background(), "login:ip:10.0.0.1", policy)
+	second, _ := limiter.Allow(context.Background(), "login:ip:10.0.0.2", policy)
+
+	assert.Equal(t, first.Allowed, true)
+	assert.Equal(t, second.Allowed, true)
+}
+
+func TestLimiterRejectsInvalidPolicy(t *testing.T) {
+	limiter, _ := newTestLimiter(NewMemoryStore())
+
+	_, err := limiter.Allow(context.Background(), "client", Policy{Algorithm: TokenBucket})
+
+	assert.NotEqual(t, err, nil)
+}
@@ -0,0 +1,181 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/model"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+const (
+	DriverMemory   = "memory"
+	DriverDatabase = "database"
+	DriverRedis    = "redis"
+)
+
+// MemoryStore only limits within the current process. Expired buckets are
+// swept at most once per minute.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]memoryBucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type memoryBucket struct {
+	value     string
+	expiresAt time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]memoryBucket{}, now: time.Now}
+}
+
+func (s *MemoryStore) Update(_ context.Context, key string, fn func(value string) (string, time.Duration)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+
+	if now.Sub(s.lastSweep) >= time.Minute {
+		for k, bucket := range s.buckets {
+			if !now.Before(bucket.expiresAt) {
+				delete(s.buckets, k)
+			}
+		}
+
+		s.lastSweep = now
+	}
+
+	var value string
+	if bucket, ok := s.buckets[key]; ok && now.Before(bucket.expiresAt) {
+		value = bucket.value
+	}
+
+	next, ttl := fn(value)
+	s.buckets[key] = memoryBucket{value: next, expiresAt: now.Add(ttl)}
+
+	return nil
+}
+
+// DatabaseStore shares buckets between instances through the
+// rate_limit_counters table, locking the row for each update.
+type DatabaseStore struct {
+	db  *gorm.DB
+	now func() time.Time
+}
+
+func NewDatabaseStore(db *gorm.DB) *DatabaseStore {
+	return &DatabaseStore{db: db, now: time.Now}
+}
+
+func (s *DatabaseStore) Update(ctx context.Context, key string, fn func(value string) (string, time.Duration)) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := s.now()
+
+		// Make sure the row exists, so concurrent first requests all wait on
+		// the same row lock.
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&model.RateLimitCounter{Bucket: key, ExpiresAt: now}).Error
+		if err != nil {
+			return err
+		}
+
+		var counter model.RateLimitCounter
+
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("bucket = ?", key).
+			First(&counter).Error
+		if err != nil {
+			return err
+		}
+
+		value := counter.Value
+		if !now.Before(counter.ExpiresAt) {
+			value = ""
+		}
+
+		next, ttl := fn(value)
+		counter.Value = next
+		counter.ExpiresAt = now.Add(ttl)
+
+		return tx.Save(&counter).Error
+	})
+}
+
+// Purge deletes the buckets that expired before the given time.
+func (s *DatabaseStore) Purge(ctx context.Context, before time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&model.RateLimitCounter{})
+
+	return result.RowsAffected, result.Error
+}
+
+var ErrContention = errors.New("ratelimit: too many concurrent updates of the same bucket")
+
+// RedisStore shares buckets between instances through Redis, updating them
+// with optimistic WATCH/MULTI/EXEC transactions so no server side scripting
+// is needed.
+type RedisStore struct {
+	client   *redis.Client
+	prefix   string
+	attempts int
+}
+
+func NewRedisStore(client *redis.Client, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix, attempts: 10}
+}
+
+func (s *RedisStore) Update(ctx context.Context, key string, fn func(value string) (string, time.Duration)) error {
+	key = s.prefix + key
+
+	for attempt := 1; attempt <= s.attempts; attempt++ {
+		committed, err := s.update(ctx, key, fn)
+		if err != nil || committed {
+			return err
+		}
+
+		// Spread out the retries of clients that collided on the same key.
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(rand.Int64N(int64(attempt) * int64(time.Millisecond)))):
+		}
+	}
+
+	return ErrContention
+}
+
+func (s *RedisStore) update(ctx context.Context, key string, fn func(value string) (string, time.Duration)) (bool, error) {
+	err := s.client.Watch(ctx, func(tx *redis.Tx) error {
+		value, err := tx.Get(ctx, key).Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return err
+		}
+
+		next, ttl := fn(value)
+
+		// Nothing to write, e.g. a denied request: the read was consistent.
+		if next == value {
+			return nil
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, next, max(ttl, time.Millisecond))
+			return nil
+		})
+
+		return err
+	}, key)
+
+	// The key changed since WATCH and nothing was written.
+	if errors.Is(err, redis.TxFailedErr) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
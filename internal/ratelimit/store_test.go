@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-playground/assert/v2"
+	"github.com/redis/go-redis/v9"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreExpiresBuckets(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	set := func(value string) func(string) (string, time.Duration) {
+		return func(string) (string, time.Duration) { return value, time.Second }
+	}
+
+	assert.Equal(t, store.Update(context.Background(), "key", set("1")), nil)
+
+	var seen string
+	read := func(value string) (string, time.Duration) {
+		seen = value
+		return value, time.Second
+	}
+
+	assert.Equal(t, store.Update(context.Background(), "key", read), nil)
+	assert.Equal(t, seen, "1")
+
+	now = now.Add(2 * time.Minute)
+	assert.Equal(t, store.Update(context.Background(), "other", set("2")), nil)
+	assert.Equal(t, len(store.buckets), 1)
+
+	assert.Equal(t, store.Update(context.Background(), "key", read), nil)
+	assert.Equal(t, seen, "")
+}
+
+func TestRedisStore(t *testing.T) {
+	server := miniredis.RunT(t)
+	server.RequireAuth("secret")
+
+	client := redis.NewClient(&redis.Options{Addr: server.Addr(), Password: "secret"})
+	defer client.Close()
+
+	limiter := NewLimiter(NewRedisStore(client, "ratelimit:"))
+	policy := Policy{Algorithm: SlidingWindow, Limit: 10, Window: time.Hour}
+
+	var allowed int
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for i := 0; i < 25; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			result, err := limiter.Allow(context.Background(), "login:ip:10.0.0.1", policy)
+			assert.Equal(t, err, nil)
+
+			if result.Allowed {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, allowed, 10)
+
+	assert.Equal(t, server.Exists("ratelimit:login:ip:10.0.0.1"), true)
+	assert.Equal(t, server.TTL("ratelimit:login:ip:10.0.0.1") > 0, true)
+}
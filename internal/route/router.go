@@ -1,32 +1,265 @@
 package route
 
 import (
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/auth"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/captcha"
 	"github.com/Fortress-Digital/go-rest-skeleton/internal/config"
 	"github.com/Fortress-Digital/go-rest-skeleton/internal/handler"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/log"
 	middlewares "github.com/Fortress-Digital/go-rest-skeleton/internal/middleware"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/ratelimit"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/supabase"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
-	"net/http"
+	"net"
+	"slices"
+	"strings"
 )
 
-func NewRouter(cfg *config.Config, handler *handler.Handler) http.Handler {
+type Dependencies struct {
+	Handler           *handler.Handler
+	HookHandler       *handler.HookHandler
+	StorageHandler    *handler.StorageHandler
+	APIKeyHandler     *handler.APIKeyHandler
+	LockoutHandler    *handler.LockoutHandler
+	SessionHandler    *handler.SessionHandler
+	InvitationHandler *handler.InvitationHandler
+	RateLimiter       *ratelimit.Limiter
+	Captcha           captcha.Verifier
+	WebhookVerifier   *supabase.WebhookVerifier
+	Authenticators    []auth.Authenticator
+	Log               log.LoggerInterface
+}
+
+func NewRouter(live *config.Live, deps Dependencies) *echo.Echo {
 	router := echo.New()
+	router.IPExtractor = ipExtractor(live.Current().Server)
 	router.Use(middleware.Recover())
-	router.Use(middleware.CORS())
-	router.Use(middleware.RateLimiter(middleware.NewRateLimiterMemoryStore(20)))
-	router.Use(middlewares.CSRFMiddleware(cfg))
+	router.Use(middlewares.Reloadable(live, func(cfg *config.Config) any { return cfg.CORS }, corsMiddleware))
+	router.Use(middlewares.Reloadable(live, func(cfg *config.Config) any { return cfg.SecurityHeaders }, func(cfg *config.Config) echo.MiddlewareFunc {
+		return middlewares.SecurityHeadersMiddleware(cfg.SecurityHeaders)
+	}))
+
+	limit := func(name string) echo.MiddlewareFunc {
+		return middlewares.Reloadable(live, func(cfg *config.Config) any { return cfg.RateLimit }, func(cfg *config.Config) echo.MiddlewareFunc {
+			return rateLimitMiddleware(cfg, deps.RateLimiter, deps.Log, name)
+		})
+	}
+
+	// endpoint returns the rate limit and captcha middleware of the named
+	// auth endpoint, limiting bots before the captcha provider is called.
+	endpoint := func(name string) []echo.MiddlewareFunc {
+		return append([]echo.MiddlewareFunc{limit(name)}, captchaMiddleware(live.Current(), deps.Captcha, deps.Log, name)...)
+	}
+
+	router.Use(limit("global"))
+	router.Use(middlewares.CSRFMiddleware(live.Current()))
+
+	authenticated := middlewares.AuthMiddleware(deps.Authenticators...)
+
+	defineRoutes(router, deps.Handler, endpoint, clientCertificate(live.Current(), "auth")...)
 
-	defineRoutes(router, handler)
+	files := []echo.MiddlewareFunc{middlewares.FeatureMiddleware(live, "storage")}
+	files = append(files, clientCertificate(live.Current(), "files")...)
+	// Files are stored per user, so services acting for no user are refused.
+	files = append(files, authenticated, middlewares.RequirePrincipal(auth.PrincipalUser, auth.PrincipalAPIKey), limit("files"))
+	defineStorageRoutes(router, deps.StorageHandler, files...)
+
+	if deps.APIKeyHandler != nil {
+		defineAPIKeyRoutes(router, deps.APIKeyHandler, authenticated, middlewares.RequirePrincipal(auth.PrincipalUser), limit("api_keys"))
+	}
+
+	if deps.SessionHandler != nil {
+		defineSessionRoutes(router, deps.SessionHandler, authenticated, middlewares.RequirePrincipal(auth.PrincipalUser))
+	}
+
+	if deps.LockoutHandler != nil || deps.InvitationHandler != nil {
+		defineAdminRoutes(router, deps.LockoutHandler, deps.InvitationHandler, authenticated, middlewares.RequirePrincipal(auth.PrincipalUser), middlewares.RequireRole(auth.RoleAdmin))
+	}
+
+	if deps.WebhookVerifier != nil {
+		defineHookRoutes(router, deps.HookHandler, append(clientCertificate(live.Current(), "hooks"), middlewares.WebhookMiddleware(deps.WebhookVerifier))...)
+	}
 
 	return router
 }
 
-func defineRoutes(router *echo.Echo, h *handler.Handler) {
-	router.GET("/", h.HomeHandler)
-	router.POST("/register", h.RegisterHandler)
-	router.POST("/login", h.LoginHandler)
-	router.POST("/forgotten-password", h.ForgottenPasswordHandler)
-	router.POST("/reset-password", h.ResetPasswordHandler)
-	router.POST("/refresh-token", h.RefreshTokenHandler)
-	router.POST("/logout", h.LogoutHandler)
+// defineRoutes registers the root routes one by one, as a group without a
+// prefix would run its middleware for every unmatched path. Sign in and
+// account recovery routes get their own, stricter, rate limits, and may
+// require a captcha.
+func defineRoutes(router *echo.Echo, h *handler.Handler, endpoint func(name string) []echo.MiddlewareFunc, m ...echo.MiddlewareFunc) {
+	router.GET("/", h.HomeHandler, m...)
+	router.GET("/csrf", h.CSRFHandler, m...)
+	router.POST(middlewares.CSPReportPath, h.CSPReportHandler, m...)
+	router.POST("/register", h.RegisterHandler, slices.Concat(m, endpoint("register"))...)
+	router.POST("/login", h.LoginHandler, slices.Concat(m, endpoint("login"))...)
+	router.POST("/forgotten-password", h.ForgottenPasswordHandler, slices.Concat(m, endpoint("forgotten_password"))...)
+	router.POST("/reset-password", h.ResetPasswordHandler, m...)
+	router.POST("/resend-confirmation", h.ResendConfirmationHandler, slices.Concat(m, endpoint("resend_confirmation"))...)
+	router.GET("/verify", h.ConfirmationPageHandler, m...)
+	router.POST("/verify", h.VerifyHandler, m...)
+	router.POST("/refresh-token", h.RefreshTokenHandler, m...)
+	router.POST("/logout", h.LogoutHandler, m...)
+}
+
+func defineHookRoutes(router *echo.Echo, h *handler.HookHandler, m ...echo.MiddlewareFunc) {
+	hooks := router.Group("/hooks", m...)
+	hooks.POST("/custom-access-token", h.CustomAccessTokenHandler)
+	hooks.POST("/send-email", h.SendEmailHandler)
+	hooks.POST("/mfa-verification-attempt", h.MFAVerificationAttemptHandler)
+	hooks.POST("/password-verification-attempt", h.PasswordVerificationAttemptHandler)
+	hooks.POST("/before-user-created", h.BeforeUserCreatedHandler)
+}
+
+func defineStorageRoutes(router *echo.Echo, h *handler.StorageHandler, m ...echo.MiddlewareFunc) {
+	read := middlewares.RequireScope("files:read")
+	write := middlewares.RequireScope("files:write")
+
+	files := router.Group("/files", m...)
+	files.GET("", h.ListHandler, read)
+	files.POST("", h.UploadHandler, write)
+	files.DELETE("", h.DeleteHandler, write)
+	files.GET("/download", h.DownloadHandler, read)
+	files.GET("/signed-url", h.SignedURLHandler, read)
+	files.POST("/signed-upload-url", h.SignedUploadURLHandler, write)
+	files.POST("/move", h.MoveHandler, write)
+	files.POST("/copy", h.CopyHandler, write)
+}
+
+func defineAPIKeyRoutes(router *echo.Echo, h *handler.APIKeyHandler, m ...echo.MiddlewareFunc) {
+	keys := router.Group("/api-keys", m...)
+	keys.GET("", h.ListHandler)
+	keys.POST("", h.CreateHandler)
+	keys.GET("/:id", h.ShowHandler)
+	keys.PATCH("/:id", h.UpdateHandler)
+	keys.DELETE("/:id", h.RevokeHandler)
+}
+
+func defineSessionRoutes(router *echo.Echo, h *handler.SessionHandler, m ...echo.MiddlewareFunc) {
+	sessions := router.Group("/sessions", m...)
+	sessions.GET("", h.ListHandler)
+	sessions.DELETE("", h.RevokeAllHandler)
+	sessions.DELETE("/:id", h.RevokeHandler)
+}
+
+func defineAdminRoutes(router *echo.Echo, lockouts *handler.LockoutHandler, invitations *handler.InvitationHandler, m ...echo.MiddlewareFunc) {
+	admin := router.Group("/admin", m...)
+
+	if lockouts != nil {
+		admin.GET("/lockouts", lockouts.ShowHandler)
+		admin.DELETE("/lockouts", lockouts.ClearHandler)
+	}
+
+	if invitations != nil {
+		admin.GET("/invitations", invitations.ListHandler)
+		admin.POST("/invitations", invitations.CreateHandler)
+		admin.DELETE("/invitations/:id", invitations.RevokeHandler)
+	}
+}
+
+// ipExtractor only reads the client IP from X-Forwarded-For on requests of a
+// trusted proxy, so clients cannot choose the IP their limits count against.
+func ipExtractor(cfg config.Server) echo.IPExtractor {
+	if len(cfg.TrustedProxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, proxy := range cfg.TrustedProxies {
+		options = append(options, echo.TrustIPRange(proxyRange(proxy)))
+	}
+
+	return echo.ExtractIPFromXFFHeader(options...)
+}
+
+// proxyRange returns the network of a trusted proxy, a single IP being a
+// network of its own. The configuration validates the entries.
+func proxyRange(proxy string) *net.IPNet {
+	if _, network, err := net.ParseCIDR(proxy); err == nil {
+		return network
+	}
+
+	ip := net.ParseIP(proxy)
+	bits := 8 * net.IPv6len
+	if ip4 := ip.To4(); ip4 != nil {
+		ip, bits = ip4, 8*net.IPv4len
+	}
+
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+}
+
+// clientCertificate returns the client certificate middleware for the route
+// group, when mutual TLS is enabled and the group has a mode configured.
+func clientCertificate(cfg *config.Config, group string) []echo.MiddlewareFunc {
+	client := cfg.Server.TLS.Client
+
+	mode, ok := client.Groups[group]
+	if !cfg.Server.TLS.Enabled || !client.Enabled || !ok {
+		return nil
+	}
+
+	return []echo.MiddlewareFunc{
+		middlewares.ClientCertificateMiddleware(mode, auth.NewCertificateAuthenticator(client.Services)),
+	}
+}
+
+// routeGroups maps path prefixes to the route groups named by the
+// configuration. Any other path belongs to the auth group.
+var routeGroups = map[string]string{
+	"/files":    "files",
+	"/api-keys": "api_keys",
+	"/sessions": "sessions",
+	"/admin":    "admin",
+	"/hooks":    "hooks",
+}
+
+func routeGroup(path string) string {
+	for prefix, group := range routeGroups {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return group
+		}
+	}
+
+	return "auth"
+}
+
+// corsMiddleware applies the CORS policy of the route group of each request.
+// It runs for every request, as preflight requests never reach the route
+// middleware.
+func corsMiddleware(cfg *config.Config) echo.MiddlewareFunc {
+	policies := map[string]echo.MiddlewareFunc{
+		"auth": middlewares.CORSMiddleware(cfg.CORS.Policy(cfg.Application.Env, "auth")),
+	}
+
+	for _, group := range routeGroups {
+		policies[group] = middlewares.CORSMiddleware(cfg.CORS.Policy(cfg.Application.Env, group))
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			return policies[routeGroup(c.Request().URL.Path)](next)(c)
+		}
+	}
+}
+
+// captchaMiddleware returns the captcha middleware of the endpoint, when
+// captcha protects it in the current environment.
+func captchaMiddleware(cfg *config.Config, verifier captcha.Verifier, log log.LoggerInterface, endpoint string) []echo.MiddlewareFunc {
+	if !cfg.Captcha.Protects(cfg.Application.Env, endpoint) {
+		return nil
+	}
+
+	return []echo.MiddlewareFunc{middlewares.CaptchaMiddleware(cfg.Captcha, verifier, log)}
+}
+
+// rateLimitMiddleware applies the named policy, if any. Without a limiter,
+// e.g. when listing routes, requests are never limited.
+func rateLimitMiddleware(cfg *config.Config, limiter *ratelimit.Limiter, log log.LoggerInterface, name string) echo.MiddlewareFunc {
+	policy, ok := cfg.RateLimit.Policies[name]
+	if !cfg.RateLimit.Enabled || !ok || limiter == nil {
+		return func(next echo.HandlerFunc) echo.HandlerFunc { return next }
+	}
+
+	return middlewares.RateLimitMiddleware(limiter, name, policy, log)
 }
@@ -0,0 +1,200 @@
+package route
+
+import (
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/config"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/handler"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/lockout"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/ratelimit"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/supabase"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/validation"
+	"github.com/go-playground/assert/v2"
+	"github.com/labstack/echo/v4"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func preflight(cfg *config.Config, path string, origin string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodOptions, path, nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	req.Header.Set("Access-Control-Request-Headers", "Content-Type, X-CSRF-Token")
+
+	rec := httptest.NewRecorder()
+	NewRouter(config.NewLive(cfg), Dependencies{}).ServeHTTP(rec, req)
+
+	return rec
+}
+
+func corsConfig() *config.Config {
+	cfg := config.Default()
+	cfg.Application.Env = "production"
+	cfg.CORS.AllowOrigins = []string{"https://*.example.com"}
+	cfg.CORS.Environments = map[string][]string{"dev": {"http://localhost:3000"}}
+
+	return cfg
+}
+
+func TestCORSPreflight(t *testing.T) {
+	rec := preflight(corsConfig(), "/login", "https://app.example.com")
+
+	assert.Equal(t, rec.Code, http.StatusNoContent)
+	assert.Equal(t, rec.Header().Get("Access-Control-Allow-Origin"), "https://app.example.com")
+	assert.Equal(t, rec.Header().Get("Access-Control-Allow-Credentials"), "true")
+	assert.Equal(t, rec.Header().Get("Access-Control-Allow-Methods"), "GET,HEAD,POST,PUT,PATCH,DELETE")
+	assert.Equal(t, rec.Header().Get("Access-Control-Allow-Headers"), "Accept,Authorization,Content-Type,X-API-Key,X-Captcha-Token,X-CSRF-Token")
+	assert.Equal(t, rec.Header().Get("Access-Control-Max-Age"), "600")
+}
+
+func TestCORSPreflightRejectsUnknownOrigin(t *testing.T) {
+	rec := preflight(corsConfig(), "/login", "https://example.com.evil.test")
+
+	assert.Equal(t, rec.Code, http.StatusNoContent)
+	assert.Equal(t, rec.Header().Get("Access-Control-Allow-Origin"), "")
+	assert.Equal(t, rec.Header().Get("Access-Control-Allow-Methods"), "")
+}
+
+func TestCORSEnvironmentOrigins(t *testing.T) {
+	cfg := corsConfig()
+	cfg.Application.Env = "dev"
+
+	rec := preflight(cfg, "/login", "http://localhost:3000")
+	assert.Equal(t, rec.Header().Get("Access-Control-Allow-Origin"), "http://localhost:3000")
+
+	rec = preflight(cfg, "/login", "https://app.example.com")
+	assert.Equal(t, rec.Header().Get("Access-Control-Allow-Origin"), "")
+}
+
+func TestCORSGroupOverride(t *testing.T) {
+	credentials := false
+	maxAge := 60
+
+	cfg := corsConfig()
+	cfg.CORS.Groups = map[string]config.CORSOverride{
+		"files": {
+			AllowOrigins:     []string{"https://cdn.example.net"},
+			AllowMethods:     []string{http.MethodGet},
+			AllowCredentials: &credentials,
+			MaxAge:           &maxAge,
+		},
+	}
+
+	rec := preflight(cfg, "/files/download", "https://cdn.example.net")
+	assert.Equal(t, rec.Header().Get("Access-Control-Allow-Origin"), "https://cdn.example.net")
+	assert.Equal(t, rec.Header().Get("Access-Control-Allow-Credentials"), "")
+	assert.Equal(t, rec.Header().Get("Access-Control-Allow-Methods"), "GET")
+	assert.Equal(t, rec.Header().Get("Access-Control-Max-Age"), "60")
+
+	// Other groups keep the top level policy.
+	rec = preflight(cfg, "/login", "https://cdn.example.net")
+	assert.Equal(t, rec.Header().Get("Access-Control-Allow-Origin"), "")
+}
+
+func TestCORSExposesHeadersOnSimpleRequests(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/files", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+
+	rec := httptest.NewRecorder()
+	NewRouter(config.NewLive(corsConfig()), Dependencies{}).ServeHTTP(rec, req)
+
+	assert.Equal(t, rec.Header().Get("Access-Control-Allow-Origin"), "https://app.example.com")
+	assert.Equal(t, rec.Header().Get("Access-Control-Expose-Headers"), "RateLimit-Limit,RateLimit-Policy,RateLimit-Remaining,RateLimit-Reset,Retry-After")
+}
+
+func TestRouteGroup(t *testing.T) {
+	assert.Equal(t, routeGroup("/files"), "files")
+	assert.Equal(t, routeGroup("/files/move"), "files")
+	assert.Equal(t, routeGroup("/filesystem"), "auth")
+	assert.Equal(t, routeGroup("/api-keys/1"), "api_keys")
+	assert.Equal(t, routeGroup("/login"), "auth")
+}
+
+func rateLimitedRouter(proxies []string) http.Handler {
+	cfg := config.Default()
+	cfg.Server.TrustedProxies = proxies
+	cfg.RateLimit.Policies = map[string]config.RateLimitPolicy{
+		"global": {Algorithm: "sliding_window", Limit: 1, Window: 60, Key: "ip"},
+	}
+
+	return NewRouter(config.NewLive(cfg), Dependencies{RateLimiter: ratelimit.NewLimiter(ratelimit.NewMemoryStore())})
+}
+
+func requestFrom(router http.Handler, remoteAddr string, forwardedFor string) int {
+	req := httptest.NewRequest(http.MethodGet, "/missing", nil)
+	req.RemoteAddr = remoteAddr
+	req.Header.Set(echo.HeaderXForwardedFor, forwardedFor)
+	req.Header.Set(echo.HeaderXRealIP, forwardedFor)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	return rec.Code
+}
+
+func TestSpoofedForwardedForSharesBucket(t *testing.T) {
+	router := rateLimitedRouter(nil)
+
+	assert.Equal(t, requestFrom(router, "203.0.113.7:1234", "198.51.100.1"), http.StatusNotFound)
+	assert.Equal(t, requestFrom(router, "203.0.113.7:1234", "198.51.100.2"), http.StatusTooManyRequests)
+	assert.Equal(t, requestFrom(router, "203.0.113.8:1234", "198.51.100.2"), http.StatusNotFound)
+}
+
+func TestTrustedProxyForwardedFor(t *testing.T) {
+	router := rateLimitedRouter([]string{"10.0.0.0/8", "192.0.2.1"})
+
+	assert.Equal(t, requestFrom(router, "10.1.2.3:1234", "198.51.100.1"), http.StatusNotFound)
+	assert.Equal(t, requestFrom(router, "192.0.2.1:1234", "198.51.100.2"), http.StatusNotFound)
+	assert.Equal(t, requestFrom(router, "10.1.2.3:1234", "198.51.100.2"), http.StatusTooManyRequests)
+
+	// Untrusted hops cannot prepend addresses of their choosing.
+	assert.Equal(t, requestFrom(router, "10.1.2.3:1234", "198.51.100.3, 203.0.113.7"), http.StatusNotFound)
+	assert.Equal(t, requestFrom(router, "10.1.2.3:1234", "198.51.100.4, 203.0.113.7"), http.StatusTooManyRequests)
+}
+
+// rejectingAuth rejects every login as invalid credentials.
+type rejectingAuth struct {
+	supabase.AuthClientInterface
+}
+
+func (rejectingAuth) SignIn(supabase.UserCredentials) (*supabase.AuthenticatedDetails, *supabase.ErrorResponse, error) {
+	return nil, &supabase.ErrorResponse{Code: http.StatusBadRequest, ErrorCode: "invalid_credentials"}, nil
+}
+
+func login(router http.Handler, remoteAddr string, forwardedFor string, email string) int {
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"email": "`+email+`", "password": "wrong-password"}`))
+	req.RemoteAddr = remoteAddr
+	req.Header.Set(echo.HeaderXForwardedFor, forwardedFor)
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	// Non-browser clients are not subject to the CSRF check.
+	req.Header.Set(echo.HeaderAuthorization, "Bearer client")
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	return rec.Code
+}
+
+func TestLockoutIgnoresForgedForwardedFor(t *testing.T) {
+	cfg := config.Default()
+	cfg.RateLimit.Enabled = false
+
+	store := ratelimit.NewMemoryStore()
+	tracker := lockout.NewTracker(store, map[string]lockout.Policy{
+		lockout.KindEmail: {MaxAttempts: 100, Window: time.Hour, Duration: time.Hour},
+		lockout.KindIP:    {MaxAttempts: 2, Window: time.Hour, Duration: time.Hour},
+	})
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	h := handler.NewHandler(cfg, rejectingAuth{}, validation.NewValidator(), nil, logger, tracker, ratelimit.NewLimiter(store), nil, nil)
+	router := NewRouter(config.NewLive(cfg), Dependencies{Handler: h})
+
+	assert.Equal(t, login(router, "203.0.113.7:1234", "198.51.100.1", "a@example.com"), http.StatusBadRequest)
+	assert.Equal(t, login(router, "203.0.113.7:1234", "198.51.100.2", "b@example.com"), http.StatusBadRequest)
+	assert.Equal(t, login(router, "203.0.113.7:1234", "198.51.100.3", "c@example.com"), http.StatusTooManyRequests)
+
+	// Forging the locked out IP does not lock out another client.
+	assert.Equal(t, login(router, "203.0.113.8:1234", "203.0.113.7", "d@example.com"), http.StatusBadRequest)
+}
@@ -0,0 +1,241 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/log"
+	"sort"
+	"sync"
+	"time"
+)
+
+var ErrDuplicateTask = errors.New("scheduler: task already registered")
+
+// TaskFunc is run on every tick of its schedule. The context is cancelled
+// when the scheduler shuts down.
+type TaskFunc func(ctx context.Context) error
+
+type task struct {
+	name     string
+	schedule *Schedule
+	fn       TaskFunc
+	running  bool
+}
+
+// Scheduler runs named tasks on cron schedules inside the server process.
+// Every replica runs the loop, but the store lock makes sure each tick of a
+// task is only executed by one of them.
+type Scheduler struct {
+	store    Store
+	log      log.LoggerInterface
+	location *time.Location
+	now      func() time.Time
+
+	mu    sync.Mutex
+	tasks map[string]*task
+
+	stop    chan struct{}
+	ctx     context.Context
+	cancel  context.CancelFunc
+	loop    sync.WaitGroup
+	runs    sync.WaitGroup
+	started sync.Once
+	stopped sync.Once
+}
+
+func NewScheduler(store Store, log log.LoggerInterface, location *time.Location) *Scheduler {
+	if location == nil {
+		location = time.UTC
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Scheduler{
+		store:    store,
+		log:      log,
+		location: location,
+		now:      time.Now,
+		tasks:    map[string]*task{},
+		stop:     make(chan struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Register adds a task running on the given cron expression.
+func (s *Scheduler) Register(name string, expr string, fn TaskFunc) error {
+	schedule, err := ParseSchedule(expr)
+	if err != nil {
+		return fmt.Errorf("scheduler: task %s: %w", name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.tasks[name]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicateTask, name)
+	}
+
+	s.tasks[name] = &task{name: name, schedule: schedule, fn: fn}
+
+	return nil
+}
+
+// Tasks returns the registered task names in alphabetical order.
+func (s *Scheduler) Tasks() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make([]string, 0, len(s.tasks))
+	for name := range s.tasks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func (s *Scheduler) Start() {
+	s.started.Do(func() {
+		s.loop.Add(1)
+		go s.run()
+	})
+}
+
+// Shutdown stops scheduling new runs and waits for running tasks to return.
+// Tasks still running when ctx expires have their context cancelled.
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	s.stopped.Do(func() { close(s.stop) })
+	s.loop.Wait()
+
+	done := make(chan struct{})
+	go func() {
+		s.runs.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		s.cancel()
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (s *Scheduler) run() {
+	defer s.loop.Done()
+
+	last := s.now().In(s.location)
+
+	for {
+		next, due := s.next(last)
+		if next.IsZero() {
+			s.log.Warn("Scheduler has no upcoming tasks")
+			<-s.stop
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+
+		select {
+		case <-s.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		for _, t := range due {
+			s.dispatch(t, next)
+		}
+
+		last = next
+	}
+}
+
+// next finds the earliest upcoming tick after last and the tasks due then.
+func (s *Scheduler) next(last time.Time) (time.Time, []*task) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var earliest time.Time
+	var due []*task
+
+	for _, t := range s.tasks {
+		next := t.schedule.Next(last)
+
+		switch {
+		case next.IsZero():
+		case earliest.IsZero() || next.Before(earliest):
+			earliest = next
+			due = []*task{t}
+		case next.Equal(earliest):
+			due = append(due, t)
+		}
+	}
+
+	return earliest, due
+}
+
+func (s *Scheduler) dispatch(t *task, tick time.Time) {
+	s.mu.Lock()
+	if t.running {
+		s.mu.Unlock()
+		s.log.Warn("Scheduled task still running, skipping tick", "task", t.name, "tick", tick)
+		return
+	}
+	t.running = true
+	s.mu.Unlock()
+
+	s.runs.Add(1)
+	go func() {
+		defer s.runs.Done()
+		defer func() {
+			s.mu.Lock()
+			t.running = false
+			s.mu.Unlock()
+		}()
+
+		s.execute(t, tick)
+	}()
+}
+
+func (s *Scheduler) execute(t *task, tick time.Time) {
+	acquired, err := s.store.Acquire(s.ctx, t.name, tick)
+	if err != nil {
+		s.log.Error("Scheduled task lock error", "task", t.name, "tick", tick, "error", err)
+		return
+	}
+
+	if !acquired {
+		s.log.Debug("Scheduled task claimed by another instance", "task", t.name, "tick", tick)
+		return
+	}
+
+	started := s.now()
+	err = s.call(t)
+	run := Run{Name: t.name, Tick: tick, Duration: s.now().Sub(started), Err: err}
+
+	if err != nil {
+		s.log.Error("Scheduled task failed", "task", t.name, "tick", tick, "error", err)
+	} else {
+		s.log.Info("Scheduled task completed", "task", t.name, "tick", tick, "duration", run.Duration)
+	}
+
+	if err = s.store.Record(context.Background(), run); err != nil {
+		s.log.Error("Scheduled task record error", "task", t.name, "error", err)
+	}
+}
+
+func (s *Scheduler) call(t *task) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("task panicked: %v", r)
+		}
+	}()
+
+	return t.fn(s.ctx)
+}
@@ -0,0 +1,153 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/model"
+	"github.com/go-playground/assert/v2"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError + 1}))
+}
+
+func TestRegisterRejectsInvalidTasks(t *testing.T) {
+	s := NewScheduler(NewMemoryStore(), testLogger(), nil)
+
+	err := s.Register("purge", "0 3 * * *", func(context.Context) error { return nil })
+	assert.Equal(t, err, nil)
+
+	err = s.Register("purge", "0 4 * * *", func(context.Context) error { return nil })
+	assert.Equal(t, errors.Is(err, ErrDuplicateTask), true)
+
+	err = s.Register("broken", "not a schedule", func(context.Context) error { return nil })
+	assert.NotEqual(t, err, nil)
+
+	assert.Equal(t, s.Tasks(), []string{"purge"})
+}
+
+func TestNextSelectsEarliestTasks(t *testing.T) {
+	s := NewScheduler(NewMemoryStore(), testLogger(), nil)
+	noop := func(context.Context) error { return nil }
+
+	_ = s.Register("hourly", "@hourly", noop)
+	_ = s.Register("quarter", "*/15 * * * *", noop)
+	_ = s.Register("daily", "@daily", noop)
+
+	next, due := s.next(time.Date(2024, time.January, 1, 0, 50, 0, 0, time.UTC))
+	assert.Equal(t, next, time.Date(2024, time.January, 1, 1, 0, 0, 0, time.UTC))
+	assert.Equal(t, len(due), 2)
+}
+
+func TestTickRunsOnceAcrossReplicas(t *testing.T) {
+	store := NewMemoryStore()
+	tick := time.Date(2024, time.January, 1, 3, 0, 0, 0, time.UTC)
+
+	var runs atomic.Int32
+	fn := func(context.Context) error {
+		runs.Add(1)
+		return nil
+	}
+
+	replicas := []*Scheduler{
+		NewScheduler(store, testLogger(), nil),
+		NewScheduler(store, testLogger(), nil),
+	}
+
+	for _, s := range replicas {
+		_ = s.Register("purge", "0 3 * * *", fn)
+		s.dispatch(s.tasks["purge"], tick)
+	}
+
+	for _, s := range replicas {
+		_ = s.Shutdown(context.Background())
+	}
+
+	assert.Equal(t, runs.Load(), int32(1))
+
+	run, err := store.LastRun(context.Background(), "purge")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, run.LastRunAt, tick)
+	assert.Equal(t, run.LastStatus, model.TaskSucceeded)
+}
+
+func TestFailuresAndPanicsAreRecorded(t *testing.T) {
+	store := NewMemoryStore()
+	s := NewScheduler(store, testLogger(), nil)
+	tick := time.Now()
+
+	_ = s.Register("failing", "@daily", func(context.Context) error { return errors.New("boom") })
+	_ = s.Register("panicking", "@daily", func(context.Context) error { panic("oops") })
+
+	s.dispatch(s.tasks["failing"], tick)
+	s.dispatch(s.tasks["panicking"], tick)
+	_ = s.Shutdown(context.Background())
+
+	run, _ := store.LastRun(context.Background(), "failing")
+	assert.Equal(t, run.LastStatus, model.TaskFailed)
+	assert.Equal(t, run.LastError, "boom")
+
+	run, _ = store.LastRun(context.Background(), "panicking")
+	assert.Equal(t, run.LastStatus, model.TaskFailed)
+	assert.Equal(t, run.LastError, "task panicked: oops")
+}
+
+func TestOverlappingTicksAreSkipped(t *testing.T) {
+	s := NewScheduler(NewMemoryStore(), testLogger(), nil)
+
+	release := make(chan struct{})
+	var runs atomic.Int32
+	_ = s.Register("slow", "* * * * *", func(context.Context) error {
+		runs.Add(1)
+		<-release
+		return nil
+	})
+
+	tick := time.Now().Truncate(time.Minute)
+	s.dispatch(s.tasks["slow"], tick)
+	s.dispatch(s.tasks["slow"], tick.Add(time.Minute))
+	close(release)
+
+	_ = s.Shutdown(context.Background())
+	assert.Equal(t, runs.Load(), int32(1))
+}
+
+func TestShutdownCancelsRunningTasks(t *testing.T) {
+	s := NewScheduler(NewMemoryStore(), testLogger(), nil)
+
+	started := make(chan struct{})
+	_ = s.Register("blocking", "@daily", func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	s.Start()
+	s.dispatch(s.tasks["blocking"], time.Now())
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := s.Shutdown(ctx)
+	assert.Equal(t, errors.Is(err, context.DeadlineExceeded), true)
+}
+
+func TestMemoryStorePurgesLocks(t *testing.T) {
+	store := NewMemoryStore()
+	tick := time.Now()
+
+	acquired, _ := store.Acquire(context.Background(), "purge", tick)
+	assert.Equal(t, acquired, true)
+
+	purged, _ := store.PurgeLocks(context.Background(), time.Now().Add(time.Minute))
+	assert.Equal(t, purged, int64(1))
+
+	acquired, _ = store.Acquire(context.Background(), "purge", tick)
+	assert.Equal(t, acquired, true)
+}
@@ -0,0 +1,155 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sync"
+	"time"
+)
+
+// Run describes a finished execution of a task.
+type Run struct {
+	Name     string
+	Tick     time.Time
+	Duration time.Duration
+	Err      error
+}
+
+// Store coordinates task execution between replicas. Acquire must return true
+// for exactly one caller per task and tick.
+type Store interface {
+	Acquire(ctx context.Context, name string, tick time.Time) (bool, error)
+	Record(ctx context.Context, run Run) error
+	LastRun(ctx context.Context, name string) (*model.ScheduledTask, error)
+	PurgeLocks(ctx context.Context, before time.Time) (int64, error)
+}
+
+// DatabaseStore uses the primary key of the scheduled_task_locks table as the
+// lock, which works on any database without advisory lock support.
+type DatabaseStore struct {
+	db *gorm.DB
+}
+
+func NewDatabaseStore(db *gorm.DB) *DatabaseStore {
+	return &DatabaseStore{db: db}
+}
+
+func (s *DatabaseStore) Acquire(ctx context.Context, name string, tick time.Time) (bool, error) {
+	result := s.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.ScheduledTaskLock{Name: name, Tick: tick.UTC()})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+func (s *DatabaseStore) Record(ctx context.Context, run Run) error {
+	return s.db.WithContext(ctx).Save(newScheduledTask(run)).Error
+}
+
+func (s *DatabaseStore) LastRun(ctx context.Context, name string) (*model.ScheduledTask, error) {
+	var task model.ScheduledTask
+
+	err := s.db.WithContext(ctx).Where("name = ?", name).First(&task).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &task, nil
+}
+
+func (s *DatabaseStore) PurgeLocks(ctx context.Context, before time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).Where("created_at < ?", before).Delete(&model.ScheduledTaskLock{})
+
+	return result.RowsAffected, result.Error
+}
+
+// MemoryStore only coordinates within the current process. It is meant for
+// tests and single instance deployments without a database.
+type MemoryStore struct {
+	mu    sync.Mutex
+	locks map[string]time.Time
+	runs  map[string]model.ScheduledTask
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		locks: map[string]time.Time{},
+		runs:  map[string]model.ScheduledTask{},
+	}
+}
+
+func (s *MemoryStore) Acquire(_ context.Context, name string, tick time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := name + "@" + tick.UTC().Format(time.RFC3339)
+	if _, ok := s.locks[key]; ok {
+		return false, nil
+	}
+
+	s.locks[key] = time.Now()
+
+	return true, nil
+}
+
+func (s *MemoryStore) Record(_ context.Context, run Run) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.runs[run.Name] = *newScheduledTask(run)
+
+	return nil
+}
+
+func (s *MemoryStore) LastRun(_ context.Context, name string) (*model.ScheduledTask, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, ok := s.runs[name]
+	if !ok {
+		return nil, nil
+	}
+
+	return &task, nil
+}
+
+func (s *MemoryStore) PurgeLocks(_ context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var purged int64
+	for key, createdAt := range s.locks {
+		if createdAt.Before(before) {
+			delete(s.locks, key)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+func newScheduledTask(run Run) *model.ScheduledTask {
+	task := &model.ScheduledTask{
+		Name:         run.Name,
+		LastRunAt:    run.Tick,
+		LastDuration: run.Duration.Milliseconds(),
+		LastStatus:   model.TaskSucceeded,
+	}
+
+	if run.Err != nil {
+		task.LastStatus = model.TaskFailed
+		task.LastError = run.Err.Error()
+	}
+
+	return task
+}
@@ -0,0 +1,117 @@
+package secret
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Scheme prefixes configuration values that must be looked up by a provider,
+// e.g. "secret://file/run/secrets/db_password" or "secret://env/DB_PASSWORD".
+const Scheme = "secret://"
+
+var ErrNotFound = errors.New("secret not found")
+
+// Provider returns the secret identified by ref, the part of the reference
+// after the provider name.
+type Provider interface {
+	Resolve(ref string) (string, error)
+}
+
+type ProviderFunc func(ref string) (string, error)
+
+func (f ProviderFunc) Resolve(ref string) (string, error) {
+	return f(ref)
+}
+
+// IsReference reports whether value should be resolved by a provider.
+func IsReference(value string) bool {
+	return strings.HasPrefix(value, Scheme)
+}
+
+// Resolver dispatches references to the provider named after the scheme.
+type Resolver struct {
+	providers map[string]Provider
+}
+
+func NewResolver(providers map[string]Provider) *Resolver {
+	return &Resolver{providers: providers}
+}
+
+func (r *Resolver) Resolve(reference string) (string, error) {
+	name, ref, ok := strings.Cut(strings.TrimPrefix(reference, Scheme), "/")
+	if !IsReference(reference) || !ok || ref == "" {
+		return "", fmt.Errorf("secret: invalid reference %q, expected %s<provider>/<name>", reference, Scheme)
+	}
+
+	provider, ok := r.providers[name]
+	if !ok {
+		return "", fmt.Errorf("secret: unknown provider %q in %q", name, reference)
+	}
+
+	value, err := provider.Resolve(ref)
+	if err != nil {
+		return "", fmt.Errorf("secret: %s: %w", reference, err)
+	}
+
+	return value, nil
+}
+
+// FileProvider reads a secret from a file, as mounted by Docker and
+// Kubernetes. The reference is the path, e.g. secret://file/run/secrets/key
+// reads /run/secrets/key. A single trailing newline is dropped.
+func FileProvider() Provider {
+	return ProviderFunc(func(ref string) (string, error) {
+		return ReadFile("/" + strings.TrimPrefix(ref, "/"))
+	})
+}
+
+// EnvProvider reads a secret from an environment variable, or from the file
+// named by its _FILE counterpart.
+func EnvProvider() Provider {
+	return ProviderFunc(func(ref string) (string, error) {
+		value, ok, err := LookupEnv(ref)
+		if err != nil {
+			return "", err
+		}
+
+		if !ok {
+			return "", ErrNotFound
+		}
+
+		return value, nil
+	})
+}
+
+// LookupEnv returns the value of the environment variable name. When it is
+// unset or empty and name_FILE is set, the file it points to is read instead.
+func LookupEnv(name string) (string, bool, error) {
+	if value, ok := os.LookupEnv(name); ok && value != "" {
+		return value, true, nil
+	}
+
+	if path, ok := os.LookupEnv(name + "_FILE"); ok && path != "" {
+		value, err := ReadFile(path)
+		if err != nil {
+			return "", false, fmt.Errorf("%s_FILE: %w", name, err)
+		}
+
+		return value, true, nil
+	}
+
+	value, ok := os.LookupEnv(name)
+
+	return value, ok, nil
+}
+
+func ReadFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	value := strings.TrimSuffix(string(data), "\n")
+
+	return strings.TrimSuffix(value, "\r"), nil
+}
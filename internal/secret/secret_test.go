@@ -0,0 +1,125 @@
+package secret
+
+import (
+	"errors"
+	"github.com/go-playground/assert/v2"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeSecret(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestResolver(t *testing.T) {
+	path := writeSecret(t, "from-file\n")
+	t.Setenv("SECRET_TEST_VALUE", "from-env")
+
+	resolver := NewResolver(map[string]Provider{
+		"file": FileProvider(),
+		"env":  EnvProvider(),
+	})
+
+	value, err := resolver.Resolve("secret://file" + path)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, value, "from-file")
+
+	value, err = resolver.Resolve("secret://env/SECRET_TEST_VALUE")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, value, "from-env")
+
+	_, err = resolver.Resolve("secret://env/SECRET_TEST_MISSING")
+	assert.Equal(t, errors.Is(err, ErrNotFound), true)
+
+	for _, reference := range []string{"secret://vault/key", "secret://env", "plain"} {
+		_, err = resolver.Resolve(reference)
+		assert.NotEqual(t, err, nil)
+	}
+}
+
+func TestLookupEnvReadsFileVariant(t *testing.T) {
+	t.Setenv("SECRET_TEST_PASSWORD_FILE", writeSecret(t, "hunter2\r\n"))
+
+	value, ok, err := LookupEnv("SECRET_TEST_PASSWORD")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, ok, true)
+	assert.Equal(t, value, "hunter2")
+
+	t.Setenv("SECRET_TEST_PASSWORD", "direct")
+
+	value, _, _ = LookupEnv("SECRET_TEST_PASSWORD")
+	assert.Equal(t, value, "direct")
+
+	t.Setenv("SECRET_TEST_BROKEN_FILE", filepath.Join(t.TempDir(), "missing"))
+
+	_, _, err = LookupEnv("SECRET_TEST_BROKEN")
+	assert.NotEqual(t, err, nil)
+}
+
+func TestStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.enc")
+	key, err := GenerateKey()
+	assert.Equal(t, err, nil)
+
+	store, err := OpenStore(path, key)
+	assert.Equal(t, err, nil)
+
+	store.Set("db_password", "hunter2")
+	store.Set("api_key", "abc")
+	assert.Equal(t, store.Save(), nil)
+
+	data, _ := os.ReadFile(path)
+	assert.Equal(t, len(data) > 0, true)
+	assert.NotEqual(t, string(data), "hunter2")
+
+	reopened, err := OpenStore(path, key)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, reopened.Names(), []string{"api_key", "db_password"})
+
+	value, err := reopened.Resolve("db_password")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, value, "hunter2")
+
+	otherKey, _ := GenerateKey()
+	_, err = OpenStore(path, otherKey)
+	assert.NotEqual(t, err, nil)
+
+	_, err = OpenStore(path, "short")
+	assert.Equal(t, errors.Is(err, ErrInvalidKey), true)
+}
+
+func TestCipherRoundTrip(t *testing.T) {
+	key, _ := GenerateKey()
+	c, err := NewCipher(key)
+	assert.Equal(t, err, nil)
+
+	sealed, err := c.EncryptString("refresh-token")
+	assert.Equal(t, err, nil)
+	assert.NotEqual(t, sealed, "refresh-token")
+
+	other, _ := c.EncryptString("refresh-token")
+	assert.NotEqual(t, sealed, other)
+
+	value, err := c.DecryptString(sealed)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, value, "refresh-token")
+
+	otherKey, _ := GenerateKey()
+	otherCipher, _ := NewCipher(otherKey)
+	_, err = otherCipher.DecryptString(sealed)
+	assert.Equal(t, err, ErrDecrypt)
+
+	_, err = c.DecryptString("refresh-token")
+	assert.Equal(t, err, ErrDecrypt)
+
+	_, err = NewCipher("short")
+	assert.Equal(t, err, ErrInvalidKey)
+}
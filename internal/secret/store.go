@@ -0,0 +1,131 @@
+package secret
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"sync"
+)
+
+const keySize = 32
+
+var ErrInvalidKey = errors.New("secret: the key must be 32 bytes encoded in base64")
+
+// Store is a local file of named secrets encrypted with AES-256-GCM. It lets
+// a team commit secrets alongside the configuration and share only the key.
+type Store struct {
+	mu      sync.Mutex
+	path    string
+	cipher  *Cipher
+	secrets map[string]string
+}
+
+// GenerateKey returns a new random store key, encoded in base64.
+func GenerateKey() (string, error) {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// OpenStore decrypts the store at path. A missing file is treated as an empty
+// store, which is created on the first Save.
+func OpenStore(path string, key string) (*Store, error) {
+	c, err := NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Store{path: path, cipher: c, secrets: map[string]string{}}
+
+	encoded, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(string(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("secret: %s is not a secret store", path)
+	}
+
+	plaintext, err := c.Open(data)
+	if err != nil {
+		return nil, fmt.Errorf("secret: cannot decrypt %s, check the store key", path)
+	}
+
+	if err = json.Unmarshal(plaintext, &s.secrets); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Resolve makes the store usable as a Provider.
+func (s *Store) Resolve(name string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	value, ok := s.secrets[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+
+	return value, nil
+}
+
+func (s *Store) Set(name string, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.secrets[name] = value
+}
+
+func (s *Store) Delete(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.secrets, name)
+}
+
+// Names returns the stored secret names in alphabetical order.
+func (s *Store) Names() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make([]string, 0, len(s.secrets))
+	for name := range s.secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Save encrypts the store with a fresh nonce and writes it back to disk.
+func (s *Store) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	plaintext, err := json.Marshal(s.secrets)
+	if err != nil {
+		return err
+	}
+
+	sealed, err := s.cipher.Seal(plaintext)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.path, []byte(base64.StdEncoding.EncodeToString(sealed)), 0o600)
+}
@@ -0,0 +1,46 @@
+package session
+
+import (
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/auth"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/supabase"
+	"github.com/labstack/echo/v4"
+)
+
+// Authenticator accepts the session cookie set by a session login. The
+// principal is read from the access token kept in the session, refreshed as
+// needed.
+type Authenticator struct {
+	manager    *Manager
+	cookieName string
+}
+
+func NewAuthenticator(manager *Manager, cookieName string) *Authenticator {
+	return &Authenticator{manager: manager, cookieName: cookieName}
+}
+
+func (a *Authenticator) Authenticate(c echo.Context) (*auth.Principal, error) {
+	cookie, err := c.Cookie(a.cookieName)
+	if err != nil || cookie.Value == "" {
+		return nil, nil
+	}
+
+	session, err := a.manager.Resolve(c.Request().Context(), cookie.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := a.manager.AccessToken(session)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := supabase.ParseAccessToken(accessToken, a.manager.secret)
+	if err != nil {
+		return nil, err
+	}
+
+	principal := auth.PrincipalFromClaims(claims, accessToken)
+	principal.Method = auth.MethodSession
+
+	return principal, nil
+}
@@ -0,0 +1,315 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/auth"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/model"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/secret"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/supabase"
+	"sync"
+	"time"
+)
+
+const (
+	tokenLength     = 32
+	userAgentLength = 512
+)
+
+var (
+	ErrInvalidSession = errors.New("invalid session")
+	ErrExpiredSession = errors.New("session has expired")
+)
+
+// Device describes where a session is used from.
+type Device struct {
+	UserAgent string
+	IP        string
+}
+
+// Manager tracks the Supabase sessions of users, and keeps the tokens of
+// sessions started with a session cookie, refreshing their access token
+// shortly before it expires. Refreshes are serialized, as Supabase refresh
+// tokens can only be used once. The tokens are stored encrypted with the
+// cipher.
+type Manager struct {
+	store         Store
+	auth          supabase.AuthClientInterface
+	secret        string
+	cipher        *secret.Cipher
+	lifetime      time.Duration
+	refreshBefore time.Duration
+	mu            sync.Mutex
+	now           func() time.Time
+}
+
+func NewManager(store Store, auth supabase.AuthClientInterface, secret string, cipher *secret.Cipher, lifetime time.Duration, refreshBefore time.Duration) *Manager {
+	return &Manager{store: store, auth: auth, secret: secret, cipher: cipher, lifetime: lifetime, refreshBefore: refreshBefore, now: time.Now}
+}
+
+// Lifetime is how long sessions last after login.
+func (m *Manager) Lifetime() time.Duration {
+	return m.lifetime
+}
+
+// Create stores the tokens of a login and returns the cookie value of the new
+// session. Only its hash is stored.
+func (m *Manager) Create(ctx context.Context, details *supabase.AuthenticatedDetails, device Device) (string, *model.Session, error) {
+	claims, err := supabase.ParseAccessToken(details.AccessToken, m.secret)
+	if err != nil {
+		return "", nil, err
+	}
+
+	raw := make([]byte, tokenLength)
+	if _, err = rand.Read(raw); err != nil {
+		return "", nil, err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	tokenHash := hash(token)
+	now := m.now()
+
+	session := &model.Session{
+		SessionID: claims.SessionID,
+		TokenHash: &tokenHash,
+		UserID:    claims.Subject,
+		Email:     claims.Email,
+		ExpiresAt: now.Add(m.lifetime),
+	}
+	setDevice(session, device, now)
+
+	if err = m.setTokens(session, details, now); err != nil {
+		return "", nil, err
+	}
+
+	if err = m.store.Create(ctx, session); err != nil {
+		return "", nil, err
+	}
+
+	return token, session, nil
+}
+
+// Track records the login or token refresh of a session whose tokens are
+// kept by the client. The session is extended by its lifetime each time.
+func (m *Manager) Track(ctx context.Context, details *supabase.AuthenticatedDetails, device Device) (*model.Session, error) {
+	claims, err := supabase.ParseAccessToken(details.AccessToken, m.secret)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := m.store.FindBySessionID(ctx, claims.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := m.now()
+
+	if session == nil {
+		session = &model.Session{SessionID: claims.SessionID, UserID: claims.Subject, Email: claims.Email}
+		setDevice(session, device, now)
+		session.ExpiresAt = now.Add(m.lifetime)
+
+		return session, m.store.Create(ctx, session)
+	}
+
+	if session.RevokedAt != nil {
+		return nil, auth.ErrRevokedSession
+	}
+
+	setDevice(session, device, now)
+	session.ExpiresAt = now.Add(m.lifetime)
+
+	return session, m.store.Update(ctx, session)
+}
+
+// Resolve returns the session of a cookie value, with a usable access token.
+// A session whose token can no longer be refreshed is destroyed.
+func (m *Manager) Resolve(ctx context.Context, token string) (*model.Session, error) {
+	session, err := m.find(ctx, token)
+	if err != nil || !m.needsRefresh(session) {
+		return session, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Another request may have refreshed the session while this one waited.
+	session, err = m.find(ctx, token)
+	if err != nil || !m.needsRefresh(session) {
+		return session, err
+	}
+
+	// Tokens stored under another key cannot be refreshed any more.
+	refreshToken, err := m.cipher.DecryptString(session.RefreshToken)
+	if err != nil {
+		_ = m.store.Delete(ctx, session.ID)
+		return nil, ErrInvalidSession
+	}
+
+	details, serviceErr, err := m.auth.RefreshToken(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if serviceErr != nil {
+		_ = m.store.Delete(ctx, session.ID)
+		return nil, ErrExpiredSession
+	}
+
+	now := m.now()
+	session.LastSeenAt = now
+
+	if err = m.setTokens(session, details, now); err != nil {
+		return nil, err
+	}
+
+	if err = m.store.Update(ctx, session); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// Destroy deletes the session of a cookie value and returns it, or nil when
+// there was none.
+func (m *Manager) Destroy(ctx context.Context, token string) (*model.Session, error) {
+	session, err := m.store.FindByTokenHash(ctx, hash(token))
+	if err != nil || session == nil {
+		return nil, err
+	}
+
+	return session, m.store.Delete(ctx, session.ID)
+}
+
+// Revoked reports whether the Supabase session was revoked. Sessions that
+// were never tracked are not.
+func (m *Manager) Revoked(ctx context.Context, sessionID string) (bool, error) {
+	session, err := m.store.FindBySessionID(ctx, sessionID)
+	if err != nil || session == nil {
+		return false, err
+	}
+
+	return session.RevokedAt != nil, nil
+}
+
+// List returns the active sessions of the user.
+func (m *Manager) List(ctx context.Context, userID string) ([]model.Session, error) {
+	return m.store.List(ctx, userID, m.now())
+}
+
+func (m *Manager) Find(ctx context.Context, userID string, id uint) (*model.Session, error) {
+	session, err := m.store.Find(ctx, userID, id)
+	if err != nil || session == nil || session.RevokedAt != nil || !m.now().Before(session.ExpiresAt) {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// Revoke stops the session from authenticating. The tokens kept for a cookie
+// session are also signed out of Supabase, on a best effort basis as the
+// session is rejected either way.
+func (m *Manager) Revoke(ctx context.Context, session *model.Session) error {
+	now := m.now()
+	session.RevokedAt = &now
+
+	if err := m.store.Update(ctx, session); err != nil {
+		return err
+	}
+
+	if accessToken, err := m.AccessToken(session); err == nil && accessToken != "" {
+		_, _ = m.auth.SignOut(accessToken, supabase.SignOutLocal)
+	}
+
+	return nil
+}
+
+// AccessToken decrypts the access token kept for a cookie session. It is
+// empty for other sessions.
+func (m *Manager) AccessToken(session *model.Session) (string, error) {
+	if session.AccessToken == "" {
+		return "", nil
+	}
+
+	return m.cipher.DecryptString(session.AccessToken)
+}
+
+// RevokeSessionID revokes the Supabase session, if tracked.
+func (m *Manager) RevokeSessionID(ctx context.Context, sessionID string) error {
+	session, err := m.store.FindBySessionID(ctx, sessionID)
+	if err != nil || session == nil || session.RevokedAt != nil {
+		return err
+	}
+
+	return m.Revoke(ctx, session)
+}
+
+// RevokeAll revokes every session of the user, after Supabase signed them
+// all out.
+func (m *Manager) RevokeAll(ctx context.Context, userID string) error {
+	return m.store.RevokeAll(ctx, userID, m.now())
+}
+
+func (m *Manager) find(ctx context.Context, token string) (*model.Session, error) {
+	session, err := m.store.FindByTokenHash(ctx, hash(token))
+	if err != nil {
+		return nil, err
+	}
+
+	if session == nil {
+		return nil, ErrInvalidSession
+	}
+
+	if session.RevokedAt != nil {
+		return nil, auth.ErrRevokedSession
+	}
+
+	if !m.now().Before(session.ExpiresAt) {
+		_ = m.store.Delete(ctx, session.ID)
+		return nil, ErrExpiredSession
+	}
+
+	return session, nil
+}
+
+func (m *Manager) needsRefresh(session *model.Session) bool {
+	return !m.now().Add(m.refreshBefore).Before(session.TokenExpiresAt)
+}
+
+func setDevice(session *model.Session, device Device, now time.Time) {
+	session.UserAgent = device.UserAgent
+	if len(session.UserAgent) > userAgentLength {
+		session.UserAgent = session.UserAgent[:userAgentLength]
+	}
+
+	session.IP = device.IP
+	session.LastSeenAt = now
+}
+
+func (m *Manager) setTokens(session *model.Session, details *supabase.AuthenticatedDetails, now time.Time) error {
+	accessToken, err := m.cipher.EncryptString(details.AccessToken)
+	if err != nil {
+		return err
+	}
+
+	refreshToken, err := m.cipher.EncryptString(details.RefreshToken)
+	if err != nil {
+		return err
+	}
+
+	session.AccessToken = accessToken
+	session.RefreshToken = refreshToken
+	session.TokenExpiresAt = now.Add(time.Duration(details.ExpiresIn) * time.Second)
+
+	return nil
+}
+
+func hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
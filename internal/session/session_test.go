@@ -0,0 +1,298 @@
+package session
+
+import (
+	"context"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/auth"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/model"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/secret"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/supabase"
+	"github.com/go-playground/assert/v2"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fakeAuth refreshes tokens into numbered ones, unless told to fail.
+type fakeAuth struct {
+	supabase.AuthClientInterface
+	refreshes int
+	signedOut []string
+	fail      bool
+}
+
+func (a *fakeAuth) RefreshToken(refreshToken string) (*supabase.AuthenticatedDetails, *supabase.ErrorResponse, error) {
+	if a.fail {
+		return nil, &supabase.ErrorResponse{Code: http.StatusBadRequest, ErrorCode: "refresh_token_not_found"}, nil
+	}
+
+	a.refreshes++
+
+	return &supabase.AuthenticatedDetails{
+		AccessToken:  "access-" + refreshToken,
+		RefreshToken: refreshToken + "+",
+		ExpiresIn:    3600,
+	}, nil, nil
+}
+
+func (a *fakeAuth) SignOut(token string, scope string) (*supabase.ErrorResponse, error) {
+	a.signedOut = append(a.signedOut, token+":"+scope)
+
+	return nil, nil
+}
+
+func accessToken(sessionID string) string {
+	claims := supabase.AccessTokenClaims{
+		Subject:   "123",
+		Role:      supabase.AuthenticatedRole,
+		Audience:  supabase.Audience{supabase.AuthenticatedRole},
+		Email:     "jane@example.com",
+		SessionID: sessionID,
+		ExpiresAt: time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC).Unix(),
+	}
+	claims.Set("user_role", "admin")
+
+	token, _ := supabase.SignAccessToken(claims, "secret")
+
+	return token
+}
+
+func details(sessionID string) *supabase.AuthenticatedDetails {
+	return &supabase.AuthenticatedDetails{
+		AccessToken:  accessToken(sessionID),
+		RefreshToken: "refresh",
+		ExpiresIn:    3600,
+	}
+}
+
+func newCipher() *secret.Cipher {
+	key, _ := secret.GenerateKey()
+	cipher, _ := secret.NewCipher(key)
+
+	return cipher
+}
+
+func newManager(client *fakeAuth) (*Manager, *time.Time) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	manager := NewManager(NewMemoryStore(), client, "secret", newCipher(), 24*time.Hour, time.Minute)
+	manager.now = func() time.Time { return now }
+
+	return manager, &now
+}
+
+func login(t *testing.T, manager *Manager, sessionID string) string {
+	token, session, err := manager.Create(context.Background(), details(sessionID), Device{UserAgent: "Firefox", IP: "192.0.2.1"})
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, session.UserID, "123")
+	assert.Equal(t, session.SessionID, sessionID)
+	assert.Equal(t, *session.TokenHash, hash(token))
+
+	return token
+}
+
+func TestManagerRefreshesNearExpiry(t *testing.T) {
+	client := &fakeAuth{}
+	manager, now := newManager(client)
+	token := login(t, manager, "s1")
+
+	session, err := manager.Resolve(context.Background(), token)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, decrypt(manager, session.AccessToken), accessToken("s1"))
+	assert.Equal(t, client.refreshes, 0)
+
+	*now = now.Add(59*time.Minute + time.Second)
+
+	session, err = manager.Resolve(context.Background(), token)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, decrypt(manager, session.AccessToken), "access-refresh")
+	assert.Equal(t, session.TokenExpiresAt, now.Add(time.Hour))
+	assert.Equal(t, session.LastSeenAt, *now)
+
+	// The refreshed tokens are kept, so the next request uses them as is.
+	session, err = manager.Resolve(context.Background(), token)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, decrypt(manager, session.RefreshToken), "refresh+")
+	assert.Equal(t, client.refreshes, 1)
+}
+
+func decrypt(manager *Manager, value string) string {
+	plaintext, _ := manager.cipher.DecryptString(value)
+
+	return plaintext
+}
+
+func TestManagerEncryptsTokens(t *testing.T) {
+	client := &fakeAuth{}
+	manager, now := newManager(client)
+	token := login(t, manager, "s1")
+
+	stored, _ := manager.store.FindByTokenHash(context.Background(), hash(token))
+	assert.NotEqual(t, stored.AccessToken, accessToken("s1"))
+	assert.NotEqual(t, stored.RefreshToken, "refresh")
+
+	plaintext, err := manager.AccessToken(stored)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, plaintext, details("s1").AccessToken)
+
+	// Tokens stored under another key end the session rather than refresh.
+	manager.cipher = newCipher()
+	*now = now.Add(time.Hour)
+
+	_, err = manager.Resolve(context.Background(), token)
+	assert.Equal(t, err, ErrInvalidSession)
+	assert.Equal(t, client.refreshes, 0)
+
+	_, err = manager.Resolve(context.Background(), token)
+	assert.Equal(t, err, ErrInvalidSession)
+}
+
+func TestManagerEndsSessions(t *testing.T) {
+	client := &fakeAuth{}
+	manager, now := newManager(client)
+
+	_, err := manager.Resolve(context.Background(), "unknown")
+	assert.Equal(t, err, ErrInvalidSession)
+
+	// A refresh token rejected by Supabase ends the session.
+	rejected := login(t, manager, "s1")
+	*now = now.Add(time.Hour)
+	client.fail = true
+
+	_, err = manager.Resolve(context.Background(), rejected)
+	assert.Equal(t, err, ErrExpiredSession)
+
+	_, err = manager.Resolve(context.Background(), rejected)
+	assert.Equal(t, err, ErrInvalidSession)
+
+	// Sessions end after their lifetime, however often they are refreshed.
+	client.fail = false
+	expired := login(t, manager, "s2")
+	*now = now.Add(24 * time.Hour)
+
+	_, err = manager.Resolve(context.Background(), expired)
+	assert.Equal(t, err, ErrExpiredSession)
+
+	destroyed := login(t, manager, "s3")
+
+	session, err := manager.Destroy(context.Background(), destroyed)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, session.SessionID, "s3")
+
+	_, err = manager.Resolve(context.Background(), destroyed)
+	assert.Equal(t, err, ErrInvalidSession)
+}
+
+func TestManagerTracksAndRevokesSessions(t *testing.T) {
+	client := &fakeAuth{}
+	manager, now := newManager(client)
+	ctx := context.Background()
+
+	tracked, err := manager.Track(ctx, details("s1"), Device{UserAgent: "curl", IP: "192.0.2.1"})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, tracked.TokenHash, (*string)(nil))
+
+	cookie := login(t, manager, "s2")
+
+	// A refresh of the same Supabase session updates it.
+	*now = now.Add(time.Hour)
+	tracked, err = manager.Track(ctx, details("s1"), Device{UserAgent: "curl", IP: "192.0.2.2"})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, tracked.IP, "192.0.2.2")
+	assert.Equal(t, tracked.LastSeenAt, *now)
+	assert.Equal(t, tracked.ExpiresAt, now.Add(24*time.Hour))
+
+	sessions, err := manager.List(ctx, "123")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(sessions), 2)
+
+	// Revoking a cookie session signs its tokens out of Supabase.
+	session, err := manager.Find(ctx, "123", sessions[1].ID)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, manager.Revoke(ctx, session), nil)
+	assert.Equal(t, client.signedOut, []string{accessToken("s2") + ":local"})
+
+	_, err = manager.Resolve(ctx, cookie)
+	assert.Equal(t, err, auth.ErrRevokedSession)
+
+	session, err = manager.Find(ctx, "123", sessions[1].ID)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, session, (*model.Session)(nil))
+
+	assert.Equal(t, manager.RevokeAll(ctx, "123"), nil)
+
+	revoked, err := manager.Revoked(ctx, "s1")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, revoked, true)
+
+	revoked, err = manager.Revoked(ctx, "untracked")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, revoked, false)
+
+	_, err = manager.Track(ctx, details("s1"), Device{})
+	assert.Equal(t, err, auth.ErrRevokedSession)
+
+	sessions, err = manager.List(ctx, "123")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(sessions), 0)
+}
+
+func TestPurgeKeepsRevokedSessions(t *testing.T) {
+	manager, now := newManager(&fakeAuth{})
+	ctx := context.Background()
+	retention := 90 * 24 * time.Hour
+
+	_, err := manager.Track(ctx, details("s1"), Device{})
+	assert.Equal(t, err, nil)
+	_, err = manager.Track(ctx, details("s2"), Device{})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, manager.RevokeSessionID(ctx, "s1"), nil)
+
+	// Past their expiry, only the session still in use is purged.
+	*now = now.Add(48 * time.Hour)
+	purged, err := manager.store.Purge(ctx, *now, now.Add(-retention))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, purged, int64(1))
+
+	// The refresh token of the revoked session cannot track it again.
+	_, err = manager.Track(ctx, details("s1"), Device{})
+	assert.Equal(t, err, auth.ErrRevokedSession)
+
+	*now = now.Add(retention)
+	purged, err = manager.store.Purge(ctx, *now, now.Add(-retention))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, purged, int64(1))
+}
+
+func TestAuthenticator(t *testing.T) {
+	manager, _ := newManager(&fakeAuth{})
+	token := login(t, manager, "s1")
+	authenticator := NewAuthenticator(manager, "session")
+
+	tests := []struct {
+		name              string
+		cookie            string
+		expectedPrincipal *auth.Principal
+		expectedErr       error
+	}{
+		{"No cookie", "", nil, nil},
+		{"Session cookie", token, &auth.Principal{Type: auth.PrincipalUser, Method: auth.MethodSession, ID: "123", Email: "jane@example.com", Role: "admin", Token: accessToken("s1"), SessionID: "s1"}, nil},
+		{"Unknown session", "unknown", nil, ErrInvalidSession},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "session", Value: tt.cookie})
+			}
+
+			principal, err := authenticator.Authenticate(echo.New().NewContext(req, httptest.NewRecorder()))
+
+			assert.Equal(t, err, tt.expectedErr)
+			assert.Equal(t, principal, tt.expectedPrincipal)
+		})
+	}
+}
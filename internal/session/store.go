@@ -0,0 +1,228 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"github.com/Fortress-Digital/go-rest-skeleton/internal/model"
+	"gorm.io/gorm"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	DriverDatabase = "database"
+	DriverMemory   = "memory"
+)
+
+// Store persists sessions. Lookups return nil without an error when no
+// session matches.
+type Store interface {
+	Create(ctx context.Context, session *model.Session) error
+	Update(ctx context.Context, session *model.Session) error
+	FindByTokenHash(ctx context.Context, hash string) (*model.Session, error)
+	FindBySessionID(ctx context.Context, sessionID string) (*model.Session, error)
+	Find(ctx context.Context, userID string, id uint) (*model.Session, error)
+	// List returns the sessions of the user neither revoked nor expired at
+	// the given time.
+	List(ctx context.Context, userID string, at time.Time) ([]model.Session, error)
+	RevokeAll(ctx context.Context, userID string, at time.Time) error
+	Delete(ctx context.Context, id uint) error
+	// Purge deletes the sessions that expired before the given time, and the
+	// revoked ones revoked before revokedBefore.
+	Purge(ctx context.Context, before time.Time, revokedBefore time.Time) (int64, error)
+}
+
+type DatabaseStore struct {
+	db *gorm.DB
+}
+
+func NewDatabaseStore(db *gorm.DB) *DatabaseStore {
+	return &DatabaseStore{db: db}
+}
+
+func (s *DatabaseStore) Create(ctx context.Context, session *model.Session) error {
+	return s.db.WithContext(ctx).Create(session).Error
+}
+
+func (s *DatabaseStore) Update(ctx context.Context, session *model.Session) error {
+	return s.db.WithContext(ctx).Save(session).Error
+}
+
+func (s *DatabaseStore) FindByTokenHash(ctx context.Context, hash string) (*model.Session, error) {
+	return s.first(s.db.WithContext(ctx).Where("token_hash = ?", hash))
+}
+
+func (s *DatabaseStore) FindBySessionID(ctx context.Context, sessionID string) (*model.Session, error) {
+	return s.first(s.db.WithContext(ctx).Where("session_id = ?", sessionID))
+}
+
+func (s *DatabaseStore) Find(ctx context.Context, userID string, id uint) (*model.Session, error) {
+	return s.first(s.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID))
+}
+
+func (s *DatabaseStore) List(ctx context.Context, userID string, at time.Time) ([]model.Session, error) {
+	var sessions []model.Session
+
+	err := s.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, at).
+		Order("id").
+		Find(&sessions).Error
+
+	return sessions, err
+}
+
+func (s *DatabaseStore) RevokeAll(ctx context.Context, userID string, at time.Time) error {
+	return s.db.WithContext(ctx).Model(&model.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		UpdateColumn("revoked_at", at).Error
+}
+
+func (s *DatabaseStore) Delete(ctx context.Context, id uint) error {
+	return s.db.WithContext(ctx).Delete(&model.Session{}, id).Error
+}
+
+func (s *DatabaseStore) Purge(ctx context.Context, before time.Time, revokedBefore time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).
+		Where("expires_at < ? AND revoked_at IS NULL", before).
+		Or("revoked_at < ?", revokedBefore).
+		Delete(&model.Session{})
+
+	return result.RowsAffected, result.Error
+}
+
+func (s *DatabaseStore) first(query *gorm.DB) (*model.Session, error) {
+	var session model.Session
+
+	err := query.First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// MemoryStore keeps sessions in the current process, so they are lost on
+// restart and not shared between instances.
+type MemoryStore struct {
+	mu       sync.Mutex
+	nextID   uint
+	sessions map[uint]model.Session
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{sessions: map[uint]model.Session{}}
+}
+
+func (s *MemoryStore) Create(_ context.Context, session *model.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	session.ID = s.nextID
+	session.CreatedAt = time.Now()
+	session.UpdatedAt = session.CreatedAt
+	s.sessions[session.ID] = *session
+
+	return nil
+}
+
+func (s *MemoryStore) Update(_ context.Context, session *model.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.sessions[session.ID]; !ok {
+		return nil
+	}
+
+	session.UpdatedAt = time.Now()
+	s.sessions[session.ID] = *session
+
+	return nil
+}
+
+func (s *MemoryStore) FindByTokenHash(_ context.Context, hash string) (*model.Session, error) {
+	return s.first(func(session model.Session) bool {
+		return session.TokenHash != nil && *session.TokenHash == hash
+	})
+}
+
+func (s *MemoryStore) FindBySessionID(_ context.Context, sessionID string) (*model.Session, error) {
+	return s.first(func(session model.Session) bool { return session.SessionID == sessionID })
+}
+
+func (s *MemoryStore) Find(_ context.Context, userID string, id uint) (*model.Session, error) {
+	return s.first(func(session model.Session) bool { return session.ID == id && session.UserID == userID })
+}
+
+func (s *MemoryStore) List(_ context.Context, userID string, at time.Time) ([]model.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var sessions []model.Session
+	for _, session := range s.sessions {
+		if session.UserID == userID && session.RevokedAt == nil && session.ExpiresAt.After(at) {
+			sessions = append(sessions, session)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].ID < sessions[j].ID })
+
+	return sessions, nil
+}
+
+func (s *MemoryStore) RevokeAll(_ context.Context, userID string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, session := range s.sessions {
+		if session.UserID == userID && session.RevokedAt == nil {
+			session.RevokedAt = &at
+			s.sessions[id] = session
+		}
+	}
+
+	return nil
+}
+
+func (s *MemoryStore) Delete(_ context.Context, id uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, id)
+
+	return nil
+}
+
+func (s *MemoryStore) Purge(_ context.Context, before time.Time, revokedBefore time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var purged int64
+	for id, session := range s.sessions {
+		expired := session.RevokedAt == nil && session.ExpiresAt.Before(before)
+		if expired || session.RevokedAt != nil && session.RevokedAt.Before(revokedBefore) {
+			delete(s.sessions, id)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+func (s *MemoryStore) first(match func(session model.Session) bool) (*model.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, session := range s.sessions {
+		if match(session) {
+			return &session, nil
+		}
+	}
+
+	return nil, nil
+}
@@ -14,8 +14,41 @@ type UserCredentials struct {
 	Email    string
 	Password string
 	Data     interface{}
+	Security *MetaSecurity `json:"gotrue_meta_security,omitempty"`
 }
 
+// MetaSecurity passes the captcha token of the client on to Supabase Auth,
+// when its captcha protection is enabled.
+type MetaSecurity struct {
+	CaptchaToken string `json:"captcha_token"`
+}
+
+type recoverRequest struct {
+	Email    string        `json:"email"`
+	Security *MetaSecurity `json:"gotrue_meta_security,omitempty"`
+}
+
+type resendRequest struct {
+	Type     string        `json:"type"`
+	Email    string        `json:"email"`
+	Security *MetaSecurity `json:"gotrue_meta_security,omitempty"`
+}
+
+type verifyRequest struct {
+	Type      string `json:"type"`
+	TokenHash string `json:"token_hash"`
+}
+
+// Types of the email links verified by Verify.
+const (
+	VerifySignup      = "signup"
+	VerifyEmail       = "email"
+	VerifyEmailChange = "email_change"
+	VerifyInvite      = "invite"
+	VerifyRecovery    = "recovery"
+	VerifyMagicLink   = "magiclink"
+)
+
 type User struct {
 	ID                 string                    `json:"id"`
 	Aud                string                    `json:"aud"`
@@ -26,10 +59,30 @@ type User struct {
 	ConfirmationSentAt time.Time                 `json:"confirmation_sent_at"`
 	AppMetadata        struct{ provider string } `json:"app_metadata"`
 	UserMetadata       map[string]interface{}    `json:"user_metadata"`
+	Identities         []UserIdentity            `json:"identities"`
 	CreatedAt          time.Time                 `json:"created_at"`
 	UpdatedAt          time.Time                 `json:"updated_at"`
 }
 
+type UserIdentity struct {
+	ID       string `json:"id"`
+	Provider string `json:"provider"`
+}
+
+// Existing reports whether a signup returned an obfuscated copy of a user
+// already registered, which Supabase Auth sends without identities.
+func (u *User) Existing() bool {
+	return len(u.Identities) == 0
+}
+
+// Scopes of SignOut: the session of the token, every session of the user or
+// every other session of the user.
+const (
+	SignOutLocal  = "local"
+	SignOutGlobal = "global"
+	SignOutOthers = "others"
+)
+
 type AuthenticatedDetails struct {
 	AccessToken          string `json:"access_token"`
 	TokenType            string `json:"token_type"`
@@ -44,10 +97,12 @@ type AuthClientInterface interface {
 	newAuthRequestWithContext(method string, uri string, data any) (*http.Request, error)
 	SignUp(credentials UserCredentials) (*User, *ErrorResponse, error)
 	SignIn(credentials UserCredentials) (*AuthenticatedDetails, *ErrorResponse, error)
-	SignOut(userToken string) (*ErrorResponse, error)
-	ForgottenPassword(email string) (*ErrorResponse, error)
+	SignOut(userToken string, scope string) (*ErrorResponse, error)
+	ForgottenPassword(email string, security *MetaSecurity) (*ErrorResponse, error)
 	ResetPassword(userToken string, password string) (*ErrorResponse, error)
 	RefreshToken(refreshToken string) (*AuthenticatedDetails, *ErrorResponse, error)
+	ResendConfirmation(email string, security *MetaSecurity) (*ErrorResponse, error)
+	Verify(tokenHash string, verificationType string) (*AuthenticatedDetails, *ErrorResponse, error)
 }
 
 type AuthClient struct {
@@ -110,8 +165,15 @@ func (a *AuthClient) SignIn(credentials UserCredentials) (*AuthenticatedDetails,
 	return &res, nil, nil
 }
 
-func (a *AuthClient) SignOut(userToken string) (*ErrorResponse, error) {
-	req, err := a.newAuthRequestWithContext(http.MethodPost, "logout", nil)
+// SignOut ends the sessions in scope, leaving Supabase to choose when the
+// scope is empty.
+func (a *AuthClient) SignOut(userToken string, scope string) (*ErrorResponse, error) {
+	uri := "logout"
+	if scope != "" {
+		uri += "?scope=" + scope
+	}
+
+	req, err := a.newAuthRequestWithContext(http.MethodPost, uri, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -133,8 +195,8 @@ func (a *AuthClient) SignOut(userToken string) (*ErrorResponse, error) {
 	return nil, nil
 }
 
-func (a *AuthClient) ForgottenPassword(email string) (*ErrorResponse, error) {
-	reqBody := map[string]string{"email": email}
+func (a *AuthClient) ForgottenPassword(email string, security *MetaSecurity) (*ErrorResponse, error) {
+	reqBody := recoverRequest{Email: email, Security: security}
 	req, err := a.newAuthRequestWithContext(http.MethodPost, "recover", reqBody)
 	if err != nil {
 		return nil, err
@@ -198,3 +260,50 @@ func (a *AuthClient) RefreshToken(refreshToken string) (*AuthenticatedDetails, *
 
 	return &res, nil, nil
 }
+
+// ResendConfirmation sends the signup confirmation email to the address
+// again.
+func (a *AuthClient) ResendConfirmation(email string, security *MetaSecurity) (*ErrorResponse, error) {
+	reqBody := resendRequest{Type: VerifySignup, Email: email, Security: security}
+	req, err := a.newAuthRequestWithContext(http.MethodPost, "resend", reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	errRes := ErrorResponse{}
+	hasCustomError, err := a.client.sendCustomRequest(req, nil, &errRes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if hasCustomError {
+		return &errRes, nil
+	}
+
+	return nil, nil
+}
+
+// Verify redeems the token hash of an email link, returning the session it
+// signs the user in with.
+func (a *AuthClient) Verify(tokenHash string, verificationType string) (*AuthenticatedDetails, *ErrorResponse, error) {
+	reqBody := verifyRequest{Type: verificationType, TokenHash: tokenHash}
+	req, err := a.newAuthRequestWithContext(http.MethodPost, "verify", reqBody)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	res := AuthenticatedDetails{}
+	errRes := ErrorResponse{}
+	hasCustomError, err := a.client.sendCustomRequest(req, &res, &errRes)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if hasCustomError {
+		return nil, &errRes, nil
+	}
+
+	return &res, nil, nil
+}
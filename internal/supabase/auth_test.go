@@ -2,6 +2,7 @@ package supabase
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"github.com/go-playground/assert/v2"
@@ -37,6 +38,16 @@ func (m *SupabaseClientMock) sendCustomRequest(req *http.Request, successValue i
 	return args.Bool(0), args.Error(1)
 }
 
+func (m *SupabaseClientMock) newRawRequestWithContext(ctx context.Context, method string, reqURL string, body io.Reader) (*http.Request, error) {
+	args := m.Called(ctx, method, reqURL, body)
+	return args.Get(0).(*http.Request), args.Error(1)
+}
+
+func (m *SupabaseClientMock) send(req *http.Request) (*http.Response, error) {
+	args := m.Called(req)
+	return args.Get(0).(*http.Response), args.Error(1)
+}
+
 func (m *SupabaseClientMock) newRequestWithContext(method string, reqURL string, data interface{}) (*http.Request, error) {
 	args := m.Called(method, reqURL, data)
 	return args.Get(0).(*http.Request), args.Error(1)
@@ -316,7 +327,7 @@ func TestSignOut(t *testing.T) {
 				On("sendCustomRequest", req, nil, &ErrorResponse{}).
 				Return(tt.sendCustomRequestRes, tt.sendCustomRequestErr)
 
-			systemErr, err := authClient.SignOut("token")
+			systemErr, err := authClient.SignOut("token", "")
 
 			expectedHeader := ""
 			if tt.newRequestWithContextErr == nil {
@@ -330,6 +341,36 @@ func TestSignOut(t *testing.T) {
 	}
 }
 
+func TestUserCredentialsMetaSecurity(t *testing.T) {
+	body, _ := json.Marshal(UserCredentials{Email: "test@example.com", Password: "password"})
+	assert.Equal(t, bytes.Contains(body, []byte("gotrue_meta_security")), false)
+
+	body, _ = json.Marshal(UserCredentials{Security: &MetaSecurity{CaptchaToken: "token"}})
+	assert.Equal(t, bytes.Contains(body, []byte(`"gotrue_meta_security":{"captcha_token":"token"}`)), true)
+}
+
+func TestSignOutScope(t *testing.T) {
+	reqUrl, _ := url.Parse("http://localhost")
+	req := &http.Request{Header: map[string][]string{}, URL: reqUrl}
+
+	mockClient := new(SupabaseClientMock)
+	authClient := &AuthClient{client: mockClient}
+
+	mockClient.
+		On("newRequestWithContext", http.MethodPost, "auth/v1/logout?scope=global", nil).
+		Return(req, nil)
+
+	mockClient.
+		On("sendCustomRequest", req, nil, &ErrorResponse{}).
+		Return(false, nil)
+
+	systemErr, err := authClient.SignOut("token", SignOutGlobal)
+
+	assert.Equal(t, systemErr, nil)
+	assert.Equal(t, err, nil)
+	mockClient.AssertExpectations(t)
+}
+
 func TestForgottenPassword(t *testing.T) {
 	tests := []struct {
 		name                     string
@@ -387,7 +428,7 @@ func TestForgottenPassword(t *testing.T) {
 			mockClient := new(SupabaseClientMock)
 			authClient := &AuthClient{client: mockClient}
 			email := "test@example.com"
-			contextBody := map[string]string{"email": email}
+			contextBody := recoverRequest{Email: email}
 			mockClient.
 				On("newRequestWithContext", http.MethodPost, "auth/v1/recover", contextBody).
 				Return(req, tt.newRequestWithContextErr)
@@ -396,7 +437,7 @@ func TestForgottenPassword(t *testing.T) {
 				On("sendCustomRequest", req, nil, &ErrorResponse{}).
 				Return(tt.sendCustomRequestRes, tt.sendCustomRequestErr)
 
-			systemErr, err := authClient.ForgottenPassword(email)
+			systemErr, err := authClient.ForgottenPassword(email, nil)
 
 			assert.Equal(t, systemErr, tt.expectedSystemErr)
 			assert.Equal(t, err, tt.expectedErr)
@@ -564,3 +605,158 @@ func TestRefreshToken(t *testing.T) {
 		})
 	}
 }
+
+func TestResendConfirmation(t *testing.T) {
+	tests := []struct {
+		name                     string
+		newRequestWithContextErr error
+		sendCustomRequestRes     bool
+		sendCustomRequestErr     error
+		expectedSystemErr        any
+		expectedErr              error
+	}{
+		{
+			name:                     "Should return nil error",
+			newRequestWithContextErr: nil,
+			sendCustomRequestRes:     false,
+			sendCustomRequestErr:     nil,
+			expectedSystemErr:        nil,
+			expectedErr:              nil,
+		},
+		{
+			name:                     "New request with context should return error",
+			newRequestWithContextErr: errors.New("new request error"),
+			sendCustomRequestRes:     false,
+			sendCustomRequestErr:     nil,
+			expectedSystemErr:        nil,
+			expectedErr:              errors.New("new request error"),
+		},
+		{
+			name:                     "Send custom request should return error",
+			newRequestWithContextErr: nil,
+			sendCustomRequestRes:     false,
+			sendCustomRequestErr:     errors.New("send custom request error"),
+			expectedSystemErr:        nil,
+			expectedErr:              errors.New("send custom request error"),
+		},
+		{
+			name:                     "Send custom request should return service system error",
+			newRequestWithContextErr: nil,
+			sendCustomRequestRes:     true,
+			sendCustomRequestErr:     nil,
+			expectedSystemErr: &ErrorResponse{
+				Code:      400,
+				ErrorCode: "error message",
+			},
+			expectedErr: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reqUrl, _ := url.Parse("http://localhost")
+			req := &http.Request{
+				Header: map[string][]string{},
+				URL:    reqUrl,
+			}
+
+			mockClient := new(SupabaseClientMock)
+			authClient := &AuthClient{client: mockClient}
+			email := "test@example.com"
+			security := &MetaSecurity{CaptchaToken: "captcha"}
+			contextBody := resendRequest{Type: VerifySignup, Email: email, Security: security}
+			mockClient.
+				On("newRequestWithContext", http.MethodPost, "auth/v1/resend", contextBody).
+				Return(req, tt.newRequestWithContextErr)
+
+			mockClient.
+				On("sendCustomRequest", req, nil, &ErrorResponse{}).
+				Return(tt.sendCustomRequestRes, tt.sendCustomRequestErr)
+
+			systemErr, err := authClient.ResendConfirmation(email, security)
+
+			assert.Equal(t, systemErr, tt.expectedSystemErr)
+			assert.Equal(t, err, tt.expectedErr)
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name                     string
+		newRequestWithContextErr error
+		sendCustomRequestRes     bool
+		sendCustomRequestErr     error
+		expectedAuthenticated    any
+		expectedSystemErr        any
+		expectedErr              error
+	}{
+		{
+			name:                     "Should return authenticated details",
+			newRequestWithContextErr: nil,
+			sendCustomRequestRes:     false,
+			sendCustomRequestErr:     nil,
+			expectedAuthenticated:    &AuthenticatedDetails{},
+			expectedSystemErr:        nil,
+			expectedErr:              nil,
+		},
+		{
+			name:                     "New request with context should return error",
+			newRequestWithContextErr: errors.New("new request error"),
+			sendCustomRequestRes:     false,
+			sendCustomRequestErr:     nil,
+			expectedAuthenticated:    nil,
+			expectedSystemErr:        nil,
+			expectedErr:              errors.New("new request error"),
+		},
+		{
+			name:                     "Send custom request should return error",
+			newRequestWithContextErr: nil,
+			sendCustomRequestRes:     false,
+			sendCustomRequestErr:     errors.New("send custom request error"),
+			expectedAuthenticated:    nil,
+			expectedSystemErr:        nil,
+			expectedErr:              errors.New("send custom request error"),
+		},
+		{
+			name:                     "Send custom request should return service system error",
+			newRequestWithContextErr: nil,
+			sendCustomRequestRes:     true,
+			sendCustomRequestErr:     nil,
+			expectedAuthenticated:    nil,
+			expectedSystemErr: &ErrorResponse{
+				Code:      400,
+				ErrorCode: "error message",
+			},
+			expectedErr: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reqUrl, _ := url.Parse("http://localhost")
+			req := &http.Request{
+				Header: map[string][]string{},
+				URL:    reqUrl,
+			}
+
+			mockClient := new(SupabaseClientMock)
+			authClient := &AuthClient{client: mockClient}
+			contextBody := verifyRequest{Type: VerifySignup, TokenHash: "hash"}
+
+			mockClient.
+				On("newRequestWithContext", http.MethodPost, "auth/v1/verify", contextBody).
+				Return(req, tt.newRequestWithContextErr)
+
+			mockClient.
+				On("sendCustomRequest", req, &AuthenticatedDetails{}, &ErrorResponse{}).
+				Return(tt.sendCustomRequestRes, tt.sendCustomRequestErr)
+
+			authenticated, systemErr, err := authClient.Verify("hash", VerifySignup)
+
+			assert.Equal(t, authenticated, tt.expectedAuthenticated)
+			assert.Equal(t, systemErr, tt.expectedSystemErr)
+			assert.Equal(t, err, tt.expectedErr)
+		})
+	}
+}
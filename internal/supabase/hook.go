@@ -0,0 +1,233 @@
+package supabase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+const (
+	HookDecisionContinue = "continue"
+	HookDecisionReject   = "reject"
+)
+
+type Audience []string
+
+type AccessTokenClaims struct {
+	Issuer       string                 `json:"iss"`
+	Audience     Audience               `json:"aud"`
+	ExpiresAt    int64                  `json:"exp"`
+	IssuedAt     int64                  `json:"iat"`
+	Subject      string                 `json:"sub"`
+	Role         string                 `json:"role"`
+	Aal          string                 `json:"aal"`
+	SessionID    string                 `json:"session_id"`
+	Email        string                 `json:"email"`
+	Phone        string                 `json:"phone"`
+	IsAnonymous  bool                   `json:"is_anonymous"`
+	AppMetadata  map[string]interface{} `json:"app_metadata,omitempty"`
+	UserMetadata map[string]interface{} `json:"user_metadata,omitempty"`
+	Amr          []interface{}          `json:"amr,omitempty"`
+	Custom       map[string]interface{} `json:"-"`
+}
+
+type CustomAccessTokenInput struct {
+	UserID               string            `json:"user_id"`
+	Claims               AccessTokenClaims `json:"claims"`
+	AuthenticationMethod string            `json:"authentication_method"`
+}
+
+type CustomAccessTokenOutput struct {
+	Claims AccessTokenClaims `json:"claims"`
+}
+
+type EmailData struct {
+	Token           string `json:"token"`
+	TokenHash       string `json:"token_hash"`
+	RedirectTo      string `json:"redirect_to"`
+	EmailActionType string `json:"email_action_type"`
+	SiteURL         string `json:"site_url"`
+	TokenNew        string `json:"token_new"`
+	TokenHashNew    string `json:"token_hash_new"`
+}
+
+type SendEmailInput struct {
+	User      User      `json:"user"`
+	EmailData EmailData `json:"email_data"`
+}
+
+type MFAVerificationAttemptInput struct {
+	FactorID   string `json:"factor_id"`
+	FactorType string `json:"factor_type"`
+	UserID     string `json:"user_id"`
+	Valid      bool   `json:"valid"`
+}
+
+type MFAVerificationAttemptOutput struct {
+	Decision string `json:"decision"`
+	Message  string `json:"message,omitempty"`
+}
+
+type PasswordVerificationAttemptInput struct {
+	UserID string `json:"user_id"`
+	Valid  bool   `json:"valid"`
+}
+
+// BeforeUserCreatedInput carries the user Supabase Auth is about to create.
+// Responding without an error lets it create the user.
+type BeforeUserCreatedInput struct {
+	User User `json:"user"`
+}
+
+type PasswordVerificationAttemptOutput struct {
+	Decision         string `json:"decision"`
+	Message          string `json:"message,omitempty"`
+	ShouldLogoutUser bool   `json:"should_logout_user,omitempty"`
+}
+
+// HookError is the error shape Supabase Auth understands from a hook endpoint.
+// Returning one from a hook lets the implementation pick the status code.
+type HookError struct {
+	HTTPCode int    `json:"http_code"`
+	Message  string `json:"message"`
+}
+
+func (e *HookError) Error() string {
+	return e.Message
+}
+
+func NewHookError(code int, message string) *HookError {
+	return &HookError{HTTPCode: code, Message: message}
+}
+
+// ErrHookFailed is returned to Supabase Auth in place of errors that did not
+// originate from a hook implementation, as it may show them to end users.
+var ErrHookFailed = NewHookError(http.StatusInternalServerError, "the request could not be completed")
+
+// AsHookError converts any error into a HookError, falling back to
+// ErrHookFailed for errors that did not originate from a hook implementation.
+func AsHookError(err error) *HookError {
+	var hookErr *HookError
+	if errors.As(err, &hookErr) {
+		return hookErr
+	}
+
+	return ErrHookFailed
+}
+
+type CustomAccessTokenHook interface {
+	CustomAccessToken(ctx context.Context, input CustomAccessTokenInput) (*CustomAccessTokenOutput, error)
+}
+
+type SendEmailHook interface {
+	SendEmail(ctx context.Context, input SendEmailInput) error
+}
+
+type MFAVerificationAttemptHook interface {
+	MFAVerificationAttempt(ctx context.Context, input MFAVerificationAttemptInput) (*MFAVerificationAttemptOutput, error)
+}
+
+type PasswordVerificationAttemptHook interface {
+	PasswordVerificationAttempt(ctx context.Context, input PasswordVerificationAttemptInput) (*PasswordVerificationAttemptOutput, error)
+}
+
+type BeforeUserCreatedHook interface {
+	BeforeUserCreated(ctx context.Context, input BeforeUserCreatedInput) error
+}
+
+// Hooks groups the hook implementations served by the application. A nil
+// field means the corresponding hook is not served.
+type Hooks struct {
+	CustomAccessToken           CustomAccessTokenHook
+	SendEmail                   SendEmailHook
+	MFAVerificationAttempt      MFAVerificationAttemptHook
+	PasswordVerificationAttempt PasswordVerificationAttemptHook
+	BeforeUserCreated           BeforeUserCreatedHook
+}
+
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+
+	return json.Marshal([]string(a))
+}
+
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+
+	*a = multiple
+
+	return nil
+}
+
+// Set adds a custom claim which is not part of the standard Supabase claims.
+func (c *AccessTokenClaims) Set(key string, value interface{}) {
+	if c.Custom == nil {
+		c.Custom = map[string]interface{}{}
+	}
+
+	c.Custom[key] = value
+}
+
+func (c AccessTokenClaims) MarshalJSON() ([]byte, error) {
+	type claims AccessTokenClaims
+
+	data, err := json.Marshal(claims(c))
+	if err != nil || len(c.Custom) == 0 {
+		return data, err
+	}
+
+	merged := map[string]interface{}{}
+	if err = json.Unmarshal(data, &merged); err != nil {
+		return nil, err
+	}
+
+	for key, value := range c.Custom {
+		if _, ok := merged[key]; !ok {
+			merged[key] = value
+		}
+	}
+
+	return json.Marshal(merged)
+}
+
+func (c *AccessTokenClaims) UnmarshalJSON(data []byte) error {
+	type claims AccessTokenClaims
+
+	var known claims
+	if err := json.Unmarshal(data, &known); err != nil {
+		return err
+	}
+
+	var all map[string]interface{}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return err
+	}
+
+	for _, key := range standardClaims {
+		delete(all, key)
+	}
+
+	*c = AccessTokenClaims(known)
+	if len(all) > 0 {
+		c.Custom = all
+	}
+
+	return nil
+}
+
+var standardClaims = []string{
+	"iss", "aud", "exp", "iat", "sub", "role", "aal", "session_id", "email", "phone",
+	"is_anonymous", "app_metadata", "user_metadata", "amr",
+}
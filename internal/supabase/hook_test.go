@@ -0,0 +1,54 @@
+package supabase
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-playground/assert/v2"
+	"net/http"
+	"testing"
+)
+
+func TestAccessTokenClaimsRoundTrip(t *testing.T) {
+	data := []byte(`{"aud":"authenticated","sub":"123","role":"authenticated","session_id":"abc","tenant_id":"t1"}`)
+
+	var claims AccessTokenClaims
+	err := json.Unmarshal(data, &claims)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, claims.Audience, Audience{"authenticated"})
+	assert.Equal(t, claims.Subject, "123")
+	assert.Equal(t, claims.SessionID, "abc")
+	assert.Equal(t, claims.Custom, map[string]interface{}{"tenant_id": "t1"})
+
+	claims.Set("user_role", "admin")
+	claims.Set("role", "ignored")
+
+	encoded, err := json.Marshal(claims)
+	assert.Equal(t, err, nil)
+
+	var result map[string]interface{}
+	_ = json.Unmarshal(encoded, &result)
+
+	assert.Equal(t, result["aud"], "authenticated")
+	assert.Equal(t, result["role"], "authenticated")
+	assert.Equal(t, result["tenant_id"], "t1")
+	assert.Equal(t, result["user_role"], "admin")
+}
+
+func TestAudienceUnmarshalArray(t *testing.T) {
+	var aud Audience
+	err := json.Unmarshal([]byte(`["a","b"]`), &aud)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, aud, Audience{"a", "b"})
+}
+
+func TestAsHookError(t *testing.T) {
+	hookErr := NewHookError(http.StatusForbidden, "forbidden")
+
+	assert.Equal(t, AsHookError(hookErr), hookErr)
+	assert.Equal(t, AsHookError(errors.New("Error 1062 (23000): Duplicate entry")), &HookError{
+		HTTPCode: http.StatusInternalServerError,
+		Message:  "the request could not be completed",
+	})
+}
@@ -0,0 +1,76 @@
+package supabase
+
+import (
+	"github.com/go-playground/assert/v2"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseAccessToken(t *testing.T) {
+	user := AccessTokenClaims{
+		Subject:   "123",
+		Role:      AuthenticatedRole,
+		Audience:  Audience{AuthenticatedRole},
+		Email:     "test@example.com",
+		ExpiresAt: time.Now().Add(time.Hour).Unix(),
+	}
+	valid, _ := SignAccessToken(user, "secret")
+
+	claims := user
+	claims.ExpiresAt = time.Now().Add(-time.Minute).Unix()
+	expired, _ := SignAccessToken(claims, "secret")
+
+	claims = user
+	claims.ExpiresAt = 0
+	withoutExpiry, _ := SignAccessToken(claims, "secret")
+
+	claims = user
+	claims.Subject = ""
+	withoutSubject, _ := SignAccessToken(claims, "secret")
+
+	claims = user
+	claims.Audience = Audience{"other"}
+	otherAudience, _ := SignAccessToken(claims, "secret")
+
+	// The anon key of a project is signed with the JWT secret too.
+	anonKey, _ := SignAccessToken(AccessTokenClaims{
+		Issuer:    "supabase",
+		Role:      "anon",
+		IssuedAt:  time.Now().Unix(),
+		ExpiresAt: time.Now().Add(10 * 365 * 24 * time.Hour).Unix(),
+	}, "secret")
+
+	otherSecret, _ := SignAccessToken(AccessTokenClaims{Subject: "123"}, "other")
+	parts := strings.Split(valid, ".")
+
+	tests := []struct {
+		name        string
+		token       string
+		expectedSub string
+		expectedErr error
+	}{
+		{"Valid token", valid, "123", nil},
+		{"Expired token", expired, "", ErrTokenExpired},
+		{"Token without expiry", withoutExpiry, "", ErrInvalidToken},
+		{"Token without subject", withoutSubject, "", ErrInvalidToken},
+		{"Other audience", otherAudience, "", ErrInvalidToken},
+		{"Anon key", anonKey, "", ErrInvalidToken},
+		{"Wrong secret", otherSecret, "", ErrInvalidToken},
+		{"Malformed token", "abc", "", ErrInvalidToken},
+		{"Unsupported algorithm", "eyJhbGciOiJub25lIn0." + parts[1] + ".", "", ErrInvalidToken},
+		{"Tampered payload", parts[0] + "." + parts[0] + "." + parts[2], "", ErrInvalidToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := ParseAccessToken(tt.token, "secret")
+
+			assert.Equal(t, err, tt.expectedErr)
+			if err == nil {
+				assert.Equal(t, claims.Subject, tt.expectedSub)
+				assert.Equal(t, claims.Email, "test@example.com")
+			}
+		})
+	}
+}
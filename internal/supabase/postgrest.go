@@ -0,0 +1,377 @@
+package supabase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	RestEndpoint = "rest/v1"
+)
+
+type CountOption string
+
+const (
+	CountNone      CountOption = ""
+	CountExact     CountOption = "exact"
+	CountPlanned   CountOption = "planned"
+	CountEstimated CountOption = "estimated"
+)
+
+type PostgrestError struct {
+	Status  int    `json:"-"`
+	Code    string `json:"code"`
+	Details string `json:"details"`
+	Hint    string `json:"hint"`
+	Message string `json:"message"`
+}
+
+func (e *PostgrestError) Error() string {
+	return e.Message
+}
+
+type PostgrestResponse struct {
+	Status       int
+	ContentRange string
+	Count        *int64
+}
+
+type PostgrestClient struct {
+	client SupabaseClientInterface
+	token  string
+}
+
+func NewPostgrestClient(baseURL string, supabaseKey string) *PostgrestClient {
+	client := CreateClient(baseURL, supabaseKey)
+	return &PostgrestClient{client: client}
+}
+
+// WithToken returns a client which forwards the end user's access token, so
+// row-level security policies are evaluated for that user.
+func (p *PostgrestClient) WithToken(userToken string) *PostgrestClient {
+	return &PostgrestClient{client: p.client, token: userToken}
+}
+
+func (p *PostgrestClient) From(table string) *QueryBuilder {
+	return &QueryBuilder{client: p, table: table}
+}
+
+func (p *PostgrestClient) Rpc(function string, params any) *FilterBuilder {
+	if params == nil {
+		params = map[string]any{}
+	}
+
+	return newFilterBuilder(p, http.MethodPost, "rpc/"+function, params)
+}
+
+type QueryBuilder struct {
+	client *PostgrestClient
+	table  string
+}
+
+func (q *QueryBuilder) Select(columns string) *FilterBuilder {
+	f := newFilterBuilder(q.client, http.MethodGet, q.table, nil)
+	f.params.Set("select", columns)
+
+	return f
+}
+
+func (q *QueryBuilder) Insert(data any) *FilterBuilder {
+	return newFilterBuilder(q.client, http.MethodPost, q.table, data)
+}
+
+func (q *QueryBuilder) Upsert(data any, onConflict string) *FilterBuilder {
+	f := newFilterBuilder(q.client, http.MethodPost, q.table, data)
+	f.prefer = append(f.prefer, "resolution=merge-duplicates")
+
+	if onConflict != "" {
+		f.params.Set("on_conflict", onConflict)
+	}
+
+	return f
+}
+
+func (q *QueryBuilder) Update(data any) *FilterBuilder {
+	return newFilterBuilder(q.client, http.MethodPatch, q.table, data)
+}
+
+func (q *QueryBuilder) Delete() *FilterBuilder {
+	return newFilterBuilder(q.client, http.MethodDelete, q.table, nil)
+}
+
+type FilterBuilder struct {
+	client  *PostgrestClient
+	method  string
+	path    string
+	body    any
+	params  url.Values
+	headers http.Header
+	prefer  []string
+}
+
+func newFilterBuilder(client *PostgrestClient, method string, path string, body any) *FilterBuilder {
+	return &FilterBuilder{
+		client:  client,
+		method:  method,
+		path:    path,
+		body:    body,
+		params:  url.Values{},
+		headers: http.Header{},
+	}
+}
+
+// Filter adds a raw PostgREST filter, e.g. Filter("age", "gte", 18).
+func (f *FilterBuilder) Filter(column string, operator string, value any) *FilterBuilder {
+	f.params.Add(column, fmt.Sprintf("%s.%v", operator, value))
+
+	return f
+}
+
+func (f *FilterBuilder) Not(column string, operator string, value any) *FilterBuilder {
+	return f.Filter(column, "not."+operator, value)
+}
+
+func (f *FilterBuilder) Eq(column string, value any) *FilterBuilder {
+	return f.Filter(column, "eq", value)
+}
+
+func (f *FilterBuilder) Neq(column string, value any) *FilterBuilder {
+	return f.Filter(column, "neq", value)
+}
+
+func (f *FilterBuilder) Gt(column string, value any) *FilterBuilder {
+	return f.Filter(column, "gt", value)
+}
+
+func (f *FilterBuilder) Gte(column string, value any) *FilterBuilder {
+	return f.Filter(column, "gte", value)
+}
+
+func (f *FilterBuilder) Lt(column string, value any) *FilterBuilder {
+	return f.Filter(column, "lt", value)
+}
+
+func (f *FilterBuilder) Lte(column string, value any) *FilterBuilder {
+	return f.Filter(column, "lte", value)
+}
+
+func (f *FilterBuilder) Like(column string, pattern string) *FilterBuilder {
+	return f.Filter(column, "like", pattern)
+}
+
+func (f *FilterBuilder) Ilike(column string, pattern string) *FilterBuilder {
+	return f.Filter(column, "ilike", pattern)
+}
+
+// Is checks for exact equality against null, true or false.
+func (f *FilterBuilder) Is(column string, value any) *FilterBuilder {
+	if value == nil {
+		value = "null"
+	}
+
+	return f.Filter(column, "is", value)
+}
+
+func (f *FilterBuilder) In(column string, values ...any) *FilterBuilder {
+	quoted := make([]string, 0, len(values))
+	for _, value := range values {
+		quoted = append(quoted, quoteFilterValue(fmt.Sprint(value)))
+	}
+
+	return f.Filter(column, "in", fmt.Sprintf("(%s)", strings.Join(quoted, ",")))
+}
+
+// RangeLt, RangeGt, RangeLte, RangeGte and RangeAdjacent compare range
+// columns, e.g. RangeLt("during", "[2000-01-01,2000-02-01)").
+func (f *FilterBuilder) RangeLt(column string, value string) *FilterBuilder {
+	return f.Filter(column, "sl", value)
+}
+
+func (f *FilterBuilder) RangeGt(column string, value string) *FilterBuilder {
+	return f.Filter(column, "sr", value)
+}
+
+func (f *FilterBuilder) RangeLte(column string, value string) *FilterBuilder {
+	return f.Filter(column, "nxr", value)
+}
+
+func (f *FilterBuilder) RangeGte(column string, value string) *FilterBuilder {
+	return f.Filter(column, "nxl", value)
+}
+
+func (f *FilterBuilder) RangeAdjacent(column string, value string) *FilterBuilder {
+	return f.Filter(column, "adj", value)
+}
+
+func (f *FilterBuilder) Order(column string, ascending bool) *FilterBuilder {
+	direction := "desc"
+	if ascending {
+		direction = "asc"
+	}
+
+	order := fmt.Sprintf("%s.%s", column, direction)
+	if existing := f.params.Get("order"); existing != "" {
+		order = existing + "," + order
+	}
+
+	f.params.Set("order", order)
+
+	return f
+}
+
+func (f *FilterBuilder) Limit(limit int) *FilterBuilder {
+	f.params.Set("limit", strconv.Itoa(limit))
+
+	return f
+}
+
+// Range paginates the result using the Range header. Both bounds are
+// inclusive and zero based.
+func (f *FilterBuilder) Range(from int, to int) *FilterBuilder {
+	f.headers.Set("Range-Unit", "items")
+	f.headers.Set("Range", fmt.Sprintf("%d-%d", from, to))
+
+	return f
+}
+
+func (f *FilterBuilder) Count(option CountOption) *FilterBuilder {
+	if option != CountNone {
+		f.prefer = append(f.prefer, "count="+string(option))
+	}
+
+	return f
+}
+
+// Returning asks PostgREST to send the affected rows back from an insert,
+// update, upsert or delete.
+func (f *FilterBuilder) Returning(columns string) *FilterBuilder {
+	f.prefer = append(f.prefer, "return=representation")
+
+	if columns != "" {
+		f.params.Set("select", columns)
+	}
+
+	return f
+}
+
+// Single expects exactly one row and decodes it as an object instead of an
+// array.
+func (f *FilterBuilder) Single() *FilterBuilder {
+	f.headers.Set("Accept", "application/vnd.pgrst.object+json")
+
+	return f
+}
+
+func (f *FilterBuilder) Execute(ctx context.Context, result any) (*PostgrestResponse, *PostgrestError, error) {
+	req, err := f.newRequest(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	res, err := f.client.client.send(req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer res.Body.Close()
+
+	pgRes := &PostgrestResponse{
+		Status:       res.StatusCode,
+		ContentRange: res.Header.Get("Content-Range"),
+		Count:        parseContentRangeCount(res.Header.Get("Content-Range")),
+	}
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= 300 {
+		errRes := PostgrestError{}
+		if err = json.NewDecoder(res.Body).Decode(&errRes); err != nil {
+			return nil, nil, fmt.Errorf("unknown error, status code: %d", res.StatusCode)
+		}
+
+		errRes.Status = res.StatusCode
+
+		return pgRes, &errRes, nil
+	}
+
+	if result != nil && res.StatusCode != http.StatusNoContent {
+		if err = json.NewDecoder(res.Body).Decode(result); err != nil && err != io.EOF {
+			return nil, nil, err
+		}
+	}
+
+	return pgRes, nil, nil
+}
+
+func (f *FilterBuilder) newRequest(ctx context.Context) (*http.Request, error) {
+	var body io.Reader
+	if f.body != nil {
+		data, err := json.Marshal(f.body)
+		if err != nil {
+			return nil, err
+		}
+
+		body = bytes.NewReader(data)
+	}
+
+	uri := fmt.Sprintf("%s/%s", RestEndpoint, f.path)
+	if len(f.params) > 0 {
+		uri = fmt.Sprintf("%s?%s", uri, f.params.Encode())
+	}
+
+	req, err := f.client.client.newRawRequestWithContext(ctx, f.method, uri, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	for key, values := range f.headers {
+		req.Header[key] = values
+	}
+
+	if len(f.prefer) > 0 {
+		req.Header.Set("Prefer", strings.Join(f.prefer, ","))
+	}
+
+	if f.client.token != "" {
+		injectAuthorizationHeader(req, f.client.token)
+	}
+
+	return req, nil
+}
+
+func quoteFilterValue(value string) string {
+	if !strings.ContainsAny(value, ",.:()\" \\") {
+		return value
+	}
+
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+
+	return fmt.Sprintf(`"%s"`, value)
+}
+
+// parseContentRangeCount extracts the total from a "0-9/100" Content-Range
+// header. The total is only present when a count was requested.
+func parseContentRangeCount(contentRange string) *int64 {
+	_, total, found := strings.Cut(contentRange, "/")
+	if !found || total == "*" {
+		return nil
+	}
+
+	count, err := strconv.ParseInt(total, 10, 64)
+	if err != nil {
+		return nil
+	}
+
+	return &count
+}
@@ -0,0 +1,210 @@
+package supabase
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-playground/assert/v2"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type recordedRequest struct {
+	method  string
+	path    string
+	query   string
+	headers http.Header
+	body    string
+}
+
+func newPostgrestServer(t *testing.T, status int, headers map[string]string, body string) (*PostgrestClient, *recordedRequest) {
+	recorded := &recordedRequest{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+
+		recorded.method = r.Method
+		recorded.path = r.URL.Path
+		recorded.query = r.URL.RawQuery
+		recorded.headers = r.Header.Clone()
+		recorded.body = string(data)
+
+		for key, value := range headers {
+			w.Header().Set(key, value)
+		}
+
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	return NewPostgrestClient(server.URL, "anon-key"), recorded
+}
+
+type todo struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+}
+
+func TestPostgrestSelect(t *testing.T) {
+	client, recorded := newPostgrestServer(t, http.StatusPartialContent, map[string]string{
+		"Content-Range": "0-1/42",
+	}, `[{"id":1,"title":"one"},{"id":2,"title":"two"}]`)
+
+	var todos []todo
+	res, pgErr, err := client.WithToken("user-jwt").
+		From("todos").
+		Select("id,title").
+		Eq("user_id", "123").
+		In("status", "open", "in progress").
+		Ilike("title", "*milk*").
+		Gte("priority", 2).
+		Order("created_at", false).
+		Order("id", true).
+		Range(0, 1).
+		Count(CountExact).
+		Execute(context.Background(), &todos)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, pgErr, (*PostgrestError)(nil))
+	assert.Equal(t, todos, []todo{{ID: 1, Title: "one"}, {ID: 2, Title: "two"}})
+	assert.Equal(t, res.Status, http.StatusPartialContent)
+	assert.Equal(t, *res.Count, int64(42))
+
+	assert.Equal(t, recorded.method, http.MethodGet)
+	assert.Equal(t, recorded.path, "/rest/v1/todos")
+	assert.Equal(t, recorded.query, "order=created_at.desc%2Cid.asc&priority=gte.2&select=id%2Ctitle&status=in.%28open%2C%22in+progress%22%29&title=ilike.%2Amilk%2A&user_id=eq.123")
+	assert.Equal(t, recorded.headers.Get("apikey"), "anon-key")
+	assert.Equal(t, recorded.headers.Get("Authorization"), "Bearer user-jwt")
+	assert.Equal(t, recorded.headers.Get("Range"), "0-1")
+	assert.Equal(t, recorded.headers.Get("Range-Unit"), "items")
+	assert.Equal(t, recorded.headers.Get("Prefer"), "count=exact")
+}
+
+func TestPostgrestMutations(t *testing.T) {
+	tests := []struct {
+		name          string
+		build         func(client *PostgrestClient) *FilterBuilder
+		expectedVerb  string
+		expectedQuery string
+		expectedBody  string
+		expectedPref  string
+	}{
+		{
+			name: "Insert",
+			build: func(client *PostgrestClient) *FilterBuilder {
+				return client.From("todos").Insert(todo{Title: "new"}).Returning("id")
+			},
+			expectedVerb:  http.MethodPost,
+			expectedQuery: "select=id",
+			expectedBody:  `{"id":0,"title":"new"}`,
+			expectedPref:  "return=representation",
+		},
+		{
+			name: "Upsert",
+			build: func(client *PostgrestClient) *FilterBuilder {
+				return client.From("todos").Upsert([]todo{{ID: 1, Title: "new"}}, "id")
+			},
+			expectedVerb:  http.MethodPost,
+			expectedQuery: "on_conflict=id",
+			expectedBody:  `[{"id":1,"title":"new"}]`,
+			expectedPref:  "resolution=merge-duplicates",
+		},
+		{
+			name: "Update",
+			build: func(client *PostgrestClient) *FilterBuilder {
+				return client.From("todos").Update(map[string]string{"title": "done"}).Eq("id", 1).Count(CountPlanned)
+			},
+			expectedVerb:  http.MethodPatch,
+			expectedQuery: "id=eq.1",
+			expectedBody:  `{"title":"done"}`,
+			expectedPref:  "count=planned",
+		},
+		{
+			name: "Delete",
+			build: func(client *PostgrestClient) *FilterBuilder {
+				return client.From("todos").Delete().Is("deleted_at", nil).Not("id", "eq", 3)
+			},
+			expectedVerb:  http.MethodDelete,
+			expectedQuery: "deleted_at=is.null&id=not.eq.3",
+			expectedBody:  "",
+			expectedPref:  "",
+		},
+		{
+			name: "Rpc",
+			build: func(client *PostgrestClient) *FilterBuilder {
+				return client.Rpc("search_todos", map[string]string{"term": "milk"}).Limit(5)
+			},
+			expectedVerb:  http.MethodPost,
+			expectedQuery: "limit=5",
+			expectedBody:  `{"term":"milk"}`,
+			expectedPref:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, recorded := newPostgrestServer(t, http.StatusNoContent, nil, "")
+
+			res, pgErr, err := tt.build(client).Execute(context.Background(), nil)
+
+			assert.Equal(t, err, nil)
+			assert.Equal(t, pgErr, (*PostgrestError)(nil))
+			assert.Equal(t, res.Count, (*int64)(nil))
+			assert.Equal(t, recorded.method, tt.expectedVerb)
+			assert.Equal(t, recorded.query, tt.expectedQuery)
+			assert.Equal(t, recorded.body, tt.expectedBody)
+			assert.Equal(t, recorded.headers.Get("Prefer"), tt.expectedPref)
+			assert.Equal(t, recorded.headers.Get("Authorization"), "")
+		})
+	}
+}
+
+func TestPostgrestSingle(t *testing.T) {
+	client, recorded := newPostgrestServer(t, http.StatusOK, nil, `{"id":1,"title":"one"}`)
+
+	var result todo
+	_, pgErr, err := client.From("todos").Select("*").Eq("id", 1).Single().Execute(context.Background(), &result)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, pgErr, (*PostgrestError)(nil))
+	assert.Equal(t, result, todo{ID: 1, Title: "one"})
+	assert.Equal(t, recorded.headers.Get("Accept"), "application/vnd.pgrst.object+json")
+}
+
+func TestPostgrestError(t *testing.T) {
+	body, _ := json.Marshal(PostgrestError{
+		Code:    "42501",
+		Message: "permission denied for table todos",
+	})
+	client, _ := newPostgrestServer(t, http.StatusForbidden, nil, string(body))
+
+	_, pgErr, err := client.From("todos").Select("*").Execute(context.Background(), nil)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, pgErr, &PostgrestError{
+		Status:  http.StatusForbidden,
+		Code:    "42501",
+		Message: "permission denied for table todos",
+	})
+}
+
+func TestPostgrestUnknownError(t *testing.T) {
+	client, _ := newPostgrestServer(t, http.StatusBadGateway, nil, "<html>")
+
+	res, pgErr, err := client.From("todos").Select("*").Execute(context.Background(), nil)
+
+	assert.Equal(t, err.Error(), "unknown error, status code: 502")
+	assert.Equal(t, pgErr, (*PostgrestError)(nil))
+	assert.Equal(t, res, (*PostgrestResponse)(nil))
+}
+
+func TestParseContentRangeCount(t *testing.T) {
+	count := int64(10)
+
+	assert.Equal(t, parseContentRangeCount("0-9/10"), &count)
+	assert.Equal(t, parseContentRangeCount("*/10"), &count)
+	assert.Equal(t, parseContentRangeCount("0-9/*"), (*int64)(nil))
+	assert.Equal(t, parseContentRangeCount(""), (*int64)(nil))
+}
@@ -0,0 +1,421 @@
+package supabase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	StorageEndpoint = "storage/v1"
+)
+
+type StorageError struct {
+	StatusCode string `json:"statusCode"`
+	ErrorCode  string `json:"error"`
+	Message    string `json:"message"`
+}
+
+func (e *StorageError) Error() string {
+	return e.Message
+}
+
+// Status returns the HTTP status reported by the storage API, which encodes
+// it as a string in the error body.
+func (e *StorageError) Status() int {
+	status, err := strconv.Atoi(e.StatusCode)
+	if err != nil || status < http.StatusBadRequest {
+		return http.StatusBadRequest
+	}
+
+	return status
+}
+
+type Bucket struct {
+	ID               string    `json:"id"`
+	Name             string    `json:"name"`
+	Owner            string    `json:"owner"`
+	Public           bool      `json:"public"`
+	FileSizeLimit    *int64    `json:"file_size_limit"`
+	AllowedMimeTypes []string  `json:"allowed_mime_types"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+type BucketOptions struct {
+	ID               string   `json:"id,omitempty"`
+	Name             string   `json:"name,omitempty"`
+	Public           bool     `json:"public"`
+	FileSizeLimit    *int64   `json:"file_size_limit,omitempty"`
+	AllowedMimeTypes []string `json:"allowed_mime_types,omitempty"`
+}
+
+type FileObject struct {
+	ID             string                 `json:"id"`
+	Name           string                 `json:"name"`
+	BucketID       string                 `json:"bucket_id"`
+	UpdatedAt      time.Time              `json:"updated_at"`
+	CreatedAt      time.Time              `json:"created_at"`
+	LastAccessedAt time.Time              `json:"last_accessed_at"`
+	Metadata       map[string]interface{} `json:"metadata"`
+}
+
+type UploadOptions struct {
+	ContentType   string
+	ContentLength int64
+	CacheControl  string
+	Upsert        bool
+}
+
+type UploadResult struct {
+	ID  string `json:"Id"`
+	Key string `json:"Key"`
+}
+
+type DownloadResult struct {
+	Body          io.ReadCloser
+	ContentType   string
+	ContentLength int64
+}
+
+type SortBy struct {
+	Column string `json:"column"`
+	Order  string `json:"order"`
+}
+
+type ListOptions struct {
+	Limit  int     `json:"limit,omitempty"`
+	Offset int     `json:"offset,omitempty"`
+	SortBy *SortBy `json:"sortBy,omitempty"`
+	Search string  `json:"search,omitempty"`
+}
+
+type SignedURL struct {
+	Path      string `json:"path"`
+	SignedURL string `json:"signedURL"`
+	Error     string `json:"error"`
+}
+
+type SignedUploadURL struct {
+	URL   string `json:"url"`
+	Path  string `json:"path"`
+	Token string `json:"token"`
+}
+
+type StorageClient struct {
+	client  SupabaseClientInterface
+	baseURL string
+	token   string
+}
+
+func NewStorageClient(baseURL string, supabaseKey string) *StorageClient {
+	client := CreateClient(baseURL, supabaseKey)
+	return &StorageClient{client: client, baseURL: baseURL}
+}
+
+// WithToken returns a client which forwards the end user's access token, so
+// storage policies are evaluated for that user.
+func (s *StorageClient) WithToken(userToken string) *StorageClient {
+	return &StorageClient{client: s.client, baseURL: s.baseURL, token: userToken}
+}
+
+func (s *StorageClient) ListBuckets(ctx context.Context) ([]Bucket, *StorageError, error) {
+	var res []Bucket
+	errRes, err := s.sendJSON(ctx, http.MethodGet, "bucket", nil, &res)
+	if errRes != nil || err != nil {
+		return nil, errRes, err
+	}
+
+	return res, nil, nil
+}
+
+func (s *StorageClient) GetBucket(ctx context.Context, id string) (*Bucket, *StorageError, error) {
+	res := Bucket{}
+	errRes, err := s.sendJSON(ctx, http.MethodGet, "bucket/"+id, nil, &res)
+	if errRes != nil || err != nil {
+		return nil, errRes, err
+	}
+
+	return &res, nil, nil
+}
+
+func (s *StorageClient) CreateBucket(ctx context.Context, options BucketOptions) (*StorageError, error) {
+	if options.Name == "" {
+		options.Name = options.ID
+	}
+
+	return s.sendJSON(ctx, http.MethodPost, "bucket", options, nil)
+}
+
+func (s *StorageClient) UpdateBucket(ctx context.Context, id string, options BucketOptions) (*StorageError, error) {
+	options.ID = id
+	if options.Name == "" {
+		options.Name = id
+	}
+
+	return s.sendJSON(ctx, http.MethodPut, "bucket/"+id, options, nil)
+}
+
+func (s *StorageClient) EmptyBucket(ctx context.Context, id string) (*StorageError, error) {
+	return s.sendJSON(ctx, http.MethodPost, "bucket/"+id+"/empty", map[string]string{}, nil)
+}
+
+func (s *StorageClient) DeleteBucket(ctx context.Context, id string) (*StorageError, error) {
+	return s.sendJSON(ctx, http.MethodDelete, "bucket/"+id, nil, nil)
+}
+
+// Upload streams body to the object at path without buffering it in memory.
+func (s *StorageClient) Upload(ctx context.Context, bucket string, path string, body io.Reader, options UploadOptions) (*UploadResult, *StorageError, error) {
+	return s.upload(ctx, http.MethodPost, objectURI("object", bucket, path), body, options)
+}
+
+func (s *StorageClient) Update(ctx context.Context, bucket string, path string, body io.Reader, options UploadOptions) (*UploadResult, *StorageError, error) {
+	return s.upload(ctx, http.MethodPut, objectURI("object", bucket, path), body, options)
+}
+
+func (s *StorageClient) Download(ctx context.Context, bucket string, path string) (*DownloadResult, *StorageError, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, objectURI("object", bucket, path), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	res, err := s.client.send(req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+
+		errRes, err := decodeStorageError(res)
+		return nil, errRes, err
+	}
+
+	return &DownloadResult{
+		Body:          res.Body,
+		ContentType:   res.Header.Get("Content-Type"),
+		ContentLength: res.ContentLength,
+	}, nil, nil
+}
+
+func (s *StorageClient) Move(ctx context.Context, bucket string, from string, to string) (*StorageError, error) {
+	return s.sendJSON(ctx, http.MethodPost, "object/move", objectTransfer(bucket, from, to), nil)
+}
+
+func (s *StorageClient) Copy(ctx context.Context, bucket string, from string, to string) (*StorageError, error) {
+	return s.sendJSON(ctx, http.MethodPost, "object/copy", objectTransfer(bucket, from, to), nil)
+}
+
+func (s *StorageClient) Remove(ctx context.Context, bucket string, paths []string) ([]FileObject, *StorageError, error) {
+	var res []FileObject
+	errRes, err := s.sendJSON(ctx, http.MethodDelete, "object/"+bucket, map[string][]string{"prefixes": paths}, &res)
+	if errRes != nil || err != nil {
+		return nil, errRes, err
+	}
+
+	return res, nil, nil
+}
+
+func (s *StorageClient) List(ctx context.Context, bucket string, prefix string, options ListOptions) ([]FileObject, *StorageError, error) {
+	body := struct {
+		Prefix string `json:"prefix"`
+		ListOptions
+	}{
+		Prefix:      prefix,
+		ListOptions: options,
+	}
+
+	var res []FileObject
+	errRes, err := s.sendJSON(ctx, http.MethodPost, "object/list/"+bucket, body, &res)
+	if errRes != nil || err != nil {
+		return nil, errRes, err
+	}
+
+	return res, nil, nil
+}
+
+// CreateSignedURL returns an absolute URL granting read access to the object
+// for expiresIn seconds.
+func (s *StorageClient) CreateSignedURL(ctx context.Context, bucket string, path string, expiresIn int) (string, *StorageError, error) {
+	res := SignedURL{}
+	body := map[string]int{"expiresIn": expiresIn}
+	errRes, err := s.sendJSON(ctx, http.MethodPost, objectURI("object/sign", bucket, path), body, &res)
+	if errRes != nil || err != nil {
+		return "", errRes, err
+	}
+
+	return s.absoluteURL(res.SignedURL), nil, nil
+}
+
+func (s *StorageClient) CreateSignedURLs(ctx context.Context, bucket string, paths []string, expiresIn int) ([]SignedURL, *StorageError, error) {
+	body := map[string]any{"expiresIn": expiresIn, "paths": paths}
+
+	var res []SignedURL
+	errRes, err := s.sendJSON(ctx, http.MethodPost, "object/sign/"+bucket, body, &res)
+	if errRes != nil || err != nil {
+		return nil, errRes, err
+	}
+
+	for i := range res {
+		if res[i].SignedURL != "" {
+			res[i].SignedURL = s.absoluteURL(res[i].SignedURL)
+		}
+	}
+
+	return res, nil, nil
+}
+
+// CreateSignedUploadURL returns a URL and token a client can use to upload the
+// object directly to storage, bypassing this service.
+func (s *StorageClient) CreateSignedUploadURL(ctx context.Context, bucket string, path string) (*SignedUploadURL, *StorageError, error) {
+	res := SignedUploadURL{}
+	errRes, err := s.sendJSON(ctx, http.MethodPost, objectURI("object/upload/sign", bucket, path), map[string]string{}, &res)
+	if errRes != nil || err != nil {
+		return nil, errRes, err
+	}
+
+	signed, err := url.Parse(s.absoluteURL(res.URL))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &SignedUploadURL{
+		URL:   signed.String(),
+		Path:  path,
+		Token: signed.Query().Get("token"),
+	}, nil, nil
+}
+
+func (s *StorageClient) UploadToSignedURL(ctx context.Context, bucket string, path string, token string, body io.Reader, options UploadOptions) (*UploadResult, *StorageError, error) {
+	uri := objectURI("object/upload/sign", bucket, path) + "?token=" + url.QueryEscape(token)
+
+	return s.upload(ctx, http.MethodPut, uri, body, options)
+}
+
+func (s *StorageClient) GetPublicURL(bucket string, path string) string {
+	return fmt.Sprintf("%s/%s/%s", s.baseURL, StorageEndpoint, objectURI("object/public", bucket, path))
+}
+
+func (s *StorageClient) upload(ctx context.Context, method string, uri string, body io.Reader, options UploadOptions) (*UploadResult, *StorageError, error) {
+	req, err := s.newRequest(ctx, method, uri, body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if options.ContentType == "" {
+		options.ContentType = "application/octet-stream"
+	}
+
+	if options.CacheControl == "" {
+		options.CacheControl = "3600"
+	}
+
+	req.Header.Set("Content-Type", options.ContentType)
+	req.Header.Set("Cache-Control", "max-age="+options.CacheControl)
+	req.Header.Set("x-upsert", strconv.FormatBool(options.Upsert))
+
+	if options.ContentLength > 0 {
+		req.ContentLength = options.ContentLength
+	}
+
+	res := UploadResult{}
+	errRes := StorageError{}
+	hasCustomError, err := s.client.sendCustomRequest(req, &res, &errRes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if hasCustomError {
+		return nil, &errRes, nil
+	}
+
+	return &res, nil, nil
+}
+
+func (s *StorageClient) sendJSON(ctx context.Context, method string, uri string, data any, successValue any) (*StorageError, error) {
+	var body io.Reader
+	if data != nil {
+		reqBody, err := json.Marshal(data)
+		if err != nil {
+			return nil, err
+		}
+
+		body = bytes.NewReader(reqBody)
+	}
+
+	req, err := s.newRequest(ctx, method, uri, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	errRes := StorageError{}
+	hasCustomError, err := s.client.sendCustomRequest(req, successValue, &errRes)
+	if err != nil {
+		return nil, err
+	}
+
+	if hasCustomError {
+		return &errRes, nil
+	}
+
+	return nil, nil
+}
+
+func (s *StorageClient) newRequest(ctx context.Context, method string, uri string, body io.Reader) (*http.Request, error) {
+	req, err := s.client.newRawRequestWithContext(ctx, method, fmt.Sprintf("%s/%s", StorageEndpoint, uri), body)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.token != "" {
+		injectAuthorizationHeader(req, s.token)
+	}
+
+	return req, nil
+}
+
+func (s *StorageClient) absoluteURL(signedURL string) string {
+	if strings.HasPrefix(signedURL, "http://") || strings.HasPrefix(signedURL, "https://") {
+		return signedURL
+	}
+
+	return fmt.Sprintf("%s/%s/%s", s.baseURL, StorageEndpoint, strings.TrimPrefix(signedURL, "/"))
+}
+
+func decodeStorageError(res *http.Response) (*StorageError, error) {
+	errRes := StorageError{}
+	if err := json.NewDecoder(res.Body).Decode(&errRes); err != nil {
+		return nil, fmt.Errorf("unknown error, status code: %d", res.StatusCode)
+	}
+
+	return &errRes, nil
+}
+
+func objectTransfer(bucket string, from string, to string) map[string]string {
+	return map[string]string{
+		"bucketId":       bucket,
+		"sourceKey":      from,
+		"destinationKey": to,
+	}
+}
+
+func objectURI(prefix string, bucket string, path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	return fmt.Sprintf("%s/%s/%s", prefix, bucket, strings.Join(segments, "/"))
+}
@@ -0,0 +1,225 @@
+package supabase
+
+import (
+	"context"
+	"github.com/go-playground/assert/v2"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newStorageServer(t *testing.T, status int, body string) (*StorageClient, *recordedRequest) {
+	recorded := &recordedRequest{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+
+		recorded.method = r.Method
+		recorded.path = r.URL.EscapedPath()
+		recorded.query = r.URL.RawQuery
+		recorded.headers = r.Header.Clone()
+		recorded.body = string(data)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	return NewStorageClient(server.URL, "service-key"), recorded
+}
+
+func TestStorageUpload(t *testing.T) {
+	client, recorded := newStorageServer(t, http.StatusOK, `{"Id":"abc","Key":"attachments/123/my file.png"}`)
+
+	res, storageErr, err := client.WithToken("user-jwt").Upload(context.Background(), "attachments", "123/my file.png", strings.NewReader("image-bytes"), UploadOptions{
+		ContentType: "image/png",
+		Upsert:      true,
+	})
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, storageErr, (*StorageError)(nil))
+	assert.Equal(t, res, &UploadResult{ID: "abc", Key: "attachments/123/my file.png"})
+	assert.Equal(t, recorded.method, http.MethodPost)
+	assert.Equal(t, recorded.path, "/storage/v1/object/attachments/123/my%20file.png")
+	assert.Equal(t, recorded.body, "image-bytes")
+	assert.Equal(t, recorded.headers.Get("Content-Type"), "image/png")
+	assert.Equal(t, recorded.headers.Get("x-upsert"), "true")
+	assert.Equal(t, recorded.headers.Get("Authorization"), "Bearer user-jwt")
+	assert.Equal(t, recorded.headers.Get("apikey"), "service-key")
+}
+
+func TestStorageUploadError(t *testing.T) {
+	client, _ := newStorageServer(t, http.StatusBadRequest, `{"statusCode":"409","error":"Duplicate","message":"The resource already exists"}`)
+
+	res, storageErr, err := client.Upload(context.Background(), "attachments", "a.png", strings.NewReader("x"), UploadOptions{})
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, res, (*UploadResult)(nil))
+	assert.Equal(t, storageErr.Status(), http.StatusConflict)
+	assert.Equal(t, storageErr.Message, "The resource already exists")
+}
+
+func TestStorageDownload(t *testing.T) {
+	client, recorded := newStorageServer(t, http.StatusOK, "file-contents")
+
+	res, storageErr, err := client.Download(context.Background(), "attachments", "123/a.txt")
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, storageErr, (*StorageError)(nil))
+
+	data, _ := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+
+	assert.Equal(t, string(data), "file-contents")
+	assert.Equal(t, recorded.method, http.MethodGet)
+	assert.Equal(t, recorded.path, "/storage/v1/object/attachments/123/a.txt")
+}
+
+func TestStorageDownloadNotFound(t *testing.T) {
+	client, _ := newStorageServer(t, http.StatusNotFound, `{"statusCode":"404","error":"not_found","message":"Object not found"}`)
+
+	res, storageErr, err := client.Download(context.Background(), "attachments", "missing.txt")
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, res, (*DownloadResult)(nil))
+	assert.Equal(t, storageErr.Status(), http.StatusNotFound)
+}
+
+func TestStorageJSONOperations(t *testing.T) {
+	tests := []struct {
+		name         string
+		call         func(client *StorageClient) (*StorageError, error)
+		expectedVerb string
+		expectedPath string
+		expectedBody string
+	}{
+		{
+			name: "Create bucket",
+			call: func(client *StorageClient) (*StorageError, error) {
+				return client.CreateBucket(context.Background(), BucketOptions{ID: "attachments"})
+			},
+			expectedVerb: http.MethodPost,
+			expectedPath: "/storage/v1/bucket",
+			expectedBody: `{"id":"attachments","name":"attachments","public":false}`,
+		},
+		{
+			name: "Empty bucket",
+			call: func(client *StorageClient) (*StorageError, error) {
+				return client.EmptyBucket(context.Background(), "attachments")
+			},
+			expectedVerb: http.MethodPost,
+			expectedPath: "/storage/v1/bucket/attachments/empty",
+			expectedBody: `{}`,
+		},
+		{
+			name: "Delete bucket",
+			call: func(client *StorageClient) (*StorageError, error) {
+				return client.DeleteBucket(context.Background(), "attachments")
+			},
+			expectedVerb: http.MethodDelete,
+			expectedPath: "/storage/v1/bucket/attachments",
+			expectedBody: "",
+		},
+		{
+			name: "Move object",
+			call: func(client *StorageClient) (*StorageError, error) {
+				return client.Move(context.Background(), "attachments", "123/a.txt", "123/b.txt")
+			},
+			expectedVerb: http.MethodPost,
+			expectedPath: "/storage/v1/object/move",
+			expectedBody: `{"bucketId":"attachments","destinationKey":"123/b.txt","sourceKey":"123/a.txt"}`,
+		},
+		{
+			name: "Copy object",
+			call: func(client *StorageClient) (*StorageError, error) {
+				return client.Copy(context.Background(), "attachments", "123/a.txt", "123/c.txt")
+			},
+			expectedVerb: http.MethodPost,
+			expectedPath: "/storage/v1/object/copy",
+			expectedBody: `{"bucketId":"attachments","destinationKey":"123/c.txt","sourceKey":"123/a.txt"}`,
+		},
+		{
+			name: "Remove objects",
+			call: func(client *StorageClient) (*StorageError, error) {
+				_, storageErr, err := client.Remove(context.Background(), "attachments", []string{"123/a.txt"})
+				return storageErr, err
+			},
+			expectedVerb: http.MethodDelete,
+			expectedPath: "/storage/v1/object/attachments",
+			expectedBody: `{"prefixes":["123/a.txt"]}`,
+		},
+		{
+			name: "List objects",
+			call: func(client *StorageClient) (*StorageError, error) {
+				_, storageErr, err := client.List(context.Background(), "attachments", "123/", ListOptions{
+					Limit:  10,
+					SortBy: &SortBy{Column: "name", Order: "asc"},
+				})
+				return storageErr, err
+			},
+			expectedVerb: http.MethodPost,
+			expectedPath: "/storage/v1/object/list/attachments",
+			expectedBody: `{"prefix":"123/","limit":10,"sortBy":{"column":"name","order":"asc"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, recorded := newStorageServer(t, http.StatusOK, `[]`)
+
+			storageErr, err := tt.call(client)
+
+			assert.Equal(t, err, nil)
+			assert.Equal(t, storageErr, (*StorageError)(nil))
+			assert.Equal(t, recorded.method, tt.expectedVerb)
+			assert.Equal(t, recorded.path, tt.expectedPath)
+			assert.Equal(t, recorded.body, tt.expectedBody)
+		})
+	}
+}
+
+func TestStorageCreateSignedURL(t *testing.T) {
+	client, recorded := newStorageServer(t, http.StatusOK, `{"signedURL":"/object/sign/attachments/123/a.txt?token=abc"}`)
+
+	signedURL, storageErr, err := client.CreateSignedURL(context.Background(), "attachments", "123/a.txt", 60)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, storageErr, (*StorageError)(nil))
+	assert.Equal(t, signedURL, client.baseURL+"/storage/v1/object/sign/attachments/123/a.txt?token=abc")
+	assert.Equal(t, recorded.path, "/storage/v1/object/sign/attachments/123/a.txt")
+	assert.Equal(t, recorded.body, `{"expiresIn":60}`)
+}
+
+func TestStorageCreateSignedUploadURL(t *testing.T) {
+	client, recorded := newStorageServer(t, http.StatusOK, `{"url":"/object/upload/sign/attachments/123/a.txt?token=upload-token"}`)
+
+	signed, storageErr, err := client.CreateSignedUploadURL(context.Background(), "attachments", "123/a.txt")
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, storageErr, (*StorageError)(nil))
+	assert.Equal(t, signed.Token, "upload-token")
+	assert.Equal(t, signed.Path, "123/a.txt")
+	assert.Equal(t, signed.URL, client.baseURL+"/storage/v1/object/upload/sign/attachments/123/a.txt?token=upload-token")
+	assert.Equal(t, recorded.path, "/storage/v1/object/upload/sign/attachments/123/a.txt")
+}
+
+func TestStorageUploadToSignedURL(t *testing.T) {
+	client, recorded := newStorageServer(t, http.StatusOK, `{"Key":"attachments/123/a.txt"}`)
+
+	res, storageErr, err := client.UploadToSignedURL(context.Background(), "attachments", "123/a.txt", "upload-token", strings.NewReader("data"), UploadOptions{ContentType: "text/plain"})
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, storageErr, (*StorageError)(nil))
+	assert.Equal(t, res.Key, "attachments/123/a.txt")
+	assert.Equal(t, recorded.method, http.MethodPut)
+	assert.Equal(t, recorded.query, "token=upload-token")
+}
+
+func TestStorageGetPublicURL(t *testing.T) {
+	client := NewStorageClient("http://localhost", "key")
+
+	assert.Equal(t, client.GetPublicURL("avatars", "/123/me.png"), "http://localhost/storage/v1/object/public/avatars/123/me.png")
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"time"
 )
@@ -22,6 +23,8 @@ type HttpClientInterface interface {
 type SupabaseClientInterface interface {
 	sendCustomRequest(req *http.Request, successValue interface{}, errorValue interface{}) (bool, error)
 	newRequestWithContext(method string, reqURL string, data any) (*http.Request, error)
+	newRawRequestWithContext(ctx context.Context, method string, reqURL string, body io.Reader) (*http.Request, error)
+	send(req *http.Request) (*http.Response, error)
 }
 
 type SupabaseClient struct {
@@ -46,9 +49,14 @@ func injectAuthorizationHeader(req *http.Request, value string) {
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", value))
 }
 
-func (c *SupabaseClient) sendCustomRequest(req *http.Request, successValue interface{}, errorValue interface{}) (bool, error) {
+func (c *SupabaseClient) send(req *http.Request) (*http.Response, error) {
 	req.Header.Set("apikey", c.apiKey)
-	res, err := c.HTTPClient.Do(req)
+
+	return c.HTTPClient.Do(req)
+}
+
+func (c *SupabaseClient) sendCustomRequest(req *http.Request, successValue interface{}, errorValue interface{}) (bool, error) {
+	res, err := c.send(req)
 	if err != nil {
 		return true, err
 	}
@@ -90,3 +98,11 @@ func (c *SupabaseClient) newRequestWithContext(method string, uri string, data a
 
 	return req, nil
 }
+
+// newRawRequestWithContext builds a request whose body is streamed as is,
+// leaving content negotiation headers to the caller.
+func (c *SupabaseClient) newRawRequestWithContext(ctx context.Context, method string, uri string, body io.Reader) (*http.Request, error) {
+	reqURL := fmt.Sprintf("%s/%s", c.BaseURL, uri)
+
+	return http.NewRequestWithContext(ctx, method, reqURL, body)
+}
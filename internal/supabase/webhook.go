@@ -0,0 +1,109 @@
+package supabase
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	WebhookIDHeader        = "webhook-id"
+	WebhookTimestampHeader = "webhook-timestamp"
+	WebhookSignatureHeader = "webhook-signature"
+
+	webhookSecretPrefix     = "whsec_"
+	webhookSignatureVersion = "v1"
+	defaultWebhookTolerance = 5 * time.Minute
+)
+
+var (
+	ErrWebhookMissingHeaders   = errors.New("missing webhook headers")
+	ErrWebhookInvalidTimestamp = errors.New("invalid webhook timestamp")
+	ErrWebhookTimestampExpired = errors.New("webhook timestamp outside of tolerance")
+	ErrWebhookInvalidSignature = errors.New("invalid webhook signature")
+)
+
+// WebhookVerifier checks Standard Webhooks signatures as sent by Supabase Auth
+// hooks. Timestamps outside the tolerance are rejected to prevent replays.
+type WebhookVerifier struct {
+	secret    []byte
+	tolerance time.Duration
+	now       func() time.Time
+}
+
+func NewWebhookVerifier(secret string, tolerance time.Duration) (*WebhookVerifier, error) {
+	secret = strings.TrimPrefix(secret, webhookSignatureVersion+",")
+	secret = strings.TrimPrefix(secret, webhookSecretPrefix)
+
+	key, err := base64.StdEncoding.DecodeString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook secret: %w", err)
+	}
+
+	if tolerance <= 0 {
+		tolerance = defaultWebhookTolerance
+	}
+
+	return &WebhookVerifier{
+		secret:    key,
+		tolerance: tolerance,
+		now:       time.Now,
+	}, nil
+}
+
+func (v *WebhookVerifier) Verify(headers http.Header, body []byte) error {
+	id := headers.Get(WebhookIDHeader)
+	timestamp := headers.Get(WebhookTimestampHeader)
+	signatures := headers.Get(WebhookSignatureHeader)
+
+	if id == "" || timestamp == "" || signatures == "" {
+		return ErrWebhookMissingHeaders
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrWebhookInvalidTimestamp
+	}
+
+	sent := time.Unix(seconds, 0)
+	now := v.now()
+	if sent.Before(now.Add(-v.tolerance)) || sent.After(now.Add(v.tolerance)) {
+		return ErrWebhookTimestampExpired
+	}
+
+	expected := v.sign(id, timestamp, body)
+
+	for _, signature := range strings.Fields(signatures) {
+		version, value, found := strings.Cut(signature, ",")
+		if !found || version != webhookSignatureVersion {
+			continue
+		}
+
+		if hmac.Equal([]byte(value), []byte(expected)) {
+			return nil
+		}
+	}
+
+	return ErrWebhookInvalidSignature
+}
+
+// Sign returns the webhook-signature header value for the given message.
+func (v *WebhookVerifier) Sign(id string, timestamp time.Time, body []byte) string {
+	signature := v.sign(id, strconv.FormatInt(timestamp.Unix(), 10), body)
+
+	return fmt.Sprintf("%s,%s", webhookSignatureVersion, signature)
+}
+
+func (v *WebhookVerifier) sign(id string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, v.secret)
+	mac.Write([]byte(fmt.Sprintf("%s.%s.", id, timestamp)))
+	mac.Write(body)
+
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
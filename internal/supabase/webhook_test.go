@@ -0,0 +1,121 @@
+package supabase
+
+import (
+	"github.com/go-playground/assert/v2"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const testWebhookSecret = "v1,whsec_c2VjcmV0LWtleS1mb3ItdGVzdHM="
+
+func TestNewWebhookVerifier(t *testing.T) {
+	verifier, err := NewWebhookVerifier(testWebhookSecret, 0)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, string(verifier.secret), "secret-key-for-tests")
+	assert.Equal(t, verifier.tolerance, 5*time.Minute)
+}
+
+func TestNewWebhookVerifierInvalidSecret(t *testing.T) {
+	verifier, err := NewWebhookVerifier("v1,whsec_!!!", 0)
+
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, verifier, (*WebhookVerifier)(nil))
+}
+
+func TestWebhookVerifierVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"user_id":"123"}`)
+
+	verifier, _ := NewWebhookVerifier(testWebhookSecret, time.Minute)
+	verifier.now = func() time.Time { return now }
+
+	other, _ := NewWebhookVerifier("v1,whsec_b3RoZXItc2VjcmV0", time.Minute)
+
+	tests := []struct {
+		name      string
+		id        string
+		timestamp string
+		signature string
+		body      []byte
+		expected  error
+	}{
+		{
+			name:      "Valid signature",
+			id:        "msg_1",
+			timestamp: strconv.FormatInt(now.Unix(), 10),
+			signature: verifier.Sign("msg_1", now, body),
+			body:      body,
+			expected:  nil,
+		},
+		{
+			name:      "Valid signature amongst several",
+			id:        "msg_1",
+			timestamp: strconv.FormatInt(now.Unix(), 10),
+			signature: other.Sign("msg_1", now, body) + " " + verifier.Sign("msg_1", now, body),
+			body:      body,
+			expected:  nil,
+		},
+		{
+			name:      "Missing headers",
+			id:        "",
+			timestamp: strconv.FormatInt(now.Unix(), 10),
+			signature: verifier.Sign("msg_1", now, body),
+			body:      body,
+			expected:  ErrWebhookMissingHeaders,
+		},
+		{
+			name:      "Invalid timestamp",
+			id:        "msg_1",
+			timestamp: "yesterday",
+			signature: verifier.Sign("msg_1", now, body),
+			body:      body,
+			expected:  ErrWebhookInvalidTimestamp,
+		},
+		{
+			name:      "Replayed message",
+			id:        "msg_1",
+			timestamp: strconv.FormatInt(now.Add(-2*time.Minute).Unix(), 10),
+			signature: verifier.Sign("msg_1", now.Add(-2*time.Minute), body),
+			body:      body,
+			expected:  ErrWebhookTimestampExpired,
+		},
+		{
+			name:      "Timestamp in the future",
+			id:        "msg_1",
+			timestamp: strconv.FormatInt(now.Add(2*time.Minute).Unix(), 10),
+			signature: verifier.Sign("msg_1", now.Add(2*time.Minute), body),
+			body:      body,
+			expected:  ErrWebhookTimestampExpired,
+		},
+		{
+			name:      "Tampered body",
+			id:        "msg_1",
+			timestamp: strconv.FormatInt(now.Unix(), 10),
+			signature: verifier.Sign("msg_1", now, body),
+			body:      []byte(`{"user_id":"456"}`),
+			expected:  ErrWebhookInvalidSignature,
+		},
+		{
+			name:      "Wrong secret",
+			id:        "msg_1",
+			timestamp: strconv.FormatInt(now.Unix(), 10),
+			signature: other.Sign("msg_1", now, body),
+			body:      body,
+			expected:  ErrWebhookInvalidSignature,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := http.Header{}
+			headers.Set(WebhookIDHeader, tt.id)
+			headers.Set(WebhookTimestampHeader, tt.timestamp)
+			headers.Set(WebhookSignatureHeader, tt.signature)
+
+			assert.Equal(t, verifier.Verify(headers, tt.body), tt.expected)
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package validation
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BreachChecker reports whether a password is known to have been breached.
+type BreachChecker interface {
+	Breached(password string) (bool, error)
+}
+
+// PwnedPasswords checks passwords offline against a local copy of the Pwned
+// Passwords range files, as written by the haveibeenpwned-downloader: one
+// <PREFIX>.txt file per five hex character SHA-1 prefix, listing the
+// SUFFIX:COUNT of the hashes sharing it. Only the file of the prefix is read,
+// as with the k-anonymity range API.
+type PwnedPasswords struct {
+	dir string
+}
+
+func NewPwnedPasswords(dir string) (*PwnedPasswords, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("pwned passwords: %w", err)
+	}
+
+	if !info.IsDir() {
+		return nil, fmt.Errorf("pwned passwords: %s is not a directory", dir)
+	}
+
+	return &PwnedPasswords{dir: dir}, nil
+}
+
+func (p *PwnedPasswords) Breached(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	file, err := os.Open(filepath.Join(p.dir, prefix+".txt"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		candidate, count, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+
+		// Padding entries, added to hide the size of a range, have a zero count.
+		if strings.EqualFold(candidate, suffix) && count != "0" {
+			return true, nil
+		}
+	}
+
+	return false, scanner.Err()
+}
@@ -0,0 +1,212 @@
+package validation
+
+import (
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// passwordTags are run in order by the "password" tag, which fails with the
+// first rule broken.
+var passwordTags = []string{
+	"password_length",
+	"password_classes",
+	"password_entropy",
+	"password_email",
+	"password_breached",
+}
+
+// PasswordPolicy configures the "password" tag. MinLength counts characters
+// and MaxLength bytes, the limit of bcrypt being in bytes. MinClasses counts
+// the lowercase, uppercase, digit and symbol classes used, and MinEntropy is
+// in bits, as estimated by PasswordEntropy. DisallowEmail rejects passwords
+// containing the Email field of the same struct, or a part of it. Breached is
+// optional.
+type PasswordPolicy struct {
+	MinLength     int
+	MaxLength     int
+	MinClasses    int
+	MinEntropy    float64
+	DisallowEmail bool
+	Breached      BreachChecker
+}
+
+// DefaultPasswordPolicy is used unless WithPasswordPolicy is given. The
+// maximum is the longest password Supabase Auth accepts.
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{MinLength: 8, MaxLength: 72, DisallowEmail: true}
+}
+
+// WithPasswordPolicy sets the rules of the "password" tag.
+func WithPasswordPolicy(policy PasswordPolicy) Option {
+	return func(o *options) {
+		o.passwordPolicy = policy
+	}
+}
+
+func registerPasswordPolicy(validate *validator.Validate, policy PasswordPolicy) {
+	_ = validate.RegisterValidation("password_length", func(fl validator.FieldLevel) bool {
+		password := fl.Field().String()
+
+		return utf8.RuneCountInString(password) >= policy.MinLength && (policy.MaxLength == 0 || len(password) <= policy.MaxLength)
+	})
+	_ = validate.RegisterValidation("password_classes", func(fl validator.FieldLevel) bool {
+		return passwordClasses(fl.Field().String()) >= policy.MinClasses
+	})
+	_ = validate.RegisterValidation("password_entropy", func(fl validator.FieldLevel) bool {
+		return PasswordEntropy(fl.Field().String()) >= policy.MinEntropy
+	})
+	_ = validate.RegisterValidation("password_email", func(fl validator.FieldLevel) bool {
+		if !policy.DisallowEmail {
+			return true
+		}
+
+		email := fl.Parent().FieldByName("Email")
+		if !email.IsValid() || email.Kind() != fl.Field().Kind() {
+			return true
+		}
+
+		return !containsEmail(fl.Field().String(), email.String())
+	})
+	_ = validate.RegisterValidation("password_breached", func(fl validator.FieldLevel) bool {
+		if policy.Breached == nil {
+			return true
+		}
+
+		// The dataset being unavailable must not lock users out.
+		breached, err := policy.Breached.Breached(fl.Field().String())
+
+		return err != nil || !breached
+	})
+
+	validate.RegisterAlias("password", strings.Join(passwordTags, ","))
+}
+
+func registerPasswordTranslations(validate *validator.Validate, translator ut.Translator, policy PasswordPolicy) {
+	messages := map[string]string{
+		"password_length":   "{0} must be at least {1} characters and at most {2} bytes long",
+		"password_classes":  "{0} must mix at least {1} of lowercase letters, uppercase letters, digits and symbols",
+		"password_entropy":  "{0} is too easy to guess, use a longer or more varied password",
+		"password_email":    "{0} must not contain your email address",
+		"password_breached": "{0} has appeared in a data breach, choose another password",
+	}
+	if policy.MaxLength == 0 {
+		messages["password_length"] = "{0} must be at least {1} characters long"
+	}
+
+	params := map[string][]string{
+		"password_length":  {strconv.Itoa(policy.MinLength), strconv.Itoa(policy.MaxLength)},
+		"password_classes": {strconv.Itoa(policy.MinClasses)},
+	}
+
+	_ = validate.RegisterTranslation("password", translator, func(ut ut.Translator) error {
+		for tag, message := range messages {
+			if err := ut.Add(tag, message, false); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, func(ut ut.Translator, fe validator.FieldError) string {
+		message, _ := ut.T(fe.ActualTag(), append([]string{fe.Field()}, params[fe.ActualTag()]...)...)
+		return message
+	})
+}
+
+// PasswordEntropy estimates the strength of a password in bits, as its
+// length times log2 of the size of the character classes it uses. Each
+// character counts at most twice, so repeating characters adds little.
+func PasswordEntropy(password string) float64 {
+	pool := 0
+	for class, used := range passwordClassSet(password) {
+		if used {
+			pool += classSizes[class]
+		}
+	}
+
+	if pool == 0 {
+		return 0
+	}
+
+	seen := map[rune]int{}
+	length := 0
+	for _, r := range password {
+		if seen[r] < 2 {
+			length++
+		}
+
+		seen[r]++
+	}
+
+	return float64(length) * math.Log2(float64(pool))
+}
+
+const (
+	classLower = iota
+	classUpper
+	classDigit
+	classSymbol
+)
+
+// classSizes are the number of characters of each class, counting ASCII
+// symbols only.
+var classSizes = [...]int{classLower: 26, classUpper: 26, classDigit: 10, classSymbol: 33}
+
+// passwordClassSet reports the classes used by the password. Letters outside
+// ASCII count as their case, and characters without a case as symbols.
+func passwordClassSet(password string) [4]bool {
+	var set [4]bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			set[classLower] = true
+		case unicode.IsUpper(r):
+			set[classUpper] = true
+		case unicode.IsDigit(r):
+			set[classDigit] = true
+		default:
+			set[classSymbol] = true
+		}
+	}
+
+	return set
+}
+
+func passwordClasses(password string) int {
+	count := 0
+	for _, used := range passwordClassSet(password) {
+		if used {
+			count++
+		}
+	}
+
+	return count
+}
+
+// containsEmail reports whether the password contains the email address, its
+// local part or any word of at least three characters of the local part.
+func containsEmail(password string, email string) bool {
+	password = strings.ToLower(password)
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	local, _, _ := strings.Cut(email, "@")
+	if local == "" {
+		return false
+	}
+
+	parts := strings.FieldsFunc(local, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for _, part := range append(parts, local) {
+		if utf8.RuneCountInString(part) >= 3 && strings.Contains(password, part) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,82 @@
+package validation
+
+import (
+	"github.com/go-playground/assert/v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type PasswordStruct struct {
+	Email    string `json:"email"`
+	Password string `json:"password" validate:"required,password"`
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	dir := t.TempDir()
+
+	// SHA-1 of "password": 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8.
+	dataset := "1D2DA4053E34E76F6576ED1DA63134B5E2A:2\r\n1E4C9B93F3F0682250B6CF8331B7EE68FD8:9545824\r\n"
+	assert.Equal(t, os.WriteFile(filepath.Join(dir, "5BAA6.txt"), []byte(dataset), 0o600), nil)
+
+	breached, err := NewPwnedPasswords(dir)
+	assert.Equal(t, err, nil)
+
+	sut := NewValidator(WithPasswordPolicy(PasswordPolicy{
+		MinLength:     8,
+		MaxLength:     72,
+		MinClasses:    3,
+		MinEntropy:    40,
+		DisallowEmail: true,
+		Breached:      breached,
+	}))
+
+	tests := []struct {
+		name     string
+		password string
+		message  string
+	}{
+		{"Strong", "Correct-Horse-42", ""},
+		{"Too short", "Ab1!", "password must be at least 8 characters and at most 72 bytes long"},
+		{"Short multibyte", "Ééé-42", "password must be at least 8 characters and at most 72 bytes long"},
+		{"Multibyte within 72 bytes", "Correct-Horse-42-" + strings.Repeat("é", 27), ""},
+		{"Multibyte over 72 bytes", "Correct-Horse-42-" + strings.Repeat("é", 28), "password must be at least 8 characters and at most 72 bytes long"},
+		{"Too few classes", "correcthorsebattery", "password must mix at least 3 of lowercase letters, uppercase letters, digits and symbols"},
+		{"Repeated characters", "Aa1Aa1Aa1Aa1", "password is too easy to guess, use a longer or more varied password"},
+		{"Email", "Jane.Doe-2024!", "password must not contain your email address"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := sut.Validate(PasswordStruct{Email: "jane.doe@example.com", Password: tt.password})
+
+			if tt.message == "" {
+				assert.Equal(t, errs, ValidationErrors{})
+				return
+			}
+
+			assert.Equal(t, len(errs.ValidationErrors), 1)
+			assert.Equal(t, errs.ValidationErrors[0].Field, "password")
+			assert.Equal(t, errs.ValidationErrors[0].Message, tt.message)
+		})
+	}
+
+	lenient := NewValidator(WithPasswordPolicy(PasswordPolicy{MinLength: 8, Breached: breached}))
+	errs := lenient.Validate(PasswordStruct{Password: "password"})
+
+	assert.Equal(t, errs.ValidationErrors[0].Message, "password has appeared in a data breach, choose another password")
+	assert.Equal(t, lenient.Validate(PasswordStruct{Password: "unlisted-password"}), ValidationErrors{})
+}
+
+func TestPasswordEntropy(t *testing.T) {
+	assert.Equal(t, PasswordEntropy(""), 0.0)
+	assert.Equal(t, PasswordEntropy("aaaa"), PasswordEntropy("aa"))
+	assert.Equal(t, PasswordEntropy("abcd") < PasswordEntropy("aBc1"), true)
+}
+
+func TestNewPwnedPasswordsRequiresDirectory(t *testing.T) {
+	_, err := NewPwnedPasswords(filepath.Join(t.TempDir(), "missing"))
+
+	assert.NotEqual(t, err, nil)
+}
@@ -28,10 +28,30 @@ type ValidatorInterface interface {
 	Validate(data interface{}) ValidationErrors
 }
 
-func NewValidator() ValidatorInterface {
+type options struct {
+	tagName        string
+	passwordPolicy PasswordPolicy
+}
+
+type Option func(*options)
+
+// WithTagName names fields after another struct tag than "json", e.g. "yaml"
+// when validating configuration.
+func WithTagName(tagName string) Option {
+	return func(o *options) {
+		o.tagName = tagName
+	}
+}
+
+func NewValidator(opts ...Option) ValidatorInterface {
+	o := options{tagName: "json", passwordPolicy: DefaultPasswordPolicy()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	validator := validator.New(validator.WithRequiredStructEnabled())
 	validator.RegisterTagNameFunc(func(fld reflect.StructField) string {
-		name := strings.SplitN(fld.Tag.Get("json"), ",", 2)[0]
+		name := strings.SplitN(fld.Tag.Get(o.tagName), ",", 2)[0]
 
 		if name == "-" {
 			return ""
@@ -40,7 +60,9 @@ func NewValidator() ValidatorInterface {
 		return name
 	})
 
+	registerPasswordPolicy(validator, o.passwordPolicy)
 	translator := registerTranslator(validator)
+	registerPasswordTranslations(validator, translator, o.passwordPolicy)
 
 	return &Validator{
 		validator:  validator,
@@ -60,19 +82,36 @@ func (v *Validator) Validate(data interface{}) ValidationErrors {
 	for _, err := range errs.(validator.ValidationErrors) {
 		validationErrors.ValidationErrors = append(validationErrors.ValidationErrors, ValidationError{
 			Message: err.Translate(v.translator),
-			Field:   err.Field(),
+			Field:   fieldPath(err),
 		})
 	}
 
 	return validationErrors
 }
 
-func registerTranslator(validator *validator.Validate) ut.Translator {
+// fieldPath returns the dotted path of the field below the validated struct,
+// e.g. "server.port", so nested fields can be told apart.
+func fieldPath(err validator.FieldError) string {
+	_, path, found := strings.Cut(err.Namespace(), ".")
+	if !found {
+		return err.Field()
+	}
+
+	return path
+}
+
+func registerTranslator(validate *validator.Validate) ut.Translator {
 	en := en.New()
 	uni := ut.New(en, en)
 	translator, _ := uni.GetTranslator("en")
 
-	_ = enTranslations.RegisterDefaultTranslations(validator, translator)
+	_ = enTranslations.RegisterDefaultTranslations(validate, translator)
+	_ = validate.RegisterTranslation("timezone", translator, func(ut ut.Translator) error {
+		return ut.Add("timezone", "{0} must be a valid time zone", false)
+	}, func(ut ut.Translator, fe validator.FieldError) string {
+		message, _ := ut.T("timezone", fe.Field())
+		return message
+	})
 
 	return translator
 }
@@ -39,3 +39,18 @@ func TestValidator_Validate_Invalid(t *testing.T) {
 	assert.Equal(t, errs.ValidationErrors[1].Field, "email")
 	assert.Equal(t, errs.ValidationErrors[1].Message, "email must be a valid email address")
 }
+
+type NestedStruct struct {
+	Server struct {
+		Port int `yaml:"port" validate:"min=1"`
+	} `yaml:"server"`
+}
+
+func TestValidator_Validate_NestedWithTagName(t *testing.T) {
+	sut := NewValidator(WithTagName("yaml"))
+	errs := sut.Validate(NestedStruct{})
+
+	assert.Equal(t, len(errs.ValidationErrors), 1)
+	assert.Equal(t, errs.ValidationErrors[0].Field, "server.port")
+	assert.Equal(t, errs.ValidationErrors[0].Message, "port must be 1 or greater")
+}